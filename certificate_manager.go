@@ -0,0 +1,445 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	stdContext "context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"golang.org/x/crypto/ocsp"
+)
+
+// DefaultCertificateReloadInterval is the default interval `CertificateManager` checks certificate files for changes.
+const DefaultCertificateReloadInterval = time.Minute
+
+// DefaultCertificateExpiryWarning is the default duration before certificate expiry `CertificateManager` starts to
+// log warnings.
+const DefaultCertificateExpiryWarning = 30 * 24 * time.Hour
+
+// defaultCertificateLogger is used by `CertificateManager` that has no logger and is not used by Echo instance.
+var defaultCertificateLogger Logger = log.New("echo")
+
+// ErrNoCertificate is returned by `CertificateManager#GetCertificate` when no certificate has been added.
+var ErrNoCertificate = errors.New("no certificate available")
+
+// CertificateFiles defines files of certificate served by `CertificateManager`.
+type CertificateFiles struct {
+	// CertFile is path to PEM encoded certificate (chain). Issuer certificate following the leaf is used to verify
+	// OCSP response.
+	CertFile string
+	// KeyFile is path to PEM encoded private key.
+	KeyFile string
+	// OCSPStapleFile is path to DER encoded OCSP response stapled to TLS handshakes. Stale or invalid response is not
+	// stapled.
+	// Optional.
+	OCSPStapleFile string
+}
+
+// CertificateInfo describes certificate loaded by `CertificateManager`.
+type CertificateInfo struct {
+	CertFile   string
+	Names      []string
+	NotAfter   time.Time
+	OCSPStaple bool
+	LoadedAt   time.Time
+}
+
+// CertificateManager serves TLS certificates loaded from files. Certificate is chosen by SNI server name (wildcard
+// names included), the first added certificate is used when no name matches. Files are checked for changes
+// periodically and reloaded without restart, so certificates can be rotated by another process. Certificate loading,
+// reload failures and approaching expiry are logged.
+//
+// Example:
+//
+//	m := echo.NewCertificateManager(e.Logger)
+//	m.Add(echo.CertificateFiles{CertFile: "example.com.pem", KeyFile: "example.com.key"})
+//	m.Add(echo.CertificateFiles{CertFile: "wildcard.example.org.pem", KeyFile: "wildcard.example.org.key"})
+//	if err := e.StartTLSWithCertificateManager(":443", m); err != http.ErrServerClosed {
+//		e.Logger.Fatal(err)
+//	}
+type CertificateManager struct {
+	// ReloadInterval is interval certificate files are checked for changes by `Watch`.
+	// Optional. Default value DefaultCertificateReloadInterval.
+	ReloadInterval time.Duration
+
+	// ExpiryWarning is duration before certificate expiry warnings are logged. Warning is logged at most once a day
+	// per certificate.
+	// Optional. Default value DefaultCertificateExpiryWarning.
+	ExpiryWarning time.Duration
+
+	logger Logger
+
+	mutex   sync.RWMutex
+	entries []*certificateEntry
+	byName  map[string]*certificateEntry
+
+	timeNow func() time.Time
+}
+
+type certificateEntry struct {
+	files       CertificateFiles
+	certificate *tls.Certificate
+	info        CertificateInfo
+	stamps      [3]fileStamp
+	lastWarning time.Time
+	// ocspNextUpdate is time stapled OCSP response becomes stale. Zero when response does not define it.
+	ocspNextUpdate time.Time
+}
+
+// NewCertificateManager creates new CertificateManager logging to given logger. When logger is nil
+// `Echo#StartTLSWithCertificateManager` sets it to `Echo#Logger` (messages logged before that go to default logger
+// writing to os.Stdout). Zero value of CertificateManager is ready to use as well.
+func NewCertificateManager(logger Logger) *CertificateManager {
+	return &CertificateManager{
+		ReloadInterval: DefaultCertificateReloadInterval,
+		ExpiryWarning:  DefaultCertificateExpiryWarning,
+		logger:         logger,
+		byName:         map[string]*certificateEntry{},
+		timeNow:        time.Now,
+	}
+}
+
+// Add loads certificate from given files and adds it to the manager.
+func (m *CertificateManager) Add(files CertificateFiles) error {
+	entry := &certificateEntry{files: files}
+	if err := m.load(entry); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	m.entries = append(m.entries, entry)
+	m.reindex()
+	m.mutex.Unlock()
+	m.checkExpiry(entry)
+	return nil
+}
+
+// GetCertificate returns certificate for TLS handshake. Can be used as `tls.Config.GetCertificate`.
+func (m *CertificateManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	if len(m.entries) == 0 {
+		return nil, ErrNoCertificate
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if entry, ok := m.byName[name]; ok {
+		return entry.certificate, nil
+	}
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		if entry, ok := m.byName["*"+name[i:]]; ok {
+			return entry.certificate, nil
+		}
+	}
+	return m.entries[0].certificate, nil
+}
+
+// TLSConfig returns TLS config serving certificates of the manager.
+func (m *CertificateManager) TLSConfig() *tls.Config {
+	return &tls.Config{GetCertificate: m.GetCertificate}
+}
+
+func (m *CertificateManager) getLogger() Logger {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	if m.logger == nil {
+		return defaultCertificateLogger
+	}
+	return m.logger
+}
+
+func (m *CertificateManager) now() time.Time {
+	if m.timeNow == nil {
+		return time.Now()
+	}
+	return m.timeNow()
+}
+
+// Certificates returns information about loaded certificates. CertificateManager does not export metrics itself,
+// callers exporting certificate expiry (i.e. as a gauge) should read it from here when metrics are collected.
+func (m *CertificateManager) Certificates() []CertificateInfo {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	infos := make([]CertificateInfo, len(m.entries))
+	for i, entry := range m.entries {
+		infos[i] = entry.info
+	}
+	return infos
+}
+
+// Reload reloads certificates which files have changed since they were loaded, stops stapling OCSP responses that
+// have become stale and logs warnings for certificates near expiry. Certificate that fails to load keeps being served
+// and the first error is returned.
+func (m *CertificateManager) Reload() error {
+	m.mutex.RLock()
+	entries := append([]*certificateEntry(nil), m.entries...)
+	m.mutex.RUnlock()
+
+	var firstErr error
+	changed := false
+	for _, entry := range entries {
+		m.mutex.RLock()
+		files, stamps := entry.files, entry.stamps
+		m.mutex.RUnlock()
+		if current, err := certificateFileStamps(files); err == nil && current == stamps {
+			m.checkOCSPStaple(entry)
+			m.checkExpiry(entry)
+			continue
+		}
+
+		reloaded := &certificateEntry{files: files}
+		if err := m.load(reloaded); err != nil {
+			m.getLogger().Errorj(log.JSON{"message": "tls certificate reload failed", "cert_file": files.CertFile, "error": err.Error()})
+			if firstErr == nil {
+				firstErr = err
+			}
+			// last loaded certificate keeps being served but its OCSP response must not outlive its validity
+			m.checkOCSPStaple(entry)
+			m.checkExpiry(entry)
+			continue
+		}
+		m.mutex.Lock()
+		entry.certificate = reloaded.certificate
+		entry.info = reloaded.info
+		entry.stamps = reloaded.stamps
+		entry.ocspNextUpdate = reloaded.ocspNextUpdate
+		m.mutex.Unlock()
+		changed = true
+		m.checkExpiry(entry)
+	}
+	if changed {
+		m.mutex.Lock()
+		m.reindex()
+		m.mutex.Unlock()
+	}
+	return firstErr
+}
+
+// Watch reloads changed certificates every ReloadInterval until context is done.
+func (m *CertificateManager) Watch(ctx stdContext.Context) {
+	interval := m.ReloadInterval
+	if interval <= 0 {
+		interval = DefaultCertificateReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = m.Reload() // errors are logged
+		}
+	}
+}
+
+// load reads certificate files into entry.
+func (m *CertificateManager) load(entry *certificateEntry) error {
+	files := entry.files
+	// file stamps are read before files so change during loading is detected by next reload
+	stamps, err := certificateFileStamps(files)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+	if err != nil {
+		return err
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return err
+	}
+	cert.Leaf = leaf
+
+	now := m.now()
+	if files.OCSPStapleFile != "" {
+		staple, nextUpdate, err := m.loadOCSPStaple(files.OCSPStapleFile, &cert, now)
+		if err != nil {
+			m.getLogger().Warnj(log.JSON{"message": "ocsp staple not used", "cert_file": files.CertFile, "ocsp_file": files.OCSPStapleFile, "error": err.Error()})
+		}
+		cert.OCSPStaple = staple
+		entry.ocspNextUpdate = nextUpdate
+	}
+
+	names := leaf.DNSNames
+	if len(names) == 0 && leaf.Subject.CommonName != "" {
+		names = []string{leaf.Subject.CommonName}
+	}
+	entry.certificate = &cert
+	entry.stamps = stamps
+	entry.info = CertificateInfo{
+		CertFile:   files.CertFile,
+		Names:      names,
+		NotAfter:   leaf.NotAfter,
+		OCSPStaple: cert.OCSPStaple != nil,
+		LoadedAt:   now,
+	}
+	m.getLogger().Infoj(log.JSON{
+		"message":            "tls certificate loaded",
+		"cert_file":          files.CertFile,
+		"names":              names,
+		"not_after":          leaf.NotAfter,
+		"expires_in_seconds": int64(leaf.NotAfter.Sub(now).Seconds()),
+		"ocsp_staple":        cert.OCSPStaple != nil,
+	})
+	return nil
+}
+
+// loadOCSPStaple reads OCSP response and verifies it is valid for the certificate and not stale. Returns the
+// response and time it becomes stale.
+func (m *CertificateManager) loadOCSPStaple(file string, cert *tls.Certificate, now time.Time) ([]byte, time.Time, error) {
+	der, err := os.ReadFile(file)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var issuer *x509.Certificate
+	if len(cert.Certificate) > 1 {
+		if issuer, err = x509.ParseCertificate(cert.Certificate[1]); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	res, err := ocsp.ParseResponseForCert(der, cert.Leaf, issuer)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if res.Status != ocsp.Good {
+		return nil, time.Time{}, fmt.Errorf("certificate status is not good: %d", res.Status)
+	}
+	if !res.NextUpdate.IsZero() && now.After(res.NextUpdate) {
+		return nil, time.Time{}, fmt.Errorf("ocsp response is stale since %s", res.NextUpdate.Format(time.RFC3339))
+	}
+	return der, res.NextUpdate, nil
+}
+
+// checkOCSPStaple stops stapling OCSP response of unchanged files when it has become stale.
+func (m *CertificateManager) checkOCSPStaple(entry *certificateEntry) {
+	now := m.now()
+
+	m.mutex.Lock()
+	if entry.certificate.OCSPStaple == nil || entry.ocspNextUpdate.IsZero() || !now.After(entry.ocspNextUpdate) {
+		m.mutex.Unlock()
+		return
+	}
+	// certificate may be used by ongoing handshakes so it is replaced with a copy instead of being modified
+	cert := *entry.certificate
+	cert.OCSPStaple = nil
+	entry.certificate = &cert
+	entry.info.OCSPStaple = false
+	files, nextUpdate := entry.files, entry.ocspNextUpdate
+	m.mutex.Unlock()
+
+	m.getLogger().Warnj(log.JSON{
+		"message":   "ocsp staple not used",
+		"cert_file": files.CertFile,
+		"ocsp_file": files.OCSPStapleFile,
+		"error":     fmt.Sprintf("ocsp response is stale since %s", nextUpdate.Format(time.RFC3339)),
+	})
+}
+
+// checkExpiry logs warning when certificate expires within ExpiryWarning.
+func (m *CertificateManager) checkExpiry(entry *certificateEntry) {
+	warning := m.ExpiryWarning
+	if warning <= 0 {
+		warning = DefaultCertificateExpiryWarning
+	}
+	now := m.now()
+
+	m.mutex.Lock()
+	info := entry.info
+	expiresIn := info.NotAfter.Sub(now)
+	if expiresIn > warning || now.Sub(entry.lastWarning) < 24*time.Hour {
+		m.mutex.Unlock()
+		return
+	}
+	entry.lastWarning = now
+	m.mutex.Unlock()
+
+	message := "tls certificate expires soon"
+	if expiresIn <= 0 {
+		message = "tls certificate has expired"
+	}
+	m.getLogger().Warnj(log.JSON{
+		"message":            message,
+		"cert_file":          info.CertFile,
+		"names":              info.Names,
+		"not_after":          info.NotAfter,
+		"expires_in_seconds": int64(expiresIn.Seconds()),
+	})
+}
+
+// reindex rebuilds SNI name index. The first added certificate wins when several certificates have the same name.
+// Must be called with mutex locked.
+func (m *CertificateManager) reindex() {
+	byName := make(map[string]*certificateEntry)
+	for _, entry := range m.entries {
+		for _, name := range entry.info.Names {
+			name = strings.ToLower(name)
+			if _, ok := byName[name]; !ok {
+				byName[name] = entry
+			}
+		}
+	}
+	m.byName = byName
+}
+
+// fileStamp identifies file version by modification time and size.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// certificateFileStamps returns stamps of certificate files.
+func certificateFileStamps(files CertificateFiles) (stamps [3]fileStamp, err error) {
+	for i, file := range []string{files.CertFile, files.KeyFile, files.OCSPStapleFile} {
+		if file == "" {
+			continue
+		}
+		stat, err := os.Stat(file)
+		if err != nil {
+			return stamps, err
+		}
+		stamps[i] = fileStamp{modTime: stat.ModTime(), size: stat.Size()}
+	}
+	return stamps, nil
+}
+
+// StartTLSWithCertificateManager starts an HTTPS server serving certificates of given CertificateManager. Changed
+// certificate files are reloaded until `Echo#Shutdown` begins.
+func (e *Echo) StartTLSWithCertificateManager(address string, m *CertificateManager) error {
+	m.mutex.Lock()
+	if m.logger == nil {
+		m.logger = e.Logger
+	}
+	m.mutex.Unlock()
+
+	e.startupMutex.Lock()
+	s := e.TLSServer
+	s.TLSConfig = m.TLSConfig()
+	e.configureTLS(address)
+	if err := e.configureServer(s); err != nil {
+		e.startupMutex.Unlock()
+		return err
+	}
+	e.startupMutex.Unlock()
+
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-e.ShutdownNotify():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	go m.Watch(ctx)
+	return s.Serve(e.TLSListener)
+}
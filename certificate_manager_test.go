@@ -0,0 +1,310 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	"bytes"
+	stdContext "context"
+	"crypto"
+	"crypto/tls"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ocsp"
+)
+
+func writeTestCertificate(t *testing.T, dir, name string, notAfter time.Time, names ...string) CertificateFiles {
+	certPEM, keyPEM := testCertificate(t, notAfter, names...)
+	files := CertificateFiles{
+		CertFile: filepath.Join(dir, name+".pem"),
+		KeyFile:  filepath.Join(dir, name+".key"),
+	}
+	if err := os.WriteFile(files.CertFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(files.KeyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func testLogger() (Logger, *bytes.Buffer) {
+	buf := new(bytes.Buffer)
+	logger := log.New("test")
+	logger.SetOutput(buf)
+	logger.SetHeader("${level}")
+	return logger, buf
+}
+
+func servedNames(t *testing.T, m *CertificateManager, serverName string) []string {
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: serverName})
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	return cert.Leaf.DNSNames
+}
+
+func TestCertificateManagerSNI(t *testing.T) {
+	dir := t.TempDir()
+	logger, _ := testLogger()
+	m := NewCertificateManager(logger)
+
+	_, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"})
+	assert.Equal(t, ErrNoCertificate, err)
+
+	notAfter := time.Now().Add(365 * 24 * time.Hour)
+	assert.NoError(t, m.Add(writeTestCertificate(t, dir, "default", notAfter, "default.test")))
+	assert.NoError(t, m.Add(writeTestCertificate(t, dir, "example", notAfter, "example.com", "www.example.com")))
+	assert.NoError(t, m.Add(writeTestCertificate(t, dir, "wildcard", notAfter, "*.example.org")))
+
+	assert.Equal(t, []string{"example.com", "www.example.com"}, servedNames(t, m, "WWW.Example.com."))
+	assert.Equal(t, []string{"*.example.org"}, servedNames(t, m, "api.example.org"))
+	assert.Equal(t, []string{"default.test"}, servedNames(t, m, "a.b.example.org"))
+	assert.Equal(t, []string{"default.test"}, servedNames(t, m, ""))
+	assert.Len(t, m.Certificates(), 3)
+
+	err = m.Add(CertificateFiles{CertFile: filepath.Join(dir, "missing.pem"), KeyFile: filepath.Join(dir, "missing.key")})
+	assert.Error(t, err)
+}
+
+func TestCertificateManagerReload(t *testing.T) {
+	dir := t.TempDir()
+	logger, buf := testLogger()
+	m := NewCertificateManager(logger)
+
+	notAfter := time.Now().Add(365 * 24 * time.Hour)
+	files := writeTestCertificate(t, dir, "site", notAfter, "old.example.com")
+	assert.NoError(t, m.Add(files))
+	assert.NoError(t, m.Reload()) // nothing changed
+	assert.Equal(t, []string{"old.example.com"}, servedNames(t, m, "old.example.com"))
+
+	writeTestCertificate(t, dir, "site", notAfter, "new.example.com")
+	assert.NoError(t, m.Reload())
+	assert.Equal(t, []string{"new.example.com"}, servedNames(t, m, "new.example.com"))
+	assert.Equal(t, []string{"new.example.com"}, m.Certificates()[0].Names)
+
+	// broken file keeps serving last loaded certificate
+	assert.NoError(t, os.WriteFile(files.KeyFile, []byte("broken"), 0o600))
+	assert.Error(t, m.Reload())
+	assert.Equal(t, []string{"new.example.com"}, servedNames(t, m, "new.example.com"))
+	assert.Contains(t, buf.String(), "tls certificate reload failed")
+}
+
+func TestCertificateManagerExpiryWarning(t *testing.T) {
+	dir := t.TempDir()
+	logger, buf := testLogger()
+	m := NewCertificateManager(logger)
+
+	assert.NoError(t, m.Add(writeTestCertificate(t, dir, "valid", time.Now().Add(365*24*time.Hour), "valid.test")))
+	assert.NotContains(t, buf.String(), "expires soon")
+
+	assert.NoError(t, m.Add(writeTestCertificate(t, dir, "expiring", time.Now().Add(24*time.Hour), "expiring.test")))
+	assert.Contains(t, buf.String(), `"message":"tls certificate expires soon"`)
+	assert.Contains(t, buf.String(), `"names":["expiring.test"]`)
+
+	// warning is logged at most once a day
+	buf.Reset()
+	assert.NoError(t, m.Reload())
+	assert.NotContains(t, buf.String(), "expires soon")
+}
+
+func TestCertificateManagerOCSPStaple(t *testing.T) {
+	dir := t.TempDir()
+	logger, buf := testLogger()
+	m := NewCertificateManager(logger)
+
+	files := writeTestCertificate(t, dir, "site", time.Now().Add(365*24*time.Hour), "ocsp.test")
+	cert, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+	if !assert.NoError(t, err) {
+		return
+	}
+	staple, err := ocsp.CreateResponse(cert.Leaf, cert.Leaf, ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: cert.Leaf.SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Hour),
+		NextUpdate:   time.Now().Add(time.Hour),
+	}, cert.PrivateKey.(crypto.Signer))
+	if !assert.NoError(t, err) {
+		return
+	}
+	files.OCSPStapleFile = filepath.Join(dir, "site.ocsp")
+	assert.NoError(t, os.WriteFile(files.OCSPStapleFile, staple, 0o600))
+	assert.NoError(t, m.Add(files))
+
+	served, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "ocsp.test"})
+	assert.NoError(t, err)
+	assert.Equal(t, staple, served.OCSPStaple)
+	assert.True(t, m.Certificates()[0].OCSPStaple)
+
+	// invalid staple is not used but certificate is still served
+	assert.NoError(t, os.WriteFile(files.OCSPStapleFile, []byte("invalid"), 0o600))
+	assert.NoError(t, m.Reload())
+	served, err = m.GetCertificate(&tls.ClientHelloInfo{ServerName: "ocsp.test"})
+	assert.NoError(t, err)
+	assert.Nil(t, served.OCSPStaple)
+	assert.Contains(t, buf.String(), "ocsp staple not used")
+}
+
+// writeTestOCSPStaple writes good OCSP response for certificate files valid until nextUpdate.
+func writeTestOCSPStaple(t *testing.T, files *CertificateFiles, thisUpdate, nextUpdate time.Time) []byte {
+	cert, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	staple, err := ocsp.CreateResponse(cert.Leaf, cert.Leaf, ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: cert.Leaf.SerialNumber,
+		ThisUpdate:   thisUpdate,
+		NextUpdate:   nextUpdate,
+	}, cert.PrivateKey.(crypto.Signer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files.OCSPStapleFile = strings.TrimSuffix(files.CertFile, ".pem") + ".ocsp"
+	if err := os.WriteFile(files.OCSPStapleFile, staple, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return staple
+}
+
+func TestCertificateManagerOCSPStapleBecomesStale(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		whenChange  func(files CertificateFiles)
+		expectError bool
+	}{
+		{
+			name:       "files did not change",
+			whenChange: func(files CertificateFiles) {},
+		},
+		{
+			name: "ocsp file was removed",
+			whenChange: func(files CertificateFiles) {
+				_ = os.Remove(files.OCSPStapleFile)
+			},
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			logger, buf := testLogger()
+			now := time.Now()
+			m := NewCertificateManager(logger)
+			m.timeNow = func() time.Time { return now }
+
+			files := writeTestCertificate(t, dir, "site", now.Add(365*24*time.Hour), "ocsp.test")
+			staple := writeTestOCSPStaple(t, &files, now.Add(-time.Hour), now.Add(time.Hour))
+			assert.NoError(t, m.Add(files))
+
+			assert.NoError(t, m.Reload())
+			served, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "ocsp.test"})
+			assert.NoError(t, err)
+			assert.Equal(t, staple, served.OCSPStaple)
+
+			// staple is not used after its next update time even when certificate can not be reloaded
+			tc.whenChange(files)
+			now = now.Add(2 * time.Hour)
+			if err := m.Reload(); tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			stale, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "ocsp.test"})
+			assert.NoError(t, err)
+			assert.Nil(t, stale.OCSPStaple)
+			assert.Equal(t, staple, served.OCSPStaple) // certificate given to earlier handshakes is not modified
+			assert.False(t, m.Certificates()[0].OCSPStaple)
+			assert.Contains(t, buf.String(), "ocsp response is stale since")
+		})
+	}
+}
+
+func TestCertificateManagerZeroValue(t *testing.T) {
+	dir := t.TempDir()
+	files := writeTestCertificate(t, dir, "site", time.Now().Add(365*24*time.Hour), "zero.test")
+
+	for _, m := range []*CertificateManager{NewCertificateManager(nil), {}} {
+		_, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "zero.test"})
+		assert.Equal(t, ErrNoCertificate, err)
+
+		assert.NoError(t, m.Add(files))
+		assert.NoError(t, m.Reload())
+		assert.Equal(t, []string{"zero.test"}, servedNames(t, m, "zero.test"))
+	}
+}
+
+func TestEchoStartTLSWithCertificateManager(t *testing.T) {
+	dir := t.TempDir()
+	e := New()
+	e.HideBanner = true
+	e.HidePort = true
+	logger, _ := testLogger()
+	m := NewCertificateManager(logger)
+	m.ReloadInterval = 10 * time.Millisecond
+	notAfter := time.Now().Add(365 * 24 * time.Hour)
+	assert.NoError(t, m.Add(writeTestCertificate(t, dir, "a", notAfter, "a.test")))
+	assert.NoError(t, m.Add(writeTestCertificate(t, dir, "b", notAfter, "*.b.test")))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- e.StartTLSWithCertificateManager("127.0.0.1:0", m)
+	}()
+	assert.NoError(t, waitForServerStart(e, errCh, true))
+
+	peerNames := func(serverName string) []string {
+		conn, err := tls.Dial("tcp", e.TLSListenerAddr().String(), &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].DNSNames
+	}
+	assert.Equal(t, []string{"a.test"}, peerNames("a.test"))
+	assert.Equal(t, []string{"*.b.test"}, peerNames("x.b.test"))
+
+	// certificate rotated by another process is served without restart
+	writeTestCertificate(t, dir, "a", notAfter, "a.test", "rotated.a.test")
+	assert.Eventually(t, func() bool {
+		return len(peerNames("a.test")) == 2
+	}, time.Second, 20*time.Millisecond)
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, e.Shutdown(ctx))
+	assert.Equal(t, http.ErrServerClosed, <-errCh)
+}
+
+func TestEchoStartTLSWithCertificateManagerUsesEchoLogger(t *testing.T) {
+	dir := t.TempDir()
+	e := New()
+	e.HideBanner = true
+	e.HidePort = true
+	logger, buf := testLogger()
+	e.Logger = logger.(*log.Logger)
+	m := NewCertificateManager(nil)
+	files := writeTestCertificate(t, dir, "a", time.Now().Add(365*24*time.Hour), "a.test")
+	assert.NoError(t, m.Add(files))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- e.StartTLSWithCertificateManager("127.0.0.1:0", m)
+	}()
+	assert.NoError(t, waitForServerStart(e, errCh, true))
+
+	assert.NoError(t, os.WriteFile(files.KeyFile, []byte("broken"), 0o600))
+	assert.Error(t, m.Reload())
+	assert.Contains(t, buf.String(), "tls certificate reload failed")
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, e.Shutdown(ctx))
+	assert.Equal(t, http.ErrServerClosed, <-errCh)
+}
@@ -51,7 +51,8 @@ type Context interface {
 	// SetPath sets the registered path for the handler.
 	SetPath(p string)
 
-	// Param returns path parameter by name.
+	// Param returns path parameter by name. When Echo is mounted under prefix with params (see `Echo#Mount()`)
+	// prefix params are returned too.
 	Param(name string) string
 
 	// ParamNames returns path parameter names.
@@ -211,6 +212,14 @@ type context struct {
 
 	// following fields are set by Router
 	handler HandlerFunc
+	route   *Route
+
+	// hostPNames and hostPValues are set when request host matched host pattern router
+	hostPNames  []string
+	hostPValues []string
+
+	// apiVersion is set by versioned routes
+	apiVersion string
 
 	// path is route path that Router matched. It is empty string where there is no route match.
 	// Route registered with RouteNotFound is considered as a match and path therefore is not empty.
@@ -264,7 +273,12 @@ func (c *context) SetResponse(r *Response) {
 }
 
 func (c *context) IsTLS() bool {
-	return c.request.TLS != nil
+	if c.request.TLS != nil {
+		return true
+	}
+	// connection may be terminated by proxy speaking PROXY protocol, see `Echo.ProxyProtocol`
+	header := ProxyProtocolHeaderFromContext(c.request.Context())
+	return header != nil && header.TLS
 }
 
 func (c *context) IsWebSocket() bool {
@@ -278,6 +292,9 @@ func (c *context) Scheme() string {
 	if c.IsTLS() {
 		return "https"
 	}
+	if scheme := forwardedProto(c.request.Header.Get(HeaderForwarded)); scheme != "" {
+		return scheme
+	}
 	if scheme := c.request.Header.Get(HeaderXForwardedProto); scheme != "" {
 		return scheme
 	}
@@ -333,7 +350,8 @@ func (c *context) Param(name string) string {
 			}
 		}
 	}
-	return ""
+	// path params of the prefix this Echo instance is mounted under (see `Echo#Mount()`)
+	return MountParam(c.request, name)
 }
 
 func (c *context) ParamNames() []string {
@@ -369,6 +387,35 @@ func (c *context) SetParamValues(values ...string) {
 	}
 }
 
+func (c *context) Route() *Route {
+	return c.route
+}
+
+func (c *context) HostParam(name string) string {
+	for i, n := range c.hostPNames {
+		if n == name && i < len(c.hostPValues) {
+			return c.hostPValues[i]
+		}
+	}
+	return ""
+}
+
+func (c *context) HostParamNames() []string {
+	return c.hostPNames
+}
+
+func (c *context) HostParamValues() []string {
+	return c.hostPValues
+}
+
+func (c *context) APIVersion() string {
+	return c.apiVersion
+}
+
+func (c *context) SetAPIVersion(version string) {
+	c.apiVersion = version
+}
+
 func (c *context) QueryParam(name string) string {
 	if c.query == nil {
 		c.query = c.request.URL.Query()
@@ -637,6 +684,10 @@ func (c *context) Logger() Logger {
 	if res != nil {
 		return res
 	}
+	// request scoped logger is not cached as request ID and route may not be known yet (i.e. in `Pre` middlewares)
+	if rl, ok := c.echo.Logger.(RequestScopedLogger); ok {
+		return rl.ForContext(c)
+	}
 	return c.echo.Logger
 }
 
@@ -649,9 +700,13 @@ func (c *context) Reset(r *http.Request, w http.ResponseWriter) {
 	c.response.reset(w)
 	c.query = nil
 	c.handler = NotFoundHandler
+	c.route = nil
 	c.store = nil
 	c.path = ""
 	c.pnames = nil
+	c.hostPNames = nil
+	c.hostPValues = c.hostPValues[:0]
+	c.apiVersion = ""
 	c.logger = nil
 	// NOTE: Don't reset because it has to have length c.echo.maxParam (or bigger) at all times
 	for i := 0; i < len(c.pvalues); i++ {
@@ -937,6 +937,14 @@ func TestContextScheme(t *testing.T) {
 			},
 			"https",
 		},
+		{
+			&context{
+				request: &http.Request{
+					Header: http.Header{HeaderForwarded: []string{`for=192.0.2.60;Proto="https", for=10.0.0.1;proto=http`}},
+				},
+			},
+			"https",
+		},
 		{
 			&context{
 				request: &http.Request{},
@@ -52,7 +52,9 @@ import (
 	"os"
 	"reflect"
 	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/labstack/gommon/color"
@@ -67,7 +69,9 @@ import (
 //
 // Goroutine safety: Do not mutate Echo instance fields after server has started. Accessing these
 // fields from handlers/middlewares and changing field values at the same time leads to data-races.
-// Adding new routes after the server has been started is also not safe!
+// Routes can be added (`Add`, `GET`, `Host` etc.) and removed (`RemoveRoute`) after the server has been started -
+// changes are applied to a copy of the router which then atomically replaces the router used to serve requests.
+// Modifying `Router` instances directly after the server has been started is not safe!
 type Echo struct {
 	filesystem
 	common
@@ -83,7 +87,28 @@ type Echo struct {
 	maxParam      *int
 	router        *Router
 	routers       map[string]*Router
-	pool          sync.Pool
+	// hostPatterns are routers for hosts with wildcards or parameters ordered by precedence
+	hostPatterns []*hostPattern
+	// routesMutex serializes route changes. After first request has been served (routingLive is set) route changes
+	// are made to copies of routers and published as new routing snapshot that ServeHTTP loads atomically.
+	routesMutex sync.Mutex
+	routingLive uint32
+	routing     atomic.Value // *routingTable
+	pool        sync.Pool
+	// shutdownHooks are called when Shutdown begins, before servers stop accepting connections
+	shutdownHooks []func(ctx stdContext.Context)
+	// startHooks are called by Run before listeners are started
+	startHooks []func(ctx stdContext.Context) error
+	// connections tracks hijacked connections for Shutdown
+	connections connectionTracker
+	// listeners are named listeners added with AddListener
+	listeners []*namedListener
+	// mounts are mount points of routes registered by Mount (*Route -> *mountPoint)
+	mounts sync.Map
+	// routeMeta is metadata of routes (*Route -> *RouteMeta), see `Echo#Meta()`
+	routeMeta sync.Map
+	// groupNotFoundRoutes are catch-all RouteNotFound routes registered by Group#Use (*Route -> struct{})
+	groupNotFoundRoutes sync.Map
 
 	StdLogger        *stdLog.Logger
 	Server           *http.Server
@@ -100,12 +125,42 @@ type Echo struct {
 	IPExtractor      IPExtractor
 	ListenerNetwork  string
 
+	// ProxyProtocol enables parsing of HAProxy PROXY protocol (v1 and v2) headers on listeners created by Echo
+	// (`Start`, `StartTLS`, `StartServer` etc.). When set, `Request.RemoteAddr`, `Context.IsTLS()` and
+	// `Context.Scheme()` reflect the client connected to the proxy.
+	ProxyProtocol *ProxyProtocolConfig
+
+	// PathNormalization defines how request path is normalized (case folding, duplicate slashes, dot segments)
+	// before it is matched by the router.
+	PathNormalization PathNormalizationConfig
+
+	// DisableAutoHead disables dispatching HEAD requests to GET handler for routes without HEAD handler. When enabled
+	// (default) response body is discarded but headers and `Content-Length` are sent.
+	DisableAutoHead bool
+
+	// DisableAutoOptions disables automatic replies (204 with `Allow` header) to OPTIONS requests for routes without
+	// OPTIONS handler. Such requests get 405 Method Not Allowed. CORS middleware still replies to preflight requests
+	// using allowed methods of the route.
+	DisableAutoOptions bool
+
+	// HTTPMethodNotAllowedHandler is called when route exists for the path but not for the request method. It
+	// receives methods allowed for the path, `Allow` header is already set. By default, `MethodNotAllowedHandler`
+	// is used.
+	HTTPMethodNotAllowedHandler func(c Context, allowed []string) error
+
+	// StrictRouting makes adding a route panic when route conflicts with already registered route (same method and
+	// path, param names are ignored) or route path is ambiguous. See `Router#CheckRoute()`. By default, new route
+	// silently replaces the existing one.
+	StrictRouting bool
+
 	// OnAddRouteHandler is called when Echo adds new route to specific host router.
 	OnAddRouteHandler func(host string, route Route, handler HandlerFunc, middleware []MiddlewareFunc)
-	DisableHTTP2      bool
-	Debug             bool
-	HideBanner        bool
-	HidePort          bool
+	// OnRemoveRouteHandler is called when Echo removes route from specific host router.
+	OnRemoveRouteHandler func(host string, route Route)
+	DisableHTTP2         bool
+	Debug                bool
+	HideBanner           bool
+	HidePort             bool
 }
 
 // Route contains a handler and information for matching against requests.
@@ -147,6 +202,24 @@ type Renderer interface {
 	Render(io.Writer, string, interface{}, Context) error
 }
 
+// TemplateRenderer is Renderer for `html/template` and `text/template` templates. Add `Echo#TemplateFuncs()` to
+// templates to generate route URLs in templates.
+//
+// Example:
+//
+//	t := template.Must(template.New("").Funcs(e.TemplateFuncs()).ParseGlob("views/*.html"))
+//	e.Renderer = &echo.TemplateRenderer{Template: t}
+type TemplateRenderer struct {
+	Template interface {
+		ExecuteTemplate(w io.Writer, name string, data interface{}) error
+	}
+}
+
+// Render renders template with name and data.
+func (t *TemplateRenderer) Render(w io.Writer, name string, data interface{}, c Context) error {
+	return t.Template.ExecuteTemplate(w, name, data)
+}
+
 // Map defines a generic map of type `map[string]interface{}`.
 type Map map[string]interface{}
 
@@ -227,6 +300,7 @@ const (
 	HeaderUpgrade             = "Upgrade"
 	HeaderVary                = "Vary"
 	HeaderWWWAuthenticate     = "WWW-Authenticate"
+	HeaderForwarded           = "Forwarded"
 	HeaderXForwardedFor       = "X-Forwarded-For"
 	HeaderXForwardedProto     = "X-Forwarded-Proto"
 	HeaderXForwardedProtocol  = "X-Forwarded-Protocol"
@@ -239,6 +313,7 @@ const (
 	HeaderXRequestedWith      = "X-Requested-With"
 	HeaderServer              = "Server"
 	HeaderOrigin              = "Origin"
+	HeaderReferer             = "Referer"
 	HeaderCacheControl        = "Cache-Control"
 	HeaderConnection          = "Connection"
 
@@ -261,6 +336,11 @@ const (
 	HeaderContentSecurityPolicyReportOnly = "Content-Security-Policy-Report-Only"
 	HeaderXCSRFToken                      = "X-CSRF-Token"
 	HeaderReferrerPolicy                  = "Referrer-Policy"
+	HeaderSecFetchSite                    = "Sec-Fetch-Site"
+	HeaderPermissionsPolicy               = "Permissions-Policy"
+	HeaderCrossOriginOpenerPolicy         = "Cross-Origin-Opener-Policy"
+	HeaderCrossOriginEmbedderPolicy       = "Cross-Origin-Embedder-Policy"
+	HeaderReportingEndpoints              = "Reporting-Endpoints"
 )
 
 const (
@@ -399,19 +479,19 @@ func (e *Echo) NewContext(r *http.Request, w http.ResponseWriter) Context {
 		response: NewResponse(w, e),
 		store:    make(Map),
 		echo:     e,
-		pvalues:  make([]string, *e.maxParam),
+		pvalues:  make([]string, e.maxParamCount()),
 		handler:  NotFoundHandler,
 	}
 }
 
 // Router returns the default router.
 func (e *Echo) Router() *Router {
-	return e.router
+	return e.currentRouting().router
 }
 
 // Routers returns the map of host => router.
 func (e *Echo) Routers() map[string]*Router {
-	return e.routers
+	return e.currentRouting().routers
 }
 
 // DefaultHTTPErrorHandler is the default HTTP error handler. It sends a JSON response
@@ -579,13 +659,30 @@ func (e *Echo) File(path, file string, m ...MiddlewareFunc) *Route {
 }
 
 func (e *Echo) add(host, method, path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
-	router := e.findRouter(host)
-	//: when handler+middleware are both nil ... make it behave like handler removal
+	return e.addRoute(host, method, path, handler, false, middlewares...)
+}
+
+// addRoute adds route to the host router. groupNotFound marks catch-all RouteNotFound routes registered by
+// `Group#Use()` - groups re-register them each time middlewares are added, so in StrictRouting mode they are not
+// checked and they may be replaced by other RouteNotFound routes.
+func (e *Echo) addRoute(host, method, path string, handler HandlerFunc, groupNotFound bool, middlewares ...MiddlewareFunc) *Route {
 	name := handlerName(handler)
-	route := router.add(method, path, name, func(c Context) error {
-		h := applyMiddleware(handler, middlewares...)
-		return h(c)
+	var route *Route
+	e.updateRouter(host, func(router *Router) {
+		if e.StrictRouting && !groupNotFound {
+			err := router.CheckRoute(method, path)
+			if err != nil && !(method == RouteNotFound && errors.Is(err, ErrRouteConflict) && e.isGroupNotFoundRoute(router, path)) {
+				panic(fmt.Errorf("echo: %w", err))
+			}
+		}
+		route = router.add(method, path, name, func(c Context) error {
+			h := applyMiddleware(handler, middlewares...)
+			return h(c)
+		})
 	})
+	if groupNotFound {
+		e.groupNotFoundRoutes.Store(route, struct{}{})
+	}
 
 	if e.OnAddRouteHandler != nil {
 		e.OnAddRouteHandler(host, *route, handler, middlewares)
@@ -600,9 +697,41 @@ func (e *Echo) Add(method, path string, handler HandlerFunc, middleware ...Middl
 	return e.add("", method, path, handler, middleware...)
 }
 
+// RemoveRoute removes route registered for method and path from the default router. Path must be the same as used
+// when route was added. Returns false when there is no such route. It is safe to call while server is running.
+func (e *Echo) RemoveRoute(method, path string) bool {
+	return e.remove("", method, path)
+}
+
+func (e *Echo) remove(host, method, path string) bool {
+	var route *Route
+	var removed bool
+	e.updateRouter(host, func(router *Router) {
+		route, removed = router.remove(method, path)
+	})
+
+	if route != nil {
+		e.mounts.Delete(route)
+		e.routeMeta.Delete(route)
+		e.groupNotFoundRoutes.Delete(route)
+	}
+	if removed && e.OnRemoveRouteHandler != nil {
+		if route == nil {
+			route = &Route{Method: method, Path: normalizePathSlash(path)}
+		}
+		e.OnRemoveRouteHandler(host, *route)
+	}
+	return removed
+}
+
 // Host creates a new router group for the provided host and optional host-level middleware.
+//
+// Name can be a pattern with named parameters `{tenant}.example.com` (matches exactly one label) or leftmost
+// wildcard `*.example.com` (matches one or more labels). Parameter values are available with `HostParam()`.
+// Request host port is ignored when matching. Router is chosen in order: exact host with port, exact host without
+// port, host pattern (more literal labels first) and lastly the default router.
 func (e *Echo) Host(name string, m ...MiddlewareFunc) (g *Group) {
-	e.routers[name] = NewRouter(e)
+	e.addHostRouter(name)
 	g = &Group{host: name, echo: e}
 	g.Use(m...)
 	return
@@ -628,19 +757,24 @@ func (e *Echo) URL(h HandlerFunc, params ...interface{}) string {
 
 // Reverse generates a URL from route name and provided parameters.
 func (e *Echo) Reverse(name string, params ...interface{}) string {
-	return e.router.Reverse(name, params...)
+	return e.currentRouting().router.Reverse(name, params...)
 }
 
 // Routes returns the registered routes for default router.
 // In case when Echo serves multiple hosts/domains use `e.Routers()["domain2.site"].Routes()` to get specific host routes.
+// Routes of Echo instances mounted with `Mount()` are included with mount prefix prepended to their paths.
 func (e *Echo) Routes() []*Route {
-	return e.router.Routes()
+	return e.mountedRoutes(e.currentRouting().router.Routes())
 }
 
 // AcquireContext returns an empty `Context` instance from the pool.
 // You must return the context by calling `ReleaseContext()`.
 func (e *Echo) AcquireContext() Context {
-	return e.pool.Get().(Context)
+	c := e.pool.Get().(*context)
+	if n := e.maxParamCount(); len(c.pvalues) < n {
+		c.pvalues = make([]string, n)
+	}
+	return c
 }
 
 // ReleaseContext returns the `Context` instance back to the pool.
@@ -651,18 +785,30 @@ func (e *Echo) ReleaseContext(c Context) {
 
 // ServeHTTP implements `http.Handler` interface, which serves HTTP requests.
 func (e *Echo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.serveHTTP(w, r, "")
+}
+
+// serveHTTP serves request. When routePrefix is not empty only routes with path under that prefix are served.
+func (e *Echo) serveHTTP(w http.ResponseWriter, r *http.Request, routePrefix string) {
 	// Acquire context
 	c := e.pool.Get().(*context)
 	c.Reset(r, w)
 	var h HandlerFunc
 
+	routing := e.loadRouting()
+	if len(c.pvalues) < routing.maxParam {
+		c.pvalues = make([]string, routing.maxParam) // routes with more params were added after context was created
+	}
+
 	if e.premiddleware == nil {
-		e.findRouter(r.Host).Find(r.Method, GetPath(r), c)
+		e.findRoute(routing, r, c)
+		restrictRoute(c, routePrefix)
 		h = c.Handler()
 		h = applyMiddleware(h, e.middleware...)
 	} else {
 		h = func(c Context) error {
-			e.findRouter(r.Host).Find(r.Method, GetPath(r), c)
+			e.findRoute(routing, r, c.(*context))
+			restrictRoute(c.(*context), routePrefix)
 			h := c.Handler()
 			h = applyMiddleware(h, e.middleware...)
 			return h(c)
@@ -679,6 +825,29 @@ func (e *Echo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	e.pool.Put(c)
 }
 
+// findRoute finds route for the request and sets the matched handler to the context.
+func (e *Echo) findRoute(routing *routingTable, r *http.Request, c *context) {
+	router := routing.match(r.Host, c)
+	if e.PathNormalization == (PathNormalizationConfig{}) {
+		router.Find(r.Method, GetPath(r), c)
+		return
+	}
+	path, values, normalized := e.PathNormalization.routePath(GetPath(r))
+	if normalized && e.PathNormalization.RedirectCode != 0 {
+		// never redirect to protocol relative URL (i.e. `//evil.com`)
+		target := "/" + strings.TrimLeft(values, "/\\")
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		code := e.PathNormalization.RedirectCode
+		c.handler = func(c Context) error {
+			return c.Redirect(code, target)
+		}
+		return
+	}
+	router.find(r.Method, path, values, c)
+}
+
 // Start starts an HTTP server.
 func (e *Echo) Start(address string) error {
 	e.startupMutex.Lock()
@@ -789,13 +958,15 @@ func (e *Echo) configureServer(s *http.Server) error {
 		e.colorer.Printf(banner, e.colorer.Red("v"+Version), e.colorer.Blue(website))
 	}
 
+	s.ConnContext = wrapProxyProtocolConnContext(s.ConnContext)
+
 	if s.TLSConfig == nil {
 		if e.Listener == nil {
 			l, err := newListener(s.Addr, e.ListenerNetwork)
 			if err != nil {
 				return err
 			}
-			e.Listener = l
+			e.Listener = e.wrapProxyProtocol(l)
 		}
 		if !e.HidePort {
 			e.colorer.Printf("⇨ http server started on %s\n", e.colorer.Green(e.Listener.Addr()))
@@ -807,7 +978,7 @@ func (e *Echo) configureServer(s *http.Server) error {
 		if err != nil {
 			return err
 		}
-		e.TLSListener = tls.NewListener(l, s.TLSConfig)
+		e.TLSListener = tls.NewListener(e.wrapProxyProtocol(l), s.TLSConfig)
 	}
 	if !e.HidePort {
 		e.colorer.Printf("⇨ https server started on %s\n", e.colorer.Green(e.TLSListener.Addr()))
@@ -844,6 +1015,7 @@ func (e *Echo) StartH2CServer(address string, h2s *http2.Server) error {
 	e.colorer.SetOutput(e.Logger.Output())
 	s.ErrorLog = e.StdLogger
 	s.Handler = h2c.NewHandler(e, h2s)
+	s.ConnContext = wrapProxyProtocolConnContext(s.ConnContext)
 	if e.Debug {
 		e.Logger.SetLevel(log.DEBUG)
 	}
@@ -858,7 +1030,7 @@ func (e *Echo) StartH2CServer(address string, h2s *http2.Server) error {
 			e.startupMutex.Unlock()
 			return err
 		}
-		e.Listener = l
+		e.Listener = e.wrapProxyProtocol(l)
 	}
 	if !e.HidePort {
 		e.colorer.Printf("⇨ http server started on %s\n", e.colorer.Green(e.Listener.Addr()))
@@ -875,17 +1047,52 @@ func (e *Echo) Close() error {
 	if err := e.TLSServer.Close(); err != nil {
 		return err
 	}
+	if err := e.closeListeners(); err != nil {
+		return err
+	}
 	return e.Server.Close()
 }
 
+// OnShutdown registers a function to call when `Shutdown` begins. Functions are called in registration order before
+// servers stop accepting new connections, so they can i.e. fail readiness checks and wait for load balancers to
+// drain traffic. Functions should return when given context is done.
+func (e *Echo) OnShutdown(fn func(ctx stdContext.Context)) {
+	e.startupMutex.Lock()
+	defer e.startupMutex.Unlock()
+	e.shutdownHooks = append(e.shutdownHooks, fn)
+}
+
 // Shutdown stops the server gracefully.
-// It internally calls `http.Server#Shutdown()`.
+// It internally calls `http.Server#Shutdown()`. Hijacked connections (i.e. WebSockets) are not tracked by
+// `http.Server` so Shutdown waits for them to be closed and closes remaining ones when context is done.
+// Channel returned by `ShutdownNotify` is closed when Shutdown begins. Hijacked connections are drained even when
+// shutting down servers fails, the first error is returned.
 func (e *Echo) Shutdown(ctx stdContext.Context) error {
+	e.connections.beginShutdown()
+
+	e.startupMutex.RLock()
+	hooks := e.shutdownHooks
+	e.startupMutex.RUnlock()
+	for _, fn := range hooks {
+		fn(ctx)
+	}
+
+	err := e.shutdownServers(ctx)
+	if dErr := e.connections.drain(ctx); err == nil {
+		err = dErr
+	}
+	return err
+}
+
+func (e *Echo) shutdownServers(ctx stdContext.Context) error {
 	e.startupMutex.Lock()
 	defer e.startupMutex.Unlock()
 	if err := e.TLSServer.Shutdown(ctx); err != nil {
 		return err
 	}
+	if err := e.shutdownListeners(ctx); err != nil {
+		return err
+	}
 	return e.Server.Shutdown(ctx)
 }
 
@@ -16,7 +16,9 @@ import (
 	"net/url"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -742,6 +744,121 @@ func TestEchoHost(t *testing.T) {
 	}
 }
 
+func TestEchoHostPattern(t *testing.T) {
+	hostHandler := func(name string) HandlerFunc {
+		return func(c Context) error {
+			body := name
+			hc := c.(HostParamContext)
+			for i, n := range hc.HostParamNames() {
+				body += " " + n + "=" + hc.HostParamValues()[i]
+			}
+			return c.String(http.StatusOK, body)
+		}
+	}
+
+	e := New()
+	e.GET("/", hostHandler("default"))
+	e.Host("api.example.com").GET("/", hostHandler("exact"))
+	e.Host("{tenant}.example.com").GET("/", hostHandler("tenant"))
+	e.Host("{tenant}.{region}.example.com").GET("/", hostHandler("region"))
+	e.Host("*.example.com").GET("/", hostHandler("wildcard"))
+	e.Host("admin.{tenant}.example.com").GET("/", hostHandler("admin"))
+
+	var testCases = []struct {
+		name       string
+		whenHost   string
+		expectBody string
+	}{
+		{
+			name:       "exact host has precedence over pattern",
+			whenHost:   "api.example.com",
+			expectBody: "exact",
+		},
+		{
+			name:       "exact host matches without port",
+			whenHost:   "api.example.com:8443",
+			expectBody: "exact",
+		},
+		{
+			name:       "host param",
+			whenHost:   "acme.example.com",
+			expectBody: "tenant tenant=acme",
+		},
+		{
+			name:       "host param with port and different case",
+			whenHost:   "ACME.Example.com:8080",
+			expectBody: "tenant tenant=acme",
+		},
+		{
+			name:       "pattern with more literal labels has precedence",
+			whenHost:   "admin.acme.example.com",
+			expectBody: "admin tenant=acme",
+		},
+		{
+			name:       "multiple host params",
+			whenHost:   "acme.eu.example.com",
+			expectBody: "region tenant=acme region=eu",
+		},
+		{
+			name:       "wildcard matches multiple labels",
+			whenHost:   "a.b.c.example.com",
+			expectBody: "wildcard *=a.b.c",
+		},
+		{
+			name:       "wildcard does not match apex domain",
+			whenHost:   "example.com",
+			expectBody: "default",
+		},
+		{
+			name:       "unknown host uses default router",
+			whenHost:   "acme.example.org",
+			expectBody: "default",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tc.whenHost
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tc.expectBody, rec.Body.String())
+		})
+	}
+}
+
+func TestHostParam(t *testing.T) {
+	type wrappedContext struct {
+		Context
+	}
+
+	e := New()
+	e.Host("{tenant}.example.com").GET("/", func(c Context) error {
+		return c.String(http.StatusOK, HostParam(c, "tenant")+"|"+HostParam(c, "unknown")+"|"+HostParam(wrappedContext{c}, "tenant"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "acme.example.com"
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "acme||", rec.Body.String())
+}
+
+func TestEchoHostPatternInvalid(t *testing.T) {
+	e := New()
+	assert.PanicsWithValue(t, "echo: host pattern wildcard `*` must be the leftmost label: api.*.example.com", func() {
+		e.Host("api.*.example.com")
+	})
+	assert.PanicsWithValue(t, "echo: invalid host pattern: api-{region.example.com", func() {
+		e.Host("api-{region.example.com")
+	})
+}
+
 func TestEchoGroup(t *testing.T) {
 	e := New()
 	buf := new(bytes.Buffer)
@@ -883,7 +1000,7 @@ func TestEchoMethodNotAllowed(t *testing.T) {
 	e.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
-	assert.Equal(t, "OPTIONS, GET", rec.Header().Get(HeaderAllow))
+	assert.Equal(t, "OPTIONS, GET, HEAD", rec.Header().Get(HeaderAllow))
 }
 
 func TestEchoContext(t *testing.T) {
@@ -1466,6 +1583,96 @@ func TestEchoOnAddRouteHandler(t *testing.T) {
 	assert.Len(t, added[1].middleware, 1)
 }
 
+func TestEchoRemoveRoute(t *testing.T) {
+	e := New()
+	okHandler := func(c Context) error { return c.String(http.StatusOK, "OK") }
+
+	removed := make([]Route, 0)
+	e.OnRemoveRouteHandler = func(host string, route Route) {
+		removed = append(removed, route)
+	}
+
+	e.GET("/users/:id", okHandler)
+	e.POST("/users/:id", okHandler)
+	api := e.Host("api.example.com").Group("/api")
+	api.GET("/status", okHandler)
+
+	serve := func(method, host, path string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "", "/users/1"))
+
+	// routing is live now, changes are applied to copy of the router
+	oldRouter := e.Router()
+	assert.True(t, e.RemoveRoute(http.MethodGet, "/users/:id"))
+	assert.False(t, e.RemoveRoute(http.MethodGet, "/users/:id"))
+	assert.NotSame(t, oldRouter, e.Router())
+
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodGet, "", "/users/1"))
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "", "/users/1"))
+
+	e.GET("/new", okHandler)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "", "/new"))
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "api.example.com", "/api/status"))
+	assert.True(t, api.RemoveRoute(http.MethodGet, "/status"))
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "api.example.com", "/api/status"))
+
+	assert.Equal(t, []Route{
+		{Method: http.MethodGet, Path: "/users/:id", Name: "github.com/jialequ/agent.TestEchoRemoveRoute.func1"},
+		{Method: http.MethodGet, Path: "/api/status", Name: "github.com/jialequ/agent.TestEchoRemoveRoute.func1"},
+	}, removed)
+
+	for _, r := range e.Routes() {
+		assert.False(t, r.Method == http.MethodGet && r.Path == "/users/:id")
+	}
+}
+
+func TestEchoRoutesChangedWhileServing(t *testing.T) {
+	e := New()
+	okHandler := func(c Context) error { return c.String(http.StatusOK, c.Param("a")) }
+	e.GET("/", okHandler)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				req := httptest.NewRequest(http.MethodGet, "/x/1/2/3", nil)
+				rec := httptest.NewRecorder()
+				e.ServeHTTP(rec, req)
+			}
+		}()
+	}
+
+	for i := 0; i < 50; i++ {
+		e.GET("/x/:a/:b/:c", okHandler)
+		e.Host("h"+strconv.Itoa(i)+".example.com").GET("/", okHandler)
+		e.RemoveRoute(http.MethodGet, "/x/:a/:b/:c")
+	}
+	close(done)
+	wg.Wait()
+
+	e.GET("/x/:a/:b/:c", okHandler)
+	req := httptest.NewRequest(http.MethodGet, "/x/1/2/3", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Body.String())
+}
+
 func TestEchoReverse(t *testing.T) {
 	var testCases = []struct {
 		name          string
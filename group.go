@@ -16,6 +16,8 @@ type Group struct {
 	prefix     string
 	echo       *Echo
 	middleware []MiddlewareFunc
+	// scopes are added to metadata of routes registered with the group, see `Group#RequireScopes()`
+	scopes []string
 }
 
 // Use implements `Echo#Use()` for sub-routes within the Group.
@@ -28,8 +30,24 @@ func (g *Group) Use(middleware ...MiddlewareFunc) {
 	// are only executed if they are added to the Router with route.
 	// So we register catch all route (404 is a safe way to emulate route match) for this group and now during routing the
 	// Router would find route to match our request path and therefore guarantee the middleware(s) will get executed.
-	g.RouteNotFound("", NotFoundHandler)
-	g.RouteNotFound("/*", NotFoundHandler)
+	g.addGroupNotFound("")
+	g.addGroupNotFound("/*")
+}
+
+func (g *Group) addGroupNotFound(path string) {
+	m := make([]MiddlewareFunc, 0, len(g.middleware))
+	m = append(m, g.middleware...)
+	g.echo.addRoute(g.host, RouteNotFound, g.prefix+path, NotFoundHandler, true, m...)
+}
+
+// RequireScopes adds scopes (permissions) required to access routes registered with the group and its sub-groups
+// after this call. Scopes are added to route metadata (see `RouteMeta.RequireScopes()`) and are checked by the
+// Authorization middleware.
+//
+// Example: `admin := e.Group("/admin").RequireScopes("admin")`
+func (g *Group) RequireScopes(scopes ...string) *Group {
+	g.scopes = append(g.scopes, scopes...)
+	return g
 }
 
 // CONNECT implements `Echo#CONNECT()` for sub-routes within the Group.
@@ -102,6 +120,7 @@ func (g *Group) Group(prefix string, middleware ...MiddlewareFunc) (sg *Group) {
 	m = append(m, middleware...)
 	sg = g.echo.Group(g.prefix+prefix, m...)
 	sg.host = g.host
+	sg.scopes = append([]string(nil), g.scopes...)
 	return
 }
 
@@ -110,6 +129,11 @@ func (g *Group) File(path, file string) {
 	g.file(path, file, g.GET)
 }
 
+// Mount implements `Echo#Mount()` for sub-routes within the Group.
+func (g *Group) Mount(prefix string, h http.Handler, m ...MiddlewareFunc) []*Route {
+	return g.echo.mount(prefix, h, g.Any, m...)
+}
+
 // RouteNotFound implements `Echo#RouteNotFound()` for sub-routes within the Group.
 //
 // Example: `g.RouteNotFound("/*", func(c echo.Context) error { return c.NoContent(http.StatusNotFound) })`
@@ -125,5 +149,14 @@ func (g *Group) Add(method, path string, handler HandlerFunc, middleware ...Midd
 	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
-	return g.echo.add(g.host, method, g.prefix+path, handler, m...)
+	route := g.echo.add(g.host, method, g.prefix+path, handler, m...)
+	if len(g.scopes) > 0 {
+		g.echo.Meta(route).RequireScopes(g.scopes...)
+	}
+	return route
+}
+
+// RemoveRoute implements `Echo#RemoveRoute()` for sub-routes within the Group.
+func (g *Group) RemoveRoute(method, path string) bool {
+	return g.echo.remove(g.host, method, g.prefix+path)
 }
@@ -0,0 +1,291 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	stdContext "context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Health check statuses.
+const (
+	// HealthStatusUp means that all checks passed.
+	HealthStatusUp = "up"
+	// HealthStatusDegraded means that some non-critical checks failed.
+	HealthStatusDegraded = "degraded"
+	// HealthStatusDown means that critical check failed or server is shutting down.
+	HealthStatusDown = "down"
+)
+
+// DefaultHealthCheckTimeout is the timeout of health check that does not define its own timeout.
+const DefaultHealthCheckTimeout = 5 * time.Second
+
+// ErrShuttingDown is reported by readiness when `Echo#Shutdown` has begun.
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// HealthCheck is named check of a component (database, cache, downstream service etc.).
+type HealthCheck struct {
+	// Name identifies check in the report.
+	Name string
+	// Check returns error when component is not healthy. Given context is cancelled when Timeout is exceeded.
+	Check func(ctx stdContext.Context) error
+	// Timeout is maximum duration of the check.
+	// Optional. Default value DefaultHealthCheckTimeout.
+	Timeout time.Duration
+	// Critical checks make readiness fail. Failing non-critical checks only degrade the status.
+	Critical bool
+	// Liveness includes check in liveness report. Only checks detecting states the process can not recover from
+	// without restart should be liveness checks.
+	Liveness bool
+}
+
+// HealthCheckResult is the result of a single health check.
+type HealthCheckResult struct {
+	Status    string        `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	Critical  bool          `json:"critical"`
+	Duration  time.Duration `json:"duration"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// HealthReport is aggregated result of health checks.
+type HealthReport struct {
+	Status string                       `json:"status"`
+	Error  string                       `json:"error,omitempty"`
+	Checks map[string]HealthCheckResult `json:"checks,omitempty"`
+}
+
+// Health aggregates registered health checks into liveness and readiness reports. Check results are cached for
+// CacheTTL so frequent probes do not overload checked components.
+//
+// Example:
+//
+//	health := echo.NewHealth()
+//	health.AddCheck(echo.HealthCheck{Name: "db", Check: db.PingContext, Critical: true})
+//	health.Register(e) // GET /livez, /healthz and /readyz
+type Health struct {
+	// CacheTTL is duration check results are reused for.
+	// Optional. Default value 0 (checks are executed for each probe).
+	CacheTTL time.Duration
+
+	// DrainDelay is duration `Echo#Shutdown` waits after readiness starts failing before servers stop accepting
+	// connections. Should be longer than load balancer readiness probe interval.
+	// Optional. Default value 0.
+	DrainDelay time.Duration
+
+	shuttingDown int32
+
+	mutex   sync.Mutex
+	checks  []HealthCheck
+	results map[string]HealthCheckResult
+
+	timeNow func() time.Time
+}
+
+// NewHealth creates new Health instance. Zero value of Health is ready to use as well.
+func NewHealth() *Health {
+	return &Health{
+		results: map[string]HealthCheckResult{},
+		timeNow: time.Now,
+	}
+}
+
+// AddCheck registers health check. Check with the same name replaces existing check.
+func (h *Health) AddCheck(check HealthCheck) {
+	if check.Name == "" || check.Check == nil {
+		panic("echo: health check requires name and check function")
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = DefaultHealthCheckTimeout
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.results, check.Name)
+	for i, c := range h.checks {
+		if c.Name == check.Name {
+			h.checks[i] = check
+			return
+		}
+	}
+	h.checks = append(h.checks, check)
+}
+
+// Register adds liveness (`GET /livez`, `GET /healthz`) and readiness (`GET /readyz`) routes to Echo and makes
+// readiness fail when `Echo#Shutdown` begins.
+func (h *Health) Register(e *Echo) {
+	e.GET("/livez", h.LivenessHandler())
+	e.GET("/healthz", h.LivenessHandler())
+	e.GET("/readyz", h.ReadinessHandler())
+	e.OnShutdown(h.shutdown)
+}
+
+// SetShuttingDown marks server as shutting down (or not) which makes readiness fail.
+func (h *Health) SetShuttingDown(shuttingDown bool) {
+	var v int32
+	if shuttingDown {
+		v = 1
+	}
+	atomic.StoreInt32(&h.shuttingDown, v)
+}
+
+// IsShuttingDown returns true when server is shutting down.
+func (h *Health) IsShuttingDown() bool {
+	return atomic.LoadInt32(&h.shuttingDown) == 1
+}
+
+func (h *Health) shutdown(ctx stdContext.Context) {
+	h.SetShuttingDown(true)
+	if h.DrainDelay <= 0 {
+		return
+	}
+	t := time.NewTimer(h.DrainDelay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// Liveness executes liveness checks and returns the report.
+func (h *Health) Liveness(ctx stdContext.Context) HealthReport {
+	return h.report(ctx, true)
+}
+
+// Readiness executes all checks and returns the report. Report status is down when server is shutting down.
+func (h *Health) Readiness(ctx stdContext.Context) HealthReport {
+	report := h.report(ctx, false)
+	if h.IsShuttingDown() {
+		report.Status = HealthStatusDown
+		report.Error = ErrShuttingDown.Error()
+	}
+	return report
+}
+
+// LivenessHandler returns handler responding with liveness report as JSON. Status code is 503 when report status
+// is down.
+func (h *Health) LivenessHandler() HandlerFunc {
+	return func(c Context) error {
+		return healthResponse(c, h.Liveness(c.Request().Context()))
+	}
+}
+
+// ReadinessHandler returns handler responding with readiness report as JSON. Status code is 503 when report status
+// is down.
+func (h *Health) ReadinessHandler() HandlerFunc {
+	return func(c Context) error {
+		return healthResponse(c, h.Readiness(c.Request().Context()))
+	}
+}
+
+func healthResponse(c Context, report HealthReport) error {
+	c.Response().Header().Set(HeaderCacheControl, "no-store")
+	code := http.StatusOK
+	if report.Status == HealthStatusDown {
+		code = http.StatusServiceUnavailable
+	}
+	return c.JSON(code, report)
+}
+
+func (h *Health) report(ctx stdContext.Context, livenessOnly bool) HealthReport {
+	h.mutex.Lock()
+	checks := make([]HealthCheck, 0, len(h.checks))
+	for _, c := range h.checks {
+		if !livenessOnly || c.Liveness {
+			checks = append(checks, c)
+		}
+	}
+	h.mutex.Unlock()
+
+	report := HealthReport{Status: HealthStatusUp}
+	if len(checks) == 0 {
+		return report
+	}
+	report.Checks = make(map[string]HealthCheckResult, len(checks))
+
+	results := make([]HealthCheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			results[i] = h.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for i, check := range checks {
+		r := results[i]
+		report.Checks[check.Name] = r
+		if r.Status == HealthStatusUp {
+			continue
+		}
+		if r.Critical {
+			report.Status = HealthStatusDown
+		} else if report.Status == HealthStatusUp {
+			report.Status = HealthStatusDegraded
+		}
+	}
+	return report
+}
+
+func (h *Health) now() time.Time {
+	if h.timeNow == nil {
+		return time.Now()
+	}
+	return h.timeNow()
+}
+
+// run executes check or returns its cached result.
+func (h *Health) run(parent stdContext.Context, check HealthCheck) HealthCheckResult {
+	now := h.now()
+	if h.CacheTTL > 0 {
+		h.mutex.Lock()
+		cached, ok := h.results[check.Name]
+		h.mutex.Unlock()
+		if ok && now.Sub(cached.CheckedAt) < h.CacheTTL {
+			return cached
+		}
+	}
+
+	ctx, cancel := stdContext.WithTimeout(parent, check.Timeout)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- check.Check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := HealthCheckResult{
+		Status:    HealthStatusUp,
+		Critical:  check.Critical,
+		Duration:  h.now().Sub(now),
+		CheckedAt: now,
+	}
+	if err != nil {
+		result.Status = HealthStatusDown
+		result.Error = err.Error()
+	}
+	// result of check interrupted by caller (i.e. probe request was aborted) says nothing about the component
+	canceled := parent.Err() != nil || errors.Is(err, stdContext.Canceled)
+	if h.CacheTTL > 0 && !canceled {
+		h.mutex.Lock()
+		if h.results == nil {
+			h.results = map[string]HealthCheckResult{}
+		}
+		h.results[check.Name] = result
+		h.mutex.Unlock()
+	}
+	return result
+}
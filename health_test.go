@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	stdContext "context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func requestHealth(e *Echo, path string) (int, HealthReport) {
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	report := HealthReport{}
+	_ = json.Unmarshal(rec.Body.Bytes(), &report)
+	return rec.Code, report
+}
+
+func TestHealth(t *testing.T) {
+	var dbErr, cacheErr error
+	e := New()
+	h := NewHealth()
+	h.AddCheck(HealthCheck{Name: "db", Critical: true, Check: func(ctx stdContext.Context) error { return dbErr }})
+	h.AddCheck(HealthCheck{Name: "cache", Check: func(ctx stdContext.Context) error { return cacheErr }})
+	h.AddCheck(HealthCheck{Name: "deadlock", Liveness: true, Critical: true, Check: func(ctx stdContext.Context) error { return nil }})
+	h.Register(e)
+
+	code, report := requestHealth(e, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, HealthStatusUp, report.Status)
+	assert.Len(t, report.Checks, 3)
+
+	cacheErr = errors.New("cache unavailable")
+	code, report = requestHealth(e, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, HealthStatusDegraded, report.Status)
+	assert.Equal(t, "cache unavailable", report.Checks["cache"].Error)
+
+	dbErr = errors.New("db unavailable")
+	code, report = requestHealth(e, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, HealthStatusDown, report.Status)
+	assert.Equal(t, HealthStatusDown, report.Checks["db"].Status)
+	assert.True(t, report.Checks["db"].Critical)
+
+	// liveness only includes liveness checks
+	for _, path := range []string{"/livez", "/healthz"} {
+		code, report = requestHealth(e, path)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, HealthStatusUp, report.Status)
+		assert.Len(t, report.Checks, 1)
+	}
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	h := NewHealth()
+	h.AddCheck(HealthCheck{Name: "slow", Critical: true, Timeout: 10 * time.Millisecond, Check: func(ctx stdContext.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}})
+
+	start := time.Now()
+	report := h.Readiness(stdContext.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, HealthStatusDown, report.Status)
+	assert.Equal(t, stdContext.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+}
+
+func TestHealthCache(t *testing.T) {
+	var calls int32
+	now := time.Now()
+	h := NewHealth()
+	h.CacheTTL = time.Minute
+	h.timeNow = func() time.Time { return now }
+	h.AddCheck(HealthCheck{Name: "db", Check: func(ctx stdContext.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}})
+
+	h.Readiness(stdContext.Background())
+	h.Readiness(stdContext.Background())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	now = now.Add(2 * time.Minute)
+	h.Readiness(stdContext.Background())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestHealthCacheSkipsCanceled(t *testing.T) {
+	var calls int32
+	h := &Health{CacheTTL: time.Minute} // zero value is usable
+	h.AddCheck(HealthCheck{Name: "db", Critical: true, Check: func(ctx stdContext.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return ctx.Err()
+	}})
+
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	cancel()
+	report := h.Readiness(ctx)
+	assert.Equal(t, HealthStatusDown, report.Status)
+
+	// result of canceled check is not cached
+	report = h.Readiness(stdContext.Background())
+	assert.Equal(t, HealthStatusUp, report.Status)
+	calledBefore := atomic.LoadInt32(&calls)
+
+	report = h.Readiness(stdContext.Background())
+	assert.Equal(t, HealthStatusUp, report.Status)
+	assert.Equal(t, calledBefore, atomic.LoadInt32(&calls))
+}
+
+func TestHealthAddCheckInvalid(t *testing.T) {
+	assert.PanicsWithValue(t, "echo: health check requires name and check function", func() {
+		NewHealth().AddCheck(HealthCheck{Name: "db"})
+	})
+}
+
+func TestHealthReadinessFailsOnShutdown(t *testing.T) {
+	e := New()
+	h := NewHealth()
+	h.DrainDelay = 50 * time.Millisecond
+	h.Register(e)
+
+	var readyDuringDrain int32
+	e.OnShutdown(func(ctx stdContext.Context) {
+		code, _ := requestHealth(e, "/readyz")
+		atomic.StoreInt32(&readyDuringDrain, int32(code))
+	})
+
+	code, _ := requestHealth(e, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+
+	start := time.Now()
+	assert.NoError(t, e.Shutdown(stdContext.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, int32(http.StatusServiceUnavailable), atomic.LoadInt32(&readyDuringDrain))
+
+	code, report := requestHealth(e, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, ErrShuttingDown.Error(), report.Error)
+
+	code, _ = requestHealth(e, "/livez")
+	assert.Equal(t, http.StatusOK, code)
+}
@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	"net"
+	"sort"
+	"strings"
+)
+
+// hostPattern is host router matched by pattern i.e. `{tenant}.example.com` or `*.example.com`.
+type hostPattern struct {
+	name   string
+	router *Router
+	// labels are pattern labels in reverse order (TLD first). Label is literal, `{name}` param or `*` wildcard.
+	labels []string
+	// pnames are parameter names in the order they appear in pattern (left to right)
+	pnames        []string
+	literalsCount int
+	hasWildcard   bool
+}
+
+// HostParamContext is implemented by Context holding parameters of request host matched by host pattern registered
+// with `Echo#Host()`. Context created by Echo implements it. Custom Context implementations wrapping Echo context
+// should delegate these methods to the wrapped context.
+type HostParamContext interface {
+	// HostParam returns host parameter by name. Host parameters are set when request host matches host pattern
+	// registered with `Echo#Host()` i.e. `{tenant}.example.com`. Wildcard `*` value is available by name `*`.
+	HostParam(name string) string
+
+	// HostParamNames returns host parameter names.
+	HostParamNames() []string
+
+	// HostParamValues returns host parameter values.
+	HostParamValues() []string
+}
+
+// HostParam returns host parameter by name. Returns empty string when parameter does not exist or context does not
+// implement `HostParamContext`.
+func HostParam(c Context, name string) string {
+	if hc, ok := c.(HostParamContext); ok {
+		return hc.HostParam(name)
+	}
+	return ""
+}
+
+func isHostPattern(name string) bool {
+	return strings.ContainsAny(name, "{*")
+}
+
+func (e *Echo) addHostPattern(name string, router *Router) {
+	p := newHostPattern(name, router)
+	for i, existing := range e.hostPatterns {
+		if existing.name == name {
+			e.hostPatterns[i] = p
+			return
+		}
+	}
+	e.hostPatterns = append(e.hostPatterns, p)
+	// precedence: more literal labels first, patterns without wildcard before patterns with wildcard, then order
+	// of registration
+	sort.SliceStable(e.hostPatterns, func(i, j int) bool {
+		a, b := e.hostPatterns[i], e.hostPatterns[j]
+		if a.literalsCount != b.literalsCount {
+			return a.literalsCount > b.literalsCount
+		}
+		return !a.hasWildcard && b.hasWildcard
+	})
+}
+
+func newHostPattern(name string, router *Router) *hostPattern {
+	p := &hostPattern{name: name, router: router}
+	labels := strings.Split(strings.ToLower(normalizeHost(name)), ".")
+	for i, label := range labels {
+		switch {
+		case label == "*":
+			if i != 0 {
+				panic("echo: host pattern wildcard `*` must be the leftmost label: " + name)
+			}
+			p.hasWildcard = true
+			p.pnames = append(p.pnames, "*")
+		case strings.HasPrefix(label, "{") && strings.HasSuffix(label, "}") && len(label) > 2:
+			p.pnames = append(p.pnames, label[1:len(label)-1])
+		case strings.ContainsAny(label, "{}*") || label == "":
+			panic("echo: invalid host pattern: " + name)
+		default:
+			p.literalsCount++
+		}
+	}
+	for i := len(labels) - 1; i >= 0; i-- {
+		p.labels = append(p.labels, labels[i])
+	}
+	return p
+}
+
+// match matches host (without port, lower case) against pattern and appends parameter values to values.
+func (p *hostPattern) match(host string, values []string) ([]string, bool) {
+	start := len(values)
+	values = append(values, make([]string, len(p.pnames))...)
+	pIndex := len(p.pnames) - 1 // labels are matched from right to left
+
+	end := len(host)
+	for _, label := range p.labels {
+		if end < 0 {
+			return values[:start], false
+		}
+		if label == "*" {
+			if end == 0 {
+				return values[:start], false
+			}
+			values[start+pIndex] = host[:end]
+			return values, true
+		}
+		i := strings.LastIndexByte(host[:end], '.')
+		hostLabel := host[i+1 : end]
+		if hostLabel == "" {
+			return values[:start], false
+		}
+		if label[0] == '{' {
+			values[start+pIndex] = hostLabel
+			pIndex--
+		} else if label != hostLabel {
+			return values[:start], false
+		}
+		end = i
+	}
+	if end >= 0 {
+		return values[:start], false // host has more labels than pattern
+	}
+	return values, true
+}
+
+// normalizeHost removes port and trailing dot from host.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+// match returns router for request host. Host parameters of matched host pattern are set to context.
+func (rt *routingTable) match(host string, c Context) *Router {
+	if len(rt.routers) == 0 {
+		return rt.router
+	}
+	if r, ok := rt.routers[host]; ok && !isHostPattern(host) {
+		return r
+	}
+	host = strings.ToLower(normalizeHost(host))
+	if r, ok := rt.routers[host]; ok && !isHostPattern(host) {
+		return r
+	}
+	if len(rt.hostPatterns) > 0 {
+		ctx := c.(*context)
+		for _, p := range rt.hostPatterns {
+			values, ok := p.match(host, ctx.hostPValues[:0])
+			ctx.hostPValues = values
+			if ok {
+				ctx.hostPNames = p.pnames
+				return p.router
+			}
+		}
+	}
+	return rt.router
+}
@@ -0,0 +1,28 @@
+module github.com/jialequ/agent/http3
+
+go 1.24
+
+require (
+	github.com/jialequ/agent v0.0.0-20261019084817-2880dd69c5e6
+	github.com/labstack/gommon v0.4.2
+	github.com/quic-go/quic-go v0.59.1
+	github.com/stretchr/testify v1.11.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/quic-go/qpack v0.6.0 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasttemplate v1.2.2 // indirect
+	golang.org/x/crypto v0.41.0 // indirect
+	golang.org/x/net v0.43.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/text v0.28.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// replace is only used when developing this module inside the repository, consumers use required version above
+replace github.com/jialequ/agent => ../
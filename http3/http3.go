@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+// Package http3 serves Echo over HTTP/3 (QUIC) alongside its HTTPS server. It lives in its own module so that
+// applications not using HTTP/3 do not depend on QUIC implementation.
+package http3
+
+import (
+	stdContext "context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	echo "github.com/jialequ/agent"
+	"github.com/labstack/gommon/color"
+	quic "github.com/quic-go/quic-go/http3"
+)
+
+// Server runs HTTP/3 server next to HTTPS server of Echo instance. Both servers use the same certificate and
+// handler, HTTPS responses advertise HTTP/3 server with `Alt-Svc` header so clients can switch to it.
+//
+// Example:
+//
+//	e := echo.New()
+//	s := http3.New(e)
+//	if err := s.StartTLS(":443", "cert.pem", "key.pem"); err != http.ErrServerClosed {
+//		e.Logger.Fatal(err)
+//	}
+type Server struct {
+	// Server is the underlying HTTP/3 server. Handler and TLSConfig are set by StartTLS.
+	Server *quic.Server
+	// Listener is UDP listener HTTP/3 server is served on.
+	// Optional. By default, StartTLS creates listener on the same port as HTTPS listener.
+	Listener net.PacketConn
+
+	echo  *echo.Echo
+	mutex sync.RWMutex
+}
+
+// New creates HTTP/3 server for given Echo instance. HTTP/3 server is shut down when `Echo#Shutdown` is called,
+// `Echo#Close` does not know about it so Server.Close must be called as well.
+func New(e *echo.Echo) *Server {
+	s := &Server{
+		Server: new(quic.Server),
+		echo:   e,
+	}
+	e.Pre(s.altSvc)
+	e.OnShutdown(func(ctx stdContext.Context) {
+		if err := s.Shutdown(ctx); err != nil {
+			e.Logger.Error(err)
+		}
+	})
+	return s
+}
+
+// altSvc is middleware adding `Alt-Svc` header to responses served over TCP.
+func (s *Server) altSvc(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if r := c.Request(); r.TLS != nil && r.ProtoMajor < 3 {
+			_ = s.Server.SetQUICHeaders(c.Response().Header())
+		}
+		return next(c)
+	}
+}
+
+// StartTLS starts an HTTPS server and an HTTP/3 server on the same address, TCP and UDP respectively. Returns
+// `http.ErrServerClosed` after `Echo#Shutdown`.
+// If `certFile` or `keyFile` is `string` the values are treated as file paths.
+// If `certFile` or `keyFile` is `[]byte` the values are treated as the certificate or key as-is.
+func (s *Server) StartTLS(address string, certFile, keyFile interface{}) error {
+	cert, err := loadCertificate(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	e := s.echo
+
+	s.mutex.Lock()
+	h3s := s.Server
+	h3s.Handler = e
+	h3s.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	if h3s.MaxHeaderBytes == 0 {
+		h3s.MaxHeaderBytes = e.TLSServer.MaxHeaderBytes
+	}
+	if h3s.IdleTimeout == 0 {
+		h3s.IdleTimeout = e.TLSServer.IdleTimeout
+	}
+	if s.Listener == nil {
+		// UDP listener is created first so HTTPS listener uses the same port even when address has port 0
+		if s.Listener, err = net.ListenPacket("udp", address); err != nil {
+			s.mutex.Unlock()
+			return err
+		}
+	}
+	h3Listener := s.Listener
+	s.mutex.Unlock()
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		_ = h3Listener.Close()
+		return err
+	}
+	if addr, ok := h3Listener.LocalAddr().(*net.UDPAddr); ok {
+		address = net.JoinHostPort(host, strconv.Itoa(addr.Port))
+	}
+
+	ts := e.TLSServer
+	ts.Addr = address
+	ts.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	if !e.DisableHTTP2 {
+		ts.TLSConfig.NextProtos = append(ts.TLSConfig.NextProtos, "h2")
+	}
+	if !e.HidePort {
+		colorer := color.New()
+		colorer.SetOutput(e.Logger.Output())
+		colorer.Printf("⇨ http3 server started on %s\n", colorer.Green(h3Listener.LocalAddr()))
+	}
+
+	tlsErrCh := make(chan error, 1)
+	h3ErrCh := make(chan error, 1)
+	go func() {
+		tlsErrCh <- e.StartServer(ts)
+	}()
+	go func() {
+		h3ErrCh <- h3s.Serve(h3Listener)
+	}()
+
+	select {
+	case err = <-tlsErrCh:
+		// HTTPS server stopped by `Echo#Close` or failed, HTTP/3 server is closed as Echo does not know about it
+		_ = s.Close()
+		<-h3ErrCh
+	case err = <-h3ErrCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			_ = ts.Close()
+		}
+		// HTTP/3 server is shut down first by `Echo#Shutdown` hook, HTTPS server is shut down by Echo itself
+		if tlsErr := <-tlsErrCh; errors.Is(err, http.ErrServerClosed) {
+			err = tlsErr
+		}
+	}
+	return err
+}
+
+// ListenerAddr returns net.Addr for HTTP/3 listener. Returns nil when server has not been started yet.
+func (s *Server) ListenerAddr() net.Addr {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.Listener == nil {
+		return nil
+	}
+	return s.Listener.LocalAddr()
+}
+
+// Shutdown gracefully shuts down HTTP/3 server and closes its listener.
+func (s *Server) Shutdown(ctx stdContext.Context) error {
+	s.mutex.RLock()
+	h3s, l := s.Server, s.Listener
+	s.mutex.RUnlock()
+	err := h3s.Shutdown(ctx)
+	if l != nil {
+		_ = l.Close()
+	}
+	return err
+}
+
+// Close immediately closes HTTP/3 server and its listener.
+func (s *Server) Close() error {
+	s.mutex.RLock()
+	h3s, l := s.Server, s.Listener
+	s.mutex.RUnlock()
+	err := h3s.Close()
+	if l != nil {
+		_ = l.Close()
+	}
+	return err
+}
+
+func loadCertificate(certFile, keyFile interface{}) (tls.Certificate, error) {
+	cert, err := filepathOrContent(certFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	key, err := filepathOrContent(keyFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(cert, key)
+}
+
+func filepathOrContent(fileOrContent interface{}) ([]byte, error) {
+	switch v := fileOrContent.(type) {
+	case string:
+		return os.ReadFile(v)
+	case []byte:
+		return v, nil
+	default:
+		return nil, echo.ErrInvalidCertOrKeyType
+	}
+}
@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package http3
+
+import (
+	stdContext "context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	echo "github.com/jialequ/agent"
+	quic "github.com/quic-go/quic-go/http3"
+	"github.com/stretchr/testify/assert"
+)
+
+// testCertificate creates self-signed certificate for given DNS names and returns it PEM encoded.
+func testCertificate(t *testing.T, names ...string) (certPEM []byte, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func waitForStart(t *testing.T, e *echo.Echo, s *Server, errCh <-chan error) bool {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-errCh:
+			t.Fatalf("server stopped: %v", err)
+		default:
+		}
+		if e.TLSListenerAddr() != nil && s.ListenerAddr() != nil {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("server did not start")
+	return false
+}
+
+func getBody(t *testing.T, client *http.Client, url string) (*http.Response, string) {
+	res, err := client.Get(url)
+	if !assert.NoError(t, err) {
+		return nil, ""
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	return res, string(body)
+}
+
+func TestServerStartTLS(t *testing.T) {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Request().Proto)
+	})
+	s := New(e)
+
+	cert, key := testCertificate(t, "localhost")
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.StartTLS("127.0.0.1:0", cert, key)
+	}()
+	if !waitForStart(t, e, s, errCh) {
+		return
+	}
+	h3Addr := s.ListenerAddr()
+	tlsAddr := e.TLSListenerAddr().String()
+	assert.Equal(t, tlsAddr, h3Addr.String())
+
+	tlsClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	res, body := getBody(t, tlsClient, "https://"+tlsAddr+"/")
+	if assert.NotNil(t, res) {
+		port := strconv.Itoa(h3Addr.(*net.UDPAddr).Port)
+		assert.Equal(t, `h3=":`+port+`"; ma=2592000`, res.Header.Get("Alt-Svc"))
+		assert.Equal(t, "HTTP/1.1", body)
+	}
+
+	h3Transport := &quic.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	defer h3Transport.Close()
+	res, body = getBody(t, &http.Client{Transport: h3Transport, Timeout: 5 * time.Second}, "https://"+h3Addr.String()+"/")
+	if assert.NotNil(t, res) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "", res.Header.Get("Alt-Svc"))
+		assert.Equal(t, "HTTP/3.0", body)
+	}
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, e.Shutdown(ctx))
+	select {
+	case err := <-errCh:
+		assert.Equal(t, http.ErrServerClosed, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("servers did not stop after shutdown")
+	}
+}
+
+func TestServerStartTLSClose(t *testing.T) {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	s := New(e)
+
+	cert, key := testCertificate(t, "localhost")
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.StartTLS("127.0.0.1:0", cert, key)
+	}()
+	if !waitForStart(t, e, s, errCh) {
+		return
+	}
+
+	assert.NoError(t, e.Close())
+	select {
+	case err := <-errCh:
+		assert.Equal(t, http.ErrServerClosed, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("servers did not stop after close")
+	}
+}
+
+func TestServerStartTLSInvalidCertificate(t *testing.T) {
+	e := echo.New()
+	s := New(e)
+	err := s.StartTLS("127.0.0.1:0", []byte("invalid"), []byte("invalid"))
+	assert.Error(t, err)
+	assert.Nil(t, s.ListenerAddr())
+
+	err = s.StartTLS("127.0.0.1:0", 1, 1)
+	assert.Equal(t, echo.ErrInvalidCertOrKeyType, err)
+}
@@ -112,6 +112,27 @@ To control this behavior, use [`TrustOption`](https://godoc.org/github.com/labst
 > **Never forget** to configure the outermost proxy (i.e.; at the edge of your infrastructure) **not to pass through incoming headers**.
 > Otherwise there is a chance of fraud, as it is what clients can control.
 
+## Case 4. With proxies using `Forwarded` header
+
+[`Forwarded`](https://datatracker.ietf.org/doc/html/rfc7239) is the standardized replacement of `X-Forwarded-*` headers.
+Each proxy appends an element (i.e. `for=192.0.2.60;proto=https`) in the same way as with XFF, so same rule applies:
+use **first _untrustable_ IP reading from right**.
+
+```go
+e.IPExtractor = echo.ExtractIPFromForwardedHeader()
+```
+
+## Case 5. With proxies using PROXY protocol
+
+L4 proxies (i.e. HAProxy or AWS NLB) can relay client address with [PROXY protocol](https://www.haproxy.org/download/2.8/doc/proxy-protocol.txt)
+at the start of the TCP connection. Set `Echo#ProxyProtocol` (or wrap your listener with `echo.NewProxyProtocolListener`)
+so that `Request.RemoteAddr` is client address and use `echo.ExtractIPDirect()`.
+
+```go
+e.ProxyProtocol = &echo.ProxyProtocolConfig{TrustedProxies: []*net.IPNet{lbIPRange}}
+e.IPExtractor = echo.ExtractIPDirect()
+```
+
 ## About default behavior
 
 In default behavior, Echo sees all of first XFF header, X-Real-IP header and IP from network layer.
@@ -273,3 +294,72 @@ func ExtractIPFromXFFHeader(options ...TrustOption) IPExtractor {
 		return strings.TrimSpace(ips[0])
 	}
 }
+
+// ExtractIPFromForwardedHeader extracts IP address using RFC 7239 `Forwarded` header.
+// Use this if you put proxy which uses this header.
+// This returns nearest untrustable IP. If all IPs are trustable, returns furthest one (i.e.: first `for=` element).
+func ExtractIPFromForwardedHeader(options ...TrustOption) IPExtractor {
+	checker := newIPChecker(options)
+	return func(req *http.Request) string {
+		directIP := extractIP(req)
+		values := req.Header[HeaderForwarded]
+		if len(values) == 0 || !checker.trust(net.ParseIP(directIP)) {
+			return directIP
+		}
+		elements := strings.Split(strings.Join(values, ","), ",")
+		for i := len(elements) - 1; i >= 0; i-- {
+			ip := net.ParseIP(forwardedForIP(forwardedParam(elements[i], "for")))
+			if ip == nil {
+				// Obfuscated identifier (i.e. `unknown`, `_hidden`) or missing `for`; cannot trust entire records
+				return directIP
+			}
+			if !checker.trust(ip) {
+				return ip.String()
+			}
+			if i == 0 {
+				// All of the IPs are trusted; return first element because it is furthest from server (best effort strategy).
+				return ip.String()
+			}
+		}
+		return directIP
+	}
+}
+
+// forwardedParam returns value of parameter from single `Forwarded` header element i.e. `for=192.0.2.60;proto=http`.
+func forwardedParam(element string, name string) string {
+	for _, pair := range strings.Split(element, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(k), name) {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+			v = v[1 : len(v)-1]
+		}
+		return v
+	}
+	return ""
+}
+
+// forwardedForIP strips optional port and brackets from `for` node i.e. `"[2001:db8:cafe::17]:4711"`.
+func forwardedForIP(node string) string {
+	if strings.HasPrefix(node, "[") {
+		if i := strings.IndexByte(node, ']'); i != -1 {
+			return node[1:i]
+		}
+		return ""
+	}
+	if strings.Count(node, ":") == 1 {
+		node, _, _ = strings.Cut(node, ":")
+	}
+	return node
+}
+
+// forwardedProto returns `proto` parameter of the first (client facing) `Forwarded` header element.
+func forwardedProto(header string) string {
+	if header == "" {
+		return ""
+	}
+	first, _, _ := strings.Cut(header, ",")
+	return forwardedParam(first, "proto")
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractIPFromForwardedHeader(t *testing.T) {
+	_, ipForTrustRange, _ := net.ParseCIDR("203.0.113.0/24")
+
+	var testCases = []struct {
+		name             string
+		givenTrustOption []TrustOption
+		whenRequest      http.Request
+		expectIP         string
+	}{
+		{
+			name: "request has no header, return direct IP",
+			whenRequest: http.Request{
+				RemoteAddr: "127.0.0.1:8080",
+			},
+			expectIP: "127.0.0.1",
+		},
+		{
+			name: "request from untrusted direct IP, header is ignored",
+			whenRequest: http.Request{
+				Header:     http.Header{HeaderForwarded: []string{"for=192.0.2.1"}},
+				RemoteAddr: "8.8.8.8:8080",
+			},
+			expectIP: "8.8.8.8",
+		},
+		{
+			name: "return first untrusted IP reading from right",
+			whenRequest: http.Request{
+				Header: http.Header{HeaderForwarded: []string{
+					`for=192.0.2.1;proto=https, for="[2001:db8:cafe::17]:4711"`,
+					`for=10.0.0.2:8080;by=10.0.0.3`,
+				}},
+				RemoteAddr: "127.0.0.1:8080",
+			},
+			expectIP: "2001:db8:cafe::17",
+		},
+		{
+			name: "case insensitive parameter name and quoted ipv4 with port",
+			whenRequest: http.Request{
+				Header:     http.Header{HeaderForwarded: []string{`For="192.0.2.43:47011"`}},
+				RemoteAddr: "127.0.0.1:8080",
+			},
+			expectIP: "192.0.2.43",
+		},
+		{
+			name:             "extra trusted range is skipped",
+			givenTrustOption: []TrustOption{TrustIPRange(ipForTrustRange)},
+			whenRequest: http.Request{
+				Header:     http.Header{HeaderForwarded: []string{"for=192.0.2.1, for=203.0.113.199"}},
+				RemoteAddr: "127.0.0.1:8080",
+			},
+			expectIP: "192.0.2.1",
+		},
+		{
+			name: "obfuscated identifier, return direct IP",
+			whenRequest: http.Request{
+				Header:     http.Header{HeaderForwarded: []string{"for=192.0.2.1, for=_hidden"}},
+				RemoteAddr: "127.0.0.1:8080",
+			},
+			expectIP: "127.0.0.1",
+		},
+		{
+			name: "element without for, return direct IP",
+			whenRequest: http.Request{
+				Header:     http.Header{HeaderForwarded: []string{"proto=https"}},
+				RemoteAddr: "127.0.0.1:8080",
+			},
+			expectIP: "127.0.0.1",
+		},
+		{
+			name: "all IPs are trusted, return furthest one",
+			whenRequest: http.Request{
+				Header:     http.Header{HeaderForwarded: []string{"for=10.0.0.1, for=10.0.0.2"}},
+				RemoteAddr: "127.0.0.1:8080",
+			},
+			expectIP: "10.0.0.1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			extractedIP := ExtractIPFromForwardedHeader(tc.givenTrustOption...)(&tc.whenRequest)
+			assert.Equal(t, tc.expectIP, extractedIP)
+		})
+	}
+}
@@ -0,0 +1,270 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	stdContext "context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// DefaultGracePeriod is the default time `Echo#Run` gives `Echo#Shutdown` to finish in-flight requests and close
+// tracked connections.
+const DefaultGracePeriod = 10 * time.Second
+
+// ErrNoListener is returned by `Echo#Run` and `Echo#StartListeners` when no address or listener is configured.
+var ErrNoListener = errors.New("echo: no address or listener configured")
+
+// RunConfig defines the config for `Echo#Run`.
+type RunConfig struct {
+	// Address is address of HTTP listener (see `Echo#Start`). Can be empty when `Echo#Listener` is set.
+	Address string
+
+	// H2CServer enables HTTP/2 Cleartext for HTTP listener (see `Echo#StartH2CServer`).
+	// Optional.
+	H2CServer *http2.Server
+
+	// TLSAddress is address of HTTPS listener (see `Echo#StartTLS`). Can be empty when `Echo#TLSListener` is set.
+	// Optional.
+	TLSAddress string
+	// TLSCertFile is certificate file path or content for HTTPS listener.
+	TLSCertFile interface{}
+	// TLSKeyFile is key file path or content for HTTPS listener.
+	TLSKeyFile interface{}
+
+	// GracePeriod is time given to `Echo#Shutdown` after signal has been received.
+	// Optional. Default value DefaultGracePeriod.
+	GracePeriod time.Duration
+
+	// Signals that start graceful shutdown.
+	// Optional. Default value os.Interrupt and syscall.SIGTERM.
+	Signals []os.Signal
+}
+
+// OnStart registers a function to call in `Echo#Run` before listeners are started. Functions are called in
+// registration order and first error aborts `Run`.
+func (e *Echo) OnStart(fn func(ctx stdContext.Context) error) {
+	e.startupMutex.Lock()
+	defer e.startupMutex.Unlock()
+	e.startHooks = append(e.startHooks, fn)
+}
+
+// ShutdownNotify returns channel that is closed when `Echo#Shutdown` begins. Handlers serving long-lived responses
+// (SSE, streaming) or hijacked connections (WebSocket) should select on it and finish.
+//
+// Hijacked connections are wrapped so Shutdown can close them when grace period ends. Handlers that need the
+// concrete connection type (i.e. `*tls.Conn`) get it with `NetConn()` method:
+//
+//	conn, _, _ := c.Response().Hijack()
+//	if nc, ok := conn.(interface{ NetConn() net.Conn }); ok {
+//		tlsConn, isTLS := nc.NetConn().(*tls.Conn)
+//	}
+func (e *Echo) ShutdownNotify() <-chan struct{} {
+	return e.connections.shutdownNotify()
+}
+
+// Run calls OnStart hooks, starts configured listeners (including ones added with `Echo#AddListener`) and blocks
+// until context is done, one of the configured signals is received or a listener fails. Then it shuts the server
+// down gracefully within the grace period (see `Echo#Shutdown`). Returns the first fatal error from hooks, listeners
+// or shutdown.
+//
+// Example:
+//
+//	e := echo.New()
+//	e.OnShutdown(func(ctx context.Context) { db.Close() })
+//	if err := e.Run(context.Background(), echo.RunConfig{Address: ":8080"}); err != nil {
+//		e.Logger.Fatal(err)
+//	}
+func (e *Echo) Run(ctx stdContext.Context, config RunConfig) error {
+	if config.GracePeriod <= 0 {
+		config.GracePeriod = DefaultGracePeriod
+	}
+	if len(config.Signals) == 0 {
+		config.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	e.startupMutex.RLock()
+	hooks := e.startHooks
+	startHTTP := config.Address != "" || e.Listener != nil
+	startTLS := config.TLSAddress != "" || e.TLSListener != nil
+	startNamed := len(e.listeners) > 0
+	e.startupMutex.RUnlock()
+	if !startHTTP && !startTLS && !startNamed {
+		return ErrNoListener
+	}
+
+	for _, fn := range hooks {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, config.Signals...)
+	defer signal.Stop(sigCh)
+
+	errCh := make(chan error, 3)
+	running := 0
+	if startHTTP {
+		running++
+		go func() {
+			if config.H2CServer != nil {
+				errCh <- e.StartH2CServer(config.Address, config.H2CServer)
+				return
+			}
+			errCh <- e.Start(config.Address)
+		}()
+	}
+	if startTLS {
+		running++
+		go func() {
+			errCh <- e.StartTLS(config.TLSAddress, config.TLSCertFile, config.TLSKeyFile)
+		}()
+	}
+	if startNamed {
+		running++
+		go func() {
+			errCh <- e.StartListeners()
+		}()
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+	case <-sigCh:
+	case err := <-errCh:
+		running--
+		runErr = listenerError(err)
+	}
+
+	shutdownCtx, cancel := stdContext.WithTimeout(stdContext.Background(), config.GracePeriod)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil && runErr == nil {
+		runErr = err
+	}
+	for ; running > 0; running-- {
+		if err := listenerError(<-errCh); err != nil && runErr == nil {
+			runErr = err
+		}
+	}
+	return runErr
+}
+
+// listenerError filters out error that listener returns after graceful shutdown.
+func listenerError(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// connectionTracker tracks hijacked connections so they can be closed when shutdown grace period ends.
+type connectionTracker struct {
+	mutex      sync.Mutex
+	conns      map[*trackedConn]struct{}
+	shutdownCh chan struct{}
+	closed     chan struct{} // signalled when tracked connection is closed
+}
+
+func (t *connectionTracker) init() {
+	if t.shutdownCh == nil {
+		t.shutdownCh = make(chan struct{})
+		t.conns = make(map[*trackedConn]struct{})
+		t.closed = make(chan struct{}, 1)
+	}
+}
+
+func (t *connectionTracker) shutdownNotify() <-chan struct{} {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.init()
+	return t.shutdownCh
+}
+
+func (t *connectionTracker) beginShutdown() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.init()
+	select {
+	case <-t.shutdownCh:
+	default:
+		close(t.shutdownCh)
+	}
+}
+
+func (t *connectionTracker) track(conn net.Conn) net.Conn {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.init()
+	tc := &trackedConn{Conn: conn, tracker: t}
+	t.conns[tc] = struct{}{}
+	return tc
+}
+
+func (t *connectionTracker) untrack(tc *trackedConn) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.conns, tc)
+	select {
+	case t.closed <- struct{}{}:
+	default:
+	}
+}
+
+// drain waits until all tracked connections are closed or context is done. Connections still open when context is
+// done are closed and context error is returned.
+func (t *connectionTracker) drain(ctx stdContext.Context) error {
+	for {
+		t.mutex.Lock()
+		t.init()
+		if len(t.conns) == 0 {
+			t.mutex.Unlock()
+			return nil
+		}
+		t.mutex.Unlock()
+
+		select {
+		case <-t.closed:
+		case <-ctx.Done():
+			t.mutex.Lock()
+			conns := make([]*trackedConn, 0, len(t.conns))
+			for tc := range t.conns {
+				conns = append(conns, tc)
+			}
+			t.mutex.Unlock()
+			for _, tc := range conns {
+				_ = tc.Close()
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// trackedConn is hijacked connection that removes itself from tracker when closed.
+type trackedConn struct {
+	net.Conn
+	tracker *connectionTracker
+	once    sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() {
+		c.tracker.untrack(c)
+	})
+	return err
+}
+
+// NetConn returns the underlying connection.
+func (c *trackedConn) NetConn() net.Conn {
+	return c.Conn
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+//go:build !windows
+
+package echo
+
+import (
+	stdContext "context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEchoRunSignal(t *testing.T) {
+	e := New()
+	e.HideBanner = true
+	e.HidePort = true
+
+	shutdownCh := make(chan struct{}, 1)
+	e.OnShutdown(func(ctx stdContext.Context) {
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+		shutdownCh <- struct{}{}
+	})
+
+	gracePeriod := 2 * time.Second
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- e.Run(stdContext.Background(), RunConfig{Address: "127.0.0.1:0", GracePeriod: gracePeriod})
+	}()
+	assert.NoError(t, waitForServerStart(e, errCh, false))
+
+	start := time.Now()
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), gracePeriod)
+	case <-time.After(2 * gracePeriod):
+		t.Fatal("Run did not return after SIGTERM")
+	}
+	select {
+	case <-shutdownCh:
+	default:
+		t.Fatal("OnShutdown hook was not called")
+	}
+}
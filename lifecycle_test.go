@@ -0,0 +1,204 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	stdContext "context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEchoRun(t *testing.T) {
+	e := New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+
+	var calls []string
+	e.OnStart(func(ctx stdContext.Context) error {
+		calls = append(calls, "start1")
+		return nil
+	})
+	e.OnStart(func(ctx stdContext.Context) error {
+		calls = append(calls, "start2")
+		return nil
+	})
+	e.OnShutdown(func(ctx stdContext.Context) {
+		calls = append(calls, "shutdown")
+	})
+
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- e.Run(ctx, RunConfig{Address: "127.0.0.1:0", GracePeriod: time.Second})
+	}()
+	assert.NoError(t, waitForServerStart(e, errCh, false))
+
+	res, err := http.Get("http://" + e.ListenerAddr().String() + "/")
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "OK", string(body))
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after context was cancelled")
+	}
+	assert.Equal(t, []string{"start1", "start2", "shutdown"}, calls)
+}
+
+func TestEchoRunStartHookError(t *testing.T) {
+	e := New()
+	hookErr := errors.New("migration failed")
+	e.OnStart(func(ctx stdContext.Context) error {
+		return hookErr
+	})
+
+	err := e.Run(stdContext.Background(), RunConfig{Address: "127.0.0.1:0"})
+	assert.Equal(t, hookErr, err)
+	assert.Nil(t, e.ListenerAddr())
+}
+
+func TestEchoRunListenerError(t *testing.T) {
+	e := New()
+	e.HideBanner = true
+
+	err := e.Run(stdContext.Background(), RunConfig{Address: "127.0.0.1:-1"})
+	assert.Error(t, err)
+}
+
+func TestEchoRunNoListener(t *testing.T) {
+	e := New()
+	assert.Equal(t, ErrNoListener, e.Run(stdContext.Background(), RunConfig{}))
+}
+
+func TestEchoShutdownClosesHijackedConnections(t *testing.T) {
+	e := New()
+	e.HideBanner = true
+	e.HidePort = true
+	hijacked := make(chan struct{})
+	e.GET("/ws", func(c Context) error {
+		conn, _, err := c.Response().Hijack()
+		if err != nil {
+			return err
+		}
+		close(hijacked)
+		<-c.Echo().ShutdownNotify()
+		// handler ignores shutdown notification and keeps connection open
+		_ = conn
+		return nil
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- e.Start("127.0.0.1:0")
+	}()
+	assert.NoError(t, waitForServerStart(e, errCh, false))
+
+	conn, err := net.Dial("tcp", e.ListenerAddr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	assert.NoError(t, err)
+	<-hijacked
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, e.Shutdown(ctx), stdContext.DeadlineExceeded)
+
+	// server closed hijacked connection so client read ends
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestEchoShutdownDrainsOnServerShutdownError(t *testing.T) {
+	e := New()
+	e.HideBanner = true
+	e.HidePort = true
+	hijacked := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	e.GET("/ws", func(c Context) error {
+		if _, _, err := c.Response().Hijack(); err != nil {
+			return err
+		}
+		close(hijacked)
+		<-release
+		return nil
+	})
+	slow := make(chan struct{})
+	e.GET("/slow", func(c Context) error {
+		close(slow)
+		<-release
+		return c.NoContent(http.StatusOK)
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- e.Start("127.0.0.1:0")
+	}()
+	assert.NoError(t, waitForServerStart(e, errCh, false))
+
+	conn, err := net.Dial("tcp", e.ListenerAddr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	assert.NoError(t, err)
+	<-hijacked
+
+	// in-flight request makes http.Server#Shutdown fail with deadline
+	slowConn, err := net.Dial("tcp", e.ListenerAddr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer slowConn.Close()
+	_, err = slowConn.Write([]byte("GET /slow HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	assert.NoError(t, err)
+	<-slow
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, e.Shutdown(ctx), stdContext.DeadlineExceeded)
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestEchoShutdownNotify(t *testing.T) {
+	e := New()
+	notify := e.ShutdownNotify()
+	select {
+	case <-notify:
+		t.Fatal("notify channel closed before shutdown")
+	default:
+	}
+
+	assert.NoError(t, e.Shutdown(stdContext.Background()))
+	select {
+	case <-notify:
+	default:
+		t.Fatal("notify channel not closed after shutdown")
+	}
+	// second shutdown does not panic on closed channel
+	assert.NoError(t, e.Shutdown(stdContext.Background()))
+}
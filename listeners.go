@@ -0,0 +1,330 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	stdContext "context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Errors returned when adding named listeners.
+var (
+	ErrListenerNameRequired = errors.New("listener name is required")
+	ErrListenerExists       = errors.New("listener with given name already exists")
+	ErrListenersStarted     = errors.New("listeners are already started")
+)
+
+// listenFdsStart is the first file descriptor passed by systemd socket activation.
+const listenFdsStart = 3
+
+// ListenerConfig defines named listener served by `Echo#StartListeners`.
+type ListenerConfig struct {
+	// Name identifies listener i.e. in `Echo#NamedListenerAddr`. Required and must be unique.
+	Name string
+
+	// Network is network of listener created by Echo: "tcp", "tcp4", "tcp6" or "unix".
+	// Optional. Default value "tcp".
+	Network string
+	// Address is address (or socket file path for "unix" network) of listener created by Echo.
+	Address string
+
+	// Listener is already created listener (i.e. from systemd socket activation). When set Network and Address are
+	// ignored.
+	// Optional.
+	Listener net.Listener
+
+	// TLSConfig makes listener serve HTTPS.
+	// Optional.
+	TLSConfig *tls.Config
+
+	// Group restricts listener to serve only routes with path prefix of the group. Requests for other paths are
+	// responded with 404 Not Found.
+	// Optional. By default, all routes are served.
+	Group *Group
+}
+
+// namedListener is listener added with `Echo#AddListener` and server serving it.
+type namedListener struct {
+	config   ListenerConfig
+	listener net.Listener
+	server   *http.Server
+}
+
+// AddListener adds named listener to be served by `Echo#StartListeners` (or `Echo#Run`) in addition to `Listener`
+// and `TLSListener`. All listeners share the Echo instance, its router and graceful shutdown.
+//
+// Example:
+//
+//	admin := e.Group("/admin")
+//	e.AddListener(echo.ListenerConfig{Name: "public", Address: ":8080"})
+//	e.AddListener(echo.ListenerConfig{Name: "public-tls", Address: ":8443", TLSConfig: tlsConfig})
+//	e.AddListener(echo.ListenerConfig{Name: "admin", Network: "unix", Address: "/run/app/admin.sock", Group: admin})
+//	if err := e.StartListeners(); err != http.ErrServerClosed {
+//		e.Logger.Fatal(err)
+//	}
+func (e *Echo) AddListener(config ListenerConfig) error {
+	if config.Name == "" {
+		return ErrListenerNameRequired
+	}
+	if config.Listener == nil {
+		if config.Network == "" {
+			config.Network = "tcp"
+		}
+		switch config.Network {
+		case "tcp", "tcp4", "tcp6", "unix":
+		default:
+			return ErrInvalidListenerNetwork
+		}
+	}
+
+	e.startupMutex.Lock()
+	defer e.startupMutex.Unlock()
+	for _, l := range e.listeners {
+		if l.config.Name == config.Name {
+			return ErrListenerExists
+		}
+	}
+	e.listeners = append(e.listeners, &namedListener{config: config})
+	return nil
+}
+
+// NamedListenerAddr returns net.Addr of listener added with `Echo#AddListener`. Returns nil when listener does not
+// exist or has not been started yet.
+func (e *Echo) NamedListenerAddr(name string) net.Addr {
+	e.startupMutex.RLock()
+	defer e.startupMutex.RUnlock()
+	for _, l := range e.listeners {
+		if l.config.Name == name && l.listener != nil {
+			return l.listener.Addr()
+		}
+	}
+	return nil
+}
+
+// StartListeners starts serving all listeners added with `Echo#AddListener` and blocks until all of them stop or
+// one of them fails. Returns as soon as any listener fails with error other than `http.ErrServerClosed` (other
+// listeners keep serving until `Echo#Shutdown` or `Echo#Close`) and `http.ErrServerClosed` when all listeners were
+// stopped.
+func (e *Echo) StartListeners() error {
+	e.startupMutex.Lock()
+	if len(e.listeners) == 0 {
+		e.startupMutex.Unlock()
+		return ErrNoListener
+	}
+	for _, l := range e.listeners {
+		if l.server != nil {
+			e.startupMutex.Unlock()
+			return ErrListenersStarted
+		}
+	}
+
+	e.colorer.SetOutput(e.Logger.Output())
+	if !e.HideBanner {
+		e.colorer.Printf(banner, e.colorer.Red("v"+Version), e.colorer.Blue(website))
+	}
+	for _, l := range e.listeners {
+		if err := e.configureNamedListener(l); err != nil {
+			for _, started := range e.listeners {
+				if started.listener != nil {
+					_ = started.listener.Close()
+				}
+				started.listener = nil
+				started.server = nil
+			}
+			e.startupMutex.Unlock()
+			return fmt.Errorf("listener %q: %w", l.config.Name, err)
+		}
+		if !e.HidePort {
+			scheme := "http"
+			if l.config.TLSConfig != nil {
+				scheme = "https"
+			}
+			e.colorer.Printf("⇨ %s server %s started on %s\n", scheme, l.config.Name, e.colorer.Green(l.listener.Addr()))
+		}
+	}
+	listeners := e.listeners
+	e.startupMutex.Unlock()
+
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l *namedListener) {
+			errCh <- l.server.Serve(l.listener)
+		}(l)
+	}
+	for range listeners {
+		if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	}
+	return http.ErrServerClosed
+}
+
+func (e *Echo) configureNamedListener(l *namedListener) error {
+	ln := l.config.Listener
+	if ln == nil {
+		var err error
+		if ln, err = newNamedListener(l.config.Network, l.config.Address); err != nil {
+			return err
+		}
+	}
+	ln = e.wrapProxyProtocol(ln)
+
+	s := &http.Server{
+		Handler:           e,
+		ReadTimeout:       e.Server.ReadTimeout,
+		ReadHeaderTimeout: e.Server.ReadHeaderTimeout,
+		WriteTimeout:      e.Server.WriteTimeout,
+		IdleTimeout:       e.Server.IdleTimeout,
+		MaxHeaderBytes:    e.Server.MaxHeaderBytes,
+		ConnContext:       e.Server.ConnContext,
+		ErrorLog:          e.StdLogger,
+	}
+	if s.ConnContext == nil {
+		s.ConnContext = ProxyProtocolConnContext
+	}
+	if l.config.Group != nil {
+		s.Handler = groupOnlyHandler(e, l.config.Group.prefix)
+	}
+	if l.config.TLSConfig != nil {
+		s.TLSConfig = l.config.TLSConfig.Clone()
+		if !e.DisableHTTP2 {
+			s.TLSConfig.NextProtos = append(s.TLSConfig.NextProtos, "h2")
+		}
+		ln = tls.NewListener(ln, s.TLSConfig)
+	}
+	l.listener = ln
+	l.server = s
+	return nil
+}
+
+func newNamedListener(network, address string) (net.Listener, error) {
+	if network == "unix" {
+		return net.Listen(network, address)
+	}
+	return newListener(address, network)
+}
+
+// groupOnlyHandler serves only routes with path under given prefix.
+func groupOnlyHandler(e *Echo, prefix string) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.serveHTTP(w, r, prefix)
+	})
+}
+
+// restrictRoute replaces matched handler with NotFoundHandler when route path is not under given prefix. Matched
+// route path is checked (not request path) so path normalization can not be used to reach other routes.
+func restrictRoute(c *context, prefix string) {
+	if prefix == "" {
+		return
+	}
+	path := c.path
+	if path == prefix || strings.HasPrefix(path, prefix+"/") {
+		return
+	}
+	c.path = ""
+	c.handler = NotFoundHandler
+}
+
+// shutdownListeners shuts down servers of named listeners. Must be called with startupMutex locked.
+func (e *Echo) shutdownListeners(ctx stdContext.Context) error {
+	var firstErr error
+	for _, l := range e.listeners {
+		if l.server == nil {
+			continue
+		}
+		if err := l.server.Shutdown(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// closeListeners closes servers of named listeners. Must be called with startupMutex locked.
+func (e *Echo) closeListeners() error {
+	var firstErr error
+	for _, l := range e.listeners {
+		if l.server == nil {
+			continue
+		}
+		if err := l.server.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// SystemdListeners returns configs of listeners passed by systemd socket activation (`LISTEN_PID`, `LISTEN_FDS`
+// and `LISTEN_FDNAMES` environment variables). Config name is file descriptor name from `FileDescriptorName=`
+// or "systemd-N" when name is not set. Repeated names get "-N" suffix (i.e. "web", "web-2") as listener names
+// must be unique. Environment variables are unset so child processes do not inherit them.
+// Returns nil when process was not socket activated.
+//
+// Example:
+//
+//	configs, err := echo.SystemdListeners()
+//	for _, config := range configs {
+//		e.AddListener(config)
+//	}
+func SystemdListeners() ([]ListenerConfig, error) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+	names := systemdListenerNames(count, strings.Split(os.Getenv("LISTEN_FDNAMES"), ":"))
+
+	configs := make([]ListenerConfig, 0, count)
+	for i, name := range names {
+		f := os.NewFile(uintptr(listenFdsStart+i), name)
+		l, err := net.FileListener(f)
+		_ = f.Close() // FileListener duplicates file descriptor
+		if err != nil {
+			for _, c := range configs {
+				_ = c.Listener.Close()
+			}
+			return nil, fmt.Errorf("systemd listener %q: %w", name, err)
+		}
+		configs = append(configs, ListenerConfig{Name: name, Listener: l})
+	}
+	return configs, nil
+}
+
+// systemdListenerNames returns unique names for count file descriptors.
+func systemdListenerNames(count int, fdNames []string) []string {
+	names := make([]string, count)
+	used := make(map[string]struct{}, count)
+	for i := range names {
+		base := "systemd-" + strconv.Itoa(i)
+		if i < len(fdNames) && fdNames[i] != "" {
+			base = fdNames[i]
+		}
+		name := base
+		for n := 2; ; n++ {
+			if _, ok := used[name]; !ok {
+				break
+			}
+			name = base + "-" + strconv.Itoa(n)
+		}
+		used[name] = struct{}{}
+		names[i] = name
+	}
+	return names
+}
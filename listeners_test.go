@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	stdContext "context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testCertificate creates self-signed certificate for given DNS names and returns it PEM encoded.
+func testCertificate(t *testing.T, notAfter time.Time, names ...string) (certPEM []byte, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func waitForNamedListeners(t *testing.T, e *Echo, errCh <-chan error, names ...string) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-errCh:
+			t.Fatalf("listeners stopped: %v", err)
+		default:
+		}
+		started := 0
+		for _, name := range names {
+			if e.NamedListenerAddr(name) != nil {
+				started++
+			}
+		}
+		if started == len(names) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("listeners did not start")
+}
+
+func getBody(t *testing.T, client *http.Client, url string) (int, string) {
+	res, err := client.Get(url)
+	if !assert.NoError(t, err) {
+		return 0, ""
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	return res.StatusCode, string(body)
+}
+
+func TestEchoStartListeners(t *testing.T) {
+	e := New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "public")
+	})
+	admin := e.Group("/admin")
+	admin.GET("/stats", func(c Context) error {
+		return c.String(http.StatusOK, "stats")
+	})
+
+	cert, err := tls.X509KeyPair(testCertificate(t, time.Now().Add(time.Hour), "localhost"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	socket := filepath.Join(t.TempDir(), "admin.sock")
+	assert.NoError(t, e.AddListener(ListenerConfig{Name: "http", Address: "127.0.0.1:0"}))
+	assert.NoError(t, e.AddListener(ListenerConfig{Name: "https", Address: "127.0.0.1:0", TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}}}))
+	assert.NoError(t, e.AddListener(ListenerConfig{Name: "admin", Network: "unix", Address: socket, Group: admin}))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- e.StartListeners()
+	}()
+	waitForNamedListeners(t, e, errCh, "http", "https", "admin")
+
+	code, body := getBody(t, http.DefaultClient, "http://"+e.NamedListenerAddr("http").String()+"/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "public", body)
+
+	tlsClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	code, body = getBody(t, tlsClient, "https://"+e.NamedListenerAddr("https").String()+"/admin/stats")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "stats", body)
+
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx stdContext.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	code, body = getBody(t, unixClient, "http://admin/admin/stats")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "stats", body)
+
+	code, _ = getBody(t, unixClient, "http://admin/")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = getBody(t, unixClient, "http://admin/admin/../")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, e.Shutdown(ctx))
+	assert.Equal(t, http.ErrServerClosed, <-errCh)
+}
+
+func TestEchoAddListenerErrors(t *testing.T) {
+	e := New()
+	assert.Equal(t, ErrListenerNameRequired, e.AddListener(ListenerConfig{Address: ":0"}))
+	assert.Equal(t, ErrInvalidListenerNetwork, e.AddListener(ListenerConfig{Name: "a", Network: "udp"}))
+	assert.NoError(t, e.AddListener(ListenerConfig{Name: "a", Address: "127.0.0.1:0"}))
+	assert.Equal(t, ErrListenerExists, e.AddListener(ListenerConfig{Name: "a", Address: "127.0.0.1:0"}))
+	assert.Nil(t, e.NamedListenerAddr("a"))
+	assert.Nil(t, e.NamedListenerAddr("unknown"))
+}
+
+func TestEchoStartListenersInvalidAddress(t *testing.T) {
+	e := New()
+	e.HideBanner = true
+	assert.NoError(t, e.AddListener(ListenerConfig{Name: "ok", Address: "127.0.0.1:0"}))
+	assert.NoError(t, e.AddListener(ListenerConfig{Name: "bad", Address: "127.0.0.1:-1"}))
+
+	err := e.StartListeners()
+	assert.ErrorContains(t, err, `listener "bad"`)
+	assert.Nil(t, e.NamedListenerAddr("ok"))
+}
+
+type failingListener struct {
+	net.Listener
+}
+
+func (l failingListener) Accept() (net.Conn, error) {
+	return nil, errors.New("accept failed")
+}
+
+func TestEchoStartListenersReturnsFirstError(t *testing.T) {
+	e := New()
+	e.HideBanner = true
+	e.HidePort = true
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, e.AddListener(ListenerConfig{Name: "ok", Address: "127.0.0.1:0"}))
+	assert.NoError(t, e.AddListener(ListenerConfig{Name: "failing", Listener: failingListener{Listener: ln}}))
+	defer e.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- e.StartListeners()
+	}()
+	select {
+	case err := <-errCh:
+		assert.EqualError(t, err, "accept failed")
+	case <-time.After(time.Second):
+		t.Fatal("StartListeners did not return on listener failure")
+	}
+}
+
+func TestEchoRunWithNamedListeners(t *testing.T) {
+	e := New()
+	e.HideBanner = true
+	e.HidePort = true
+	assert.NoError(t, e.AddListener(ListenerConfig{Name: "http", Address: "127.0.0.1:0"}))
+
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- e.Run(ctx, RunConfig{GracePeriod: time.Second})
+	}()
+	waitForNamedListeners(t, e, errCh, "http")
+	cancel()
+	assert.NoError(t, <-errCh)
+}
+
+func TestSystemdListeners(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	configs, err := SystemdListeners()
+	assert.NoError(t, err)
+	assert.Nil(t, configs)
+
+	// LISTEN_PID for other process is ignored
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	configs, err = SystemdListeners()
+	assert.NoError(t, err)
+	assert.Nil(t, configs)
+	_, ok := os.LookupEnv("LISTEN_FDS")
+	assert.False(t, ok)
+}
+
+func TestSystemdListenerNames(t *testing.T) {
+	assert.Equal(t,
+		[]string{"web", "web-2", "systemd-2", "web-3", "admin"},
+		systemdListenerNames(5, []string{"web", "web", "", "web", "admin"}),
+	)
+	assert.Equal(t, []string{"systemd-0", "systemd-1"}, systemdListenerNames(2, nil))
+}
@@ -40,3 +40,10 @@ type Logger interface {
 	Panicj(j log.JSON)
 	Panicf(format string, args ...interface{})
 }
+
+// RequestScopedLogger is implemented by loggers that can create logger bound to the request. When `Echo#Logger`
+// implements this interface `Context.Logger()` returns logger created with `ForContext`.
+type RequestScopedLogger interface {
+	// ForContext returns logger that adds request details (request ID, route etc.) to every logged message.
+	ForContext(c Context) Logger
+}
@@ -0,0 +1,308 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+//go:build go1.21
+
+package echo
+
+import (
+	"bytes"
+	stdContext "context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+// SlogLogger is `Logger` implementation that writes records to `log/slog` logger. It allows routing framework logs
+// into structured logging pipeline.
+//
+// Example:
+//
+//	e := echo.New()
+//	e.Logger = echo.NewSlogLogger(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+//
+// Logger is also `RequestScopedLogger` so `Context.Logger()` returns logger that adds request ID, route, method and
+// remote IP of the request to every record.
+type SlogLogger struct {
+	logger *slog.Logger
+	level  *slog.LevelVar
+	prefix string
+}
+
+// NewSlogLogger creates new SlogLogger writing records to given logger. When logger is nil `slog.Default()` is used.
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelDebug)
+	return &SlogLogger{logger: logger, level: level}
+}
+
+// Slog returns underlying slog logger.
+func (l *SlogLogger) Slog() *slog.Logger {
+	return l.logger
+}
+
+// With returns logger that adds given attributes to every record. Level is shared with the parent logger.
+func (l *SlogLogger) With(args ...any) *SlogLogger {
+	return &SlogLogger{logger: l.logger.With(args...), level: l.level, prefix: l.prefix}
+}
+
+// ForContext returns logger that adds request ID, route, method and remote IP of the request to every record.
+func (l *SlogLogger) ForContext(c Context) Logger {
+	req := c.Request()
+	args := make([]any, 0, 8)
+	if id := requestID(c); id != "" {
+		args = append(args, slog.String("request_id", id))
+	}
+	args = append(args,
+		slog.String("method", req.Method),
+		slog.String("route", c.Path()),
+		slog.String("remote_ip", c.RealIP()),
+	)
+	return l.With(args...)
+}
+
+// Output returns writer that logs every written line as record on info level. Useful for `http.Server.ErrorLog`.
+func (l *SlogLogger) Output() io.Writer {
+	return &slogWriter{logger: l}
+}
+
+// SetOutput replaces underlying logger with logger writing JSON records to given writer. Attributes added with
+// `With` are not retained.
+func (l *SlogLogger) SetOutput(w io.Writer) {
+	l.logger = slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: l.level}))
+}
+
+// Prefix returns prefix added as `prefix` attribute to every record.
+func (l *SlogLogger) Prefix() string {
+	return l.prefix
+}
+
+// SetPrefix sets prefix added as `prefix` attribute to every record.
+func (l *SlogLogger) SetPrefix(p string) {
+	l.prefix = p
+}
+
+// Level returns minimum level of records that are logged.
+func (l *SlogLogger) Level() log.Lvl {
+	switch lvl := l.level.Level(); {
+	case lvl <= slog.LevelDebug:
+		return log.DEBUG
+	case lvl <= slog.LevelInfo:
+		return log.INFO
+	case lvl <= slog.LevelWarn:
+		return log.WARN
+	case lvl <= slog.LevelError:
+		return log.ERROR
+	default:
+		return log.OFF
+	}
+}
+
+// SetLevel sets minimum level of records that are logged.
+func (l *SlogLogger) SetLevel(v log.Lvl) {
+	l.level.Set(slogLevel(v))
+}
+
+// SetHeader does nothing. Record format is defined by slog handler.
+func (l *SlogLogger) SetHeader(h string) {}
+
+// Print logs record on info level regardless of logger level (unless logging is turned off).
+func (l *SlogLogger) Print(i ...interface{}) {
+	l.print(fmt.Sprint(i...))
+}
+
+// Printf logs record on info level regardless of logger level (unless logging is turned off).
+func (l *SlogLogger) Printf(format string, args ...interface{}) {
+	l.print(fmt.Sprintf(format, args...))
+}
+
+// Printj logs record on info level regardless of logger level (unless logging is turned off).
+func (l *SlogLogger) Printj(j log.JSON) {
+	l.printj(j)
+}
+
+func (l *SlogLogger) Debug(i ...interface{}) {
+	l.log(slog.LevelDebug, fmt.Sprint(i...))
+}
+
+func (l *SlogLogger) Debugf(format string, args ...interface{}) {
+	l.log(slog.LevelDebug, fmt.Sprintf(format, args...))
+}
+
+func (l *SlogLogger) Debugj(j log.JSON) {
+	l.logj(slog.LevelDebug, j)
+}
+
+func (l *SlogLogger) Info(i ...interface{}) {
+	l.log(slog.LevelInfo, fmt.Sprint(i...))
+}
+
+func (l *SlogLogger) Infof(format string, args ...interface{}) {
+	l.log(slog.LevelInfo, fmt.Sprintf(format, args...))
+}
+
+func (l *SlogLogger) Infoj(j log.JSON) {
+	l.logj(slog.LevelInfo, j)
+}
+
+func (l *SlogLogger) Warn(i ...interface{}) {
+	l.log(slog.LevelWarn, fmt.Sprint(i...))
+}
+
+func (l *SlogLogger) Warnf(format string, args ...interface{}) {
+	l.log(slog.LevelWarn, fmt.Sprintf(format, args...))
+}
+
+func (l *SlogLogger) Warnj(j log.JSON) {
+	l.logj(slog.LevelWarn, j)
+}
+
+func (l *SlogLogger) Error(i ...interface{}) {
+	l.log(slog.LevelError, fmt.Sprint(i...))
+}
+
+func (l *SlogLogger) Errorf(format string, args ...interface{}) {
+	l.log(slog.LevelError, fmt.Sprintf(format, args...))
+}
+
+func (l *SlogLogger) Errorj(j log.JSON) {
+	l.logj(slog.LevelError, j)
+}
+
+// Fatal logs record on error level and exits with status 1.
+func (l *SlogLogger) Fatal(i ...interface{}) {
+	l.log(slog.LevelError, fmt.Sprint(i...))
+	os.Exit(1)
+}
+
+// Fatalj logs record on error level and exits with status 1.
+func (l *SlogLogger) Fatalj(j log.JSON) {
+	l.logj(slog.LevelError, j)
+	os.Exit(1)
+}
+
+// Fatalf logs record on error level and exits with status 1.
+func (l *SlogLogger) Fatalf(format string, args ...interface{}) {
+	l.log(slog.LevelError, fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
+
+// Panic logs record on error level and panics with the message.
+func (l *SlogLogger) Panic(i ...interface{}) {
+	msg := fmt.Sprint(i...)
+	l.log(slog.LevelError, msg)
+	panic(msg)
+}
+
+// Panicj logs record on error level and panics with the given JSON.
+func (l *SlogLogger) Panicj(j log.JSON) {
+	l.logj(slog.LevelError, j)
+	panic(j)
+}
+
+// Panicf logs record on error level and panics with the message.
+func (l *SlogLogger) Panicf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	l.log(slog.LevelError, msg)
+	panic(msg)
+}
+
+func (l *SlogLogger) print(msg string) {
+	if l.Level() != log.OFF {
+		l.logAttrs(slog.LevelInfo, msg, nil)
+	}
+}
+
+func (l *SlogLogger) printj(j log.JSON) {
+	if l.Level() != log.OFF {
+		l.logAttrs(slog.LevelInfo, "", jsonAttrs(j))
+	}
+}
+
+func (l *SlogLogger) log(level slog.Level, msg string) {
+	if level >= l.level.Level() {
+		l.logAttrs(level, msg, nil)
+	}
+}
+
+func (l *SlogLogger) logj(level slog.Level, j log.JSON) {
+	if level >= l.level.Level() {
+		l.logAttrs(level, "", jsonAttrs(j))
+	}
+}
+
+// logAttrs must be called from exported logging methods through exactly one helper so caller of these methods is
+// recorded as source of the record.
+func (l *SlogLogger) logAttrs(level slog.Level, msg string, attrs []slog.Attr) {
+	ctx := stdContext.Background()
+	h := l.logger.Handler()
+	if !h.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(4, pcs[:]) // skip [Callers, logAttrs, log/print helper, exported method]
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	if l.prefix != "" {
+		r.AddAttrs(slog.String("prefix", l.prefix))
+	}
+	r.AddAttrs(attrs...)
+	_ = h.Handle(ctx, r)
+}
+
+// requestID returns request ID set by `middleware.RequestID` or sent by client.
+func requestID(c Context) string {
+	if id := c.Response().Header().Get(HeaderXRequestID); id != "" {
+		return id
+	}
+	return c.Request().Header.Get(HeaderXRequestID)
+}
+
+func slogLevel(v log.Lvl) slog.Level {
+	switch v {
+	case log.DEBUG:
+		return slog.LevelDebug
+	case log.INFO:
+		return slog.LevelInfo
+	case log.WARN:
+		return slog.LevelWarn
+	case log.ERROR:
+		return slog.LevelError
+	default:
+		return slog.LevelError + 4 // nothing is logged
+	}
+}
+
+func jsonAttrs(j log.JSON) []slog.Attr {
+	keys := make([]string, 0, len(j))
+	for k := range j {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, slog.Any(k, j[k]))
+	}
+	return attrs
+}
+
+// slogWriter logs every written line as record on info level.
+type slogWriter struct {
+	logger *SlogLogger
+}
+
+func (w *slogWriter) Write(p []byte) (int, error) {
+	msg := string(bytes.TrimRight(p, "\r\n"))
+	if msg != "" && w.logger.Level() != log.OFF {
+		w.logger.logAttrs(slog.LevelInfo, msg, nil)
+	}
+	return len(p), nil
+}
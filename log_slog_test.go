@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+//go:build go1.21
+
+package echo
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSlogLogger(buf *bytes.Buffer) *SlogLogger {
+	return NewSlogLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+}
+
+func decodeSlogRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		r := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &r))
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestSlogLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := newTestSlogLogger(buf)
+	l.SetPrefix("echo")
+	l.SetLevel(log.WARN)
+	assert.Equal(t, log.WARN, l.Level())
+
+	l.Debug("debug")
+	l.Infof("info %d", 1)
+	l.Warnf("warn %d", 2)
+	l.Errorj(log.JSON{"key": "value"})
+	l.Print("print")
+
+	records := decodeSlogRecords(t, buf)
+	if assert.Len(t, records, 3) {
+		assert.Equal(t, "WARN", records[0]["level"])
+		assert.Equal(t, "warn 2", records[0]["msg"])
+		assert.Equal(t, "echo", records[0]["prefix"])
+
+		assert.Equal(t, "ERROR", records[1]["level"])
+		assert.Equal(t, "value", records[1]["key"])
+
+		assert.Equal(t, "INFO", records[2]["level"])
+		assert.Equal(t, "print", records[2]["msg"])
+	}
+
+	buf.Reset()
+	l.SetLevel(log.OFF)
+	assert.Equal(t, log.OFF, l.Level())
+	l.Error("error")
+	l.Print("print")
+	assert.Equal(t, "", buf.String())
+}
+
+func TestSlogLoggerOutput(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := newTestSlogLogger(buf)
+
+	_, err := l.Output().Write([]byte("http: TLS handshake error\n"))
+	assert.NoError(t, err)
+
+	records := decodeSlogRecords(t, buf)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "http: TLS handshake error", records[0]["msg"])
+	}
+}
+
+func TestSlogLoggerForContext(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := New()
+	e.Logger = newTestSlogLogger(buf)
+	e.GET("/users/:id", func(c Context) error {
+		c.Logger().Info("hello")
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(HeaderXRequestID, "abc")
+	req.RemoteAddr = "192.168.0.1:1234"
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	records := decodeSlogRecords(t, buf)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "hello", records[0]["msg"])
+		assert.Equal(t, "abc", records[0]["request_id"])
+		assert.Equal(t, http.MethodGet, records[0]["method"])
+		assert.Equal(t, "/users/:id", records[0]["route"])
+		assert.Equal(t, "192.168.0.1", records[0]["remote_ip"])
+	}
+}
@@ -0,0 +1,460 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	echo "github.com/jialequ/agent"
+)
+
+// Access log format names for AccessLogConfig.Format.
+const (
+	// AccessLogFormatCommon is Apache Common Log Format.
+	AccessLogFormatCommon = "common"
+	// AccessLogFormatCombined is Apache Combined Log Format (Common Log Format with referer and user agent).
+	AccessLogFormatCombined = "combined"
+	// AccessLogFormatLogfmt is logfmt (`key=value` pairs) format.
+	AccessLogFormatLogfmt = "logfmt"
+	// AccessLogFormatECS is Elastic Common Schema JSON format. Field names follow OpenTelemetry HTTP semantic
+	// conventions where ECS has no equivalent.
+	AccessLogFormatECS = "ecs"
+)
+
+// AccessLogEncoder encodes request values as single access log line.
+type AccessLogEncoder interface {
+	// Encode writes access log line (including trailing newline) for given values to buf.
+	Encode(buf *bytes.Buffer, v RequestLoggerValues)
+}
+
+// AccessLogEncoderFunc is an adapter to use ordinary function as AccessLogEncoder.
+type AccessLogEncoderFunc func(buf *bytes.Buffer, v RequestLoggerValues)
+
+// Encode calls f(buf, v).
+func (f AccessLogEncoderFunc) Encode(buf *bytes.Buffer, v RequestLoggerValues) {
+	f(buf, v)
+}
+
+// AccessLogEncoderByName returns built-in encoder for given format name.
+func AccessLogEncoderByName(name string) (AccessLogEncoder, error) {
+	switch strings.ToLower(name) {
+	case AccessLogFormatCommon:
+		return AccessLogEncoderFunc(encodeCommonLog), nil
+	case AccessLogFormatCombined:
+		return AccessLogEncoderFunc(encodeCombinedLog), nil
+	case AccessLogFormatLogfmt:
+		return AccessLogEncoderFunc(encodeLogfmt), nil
+	case AccessLogFormatECS:
+		return AccessLogEncoderFunc(encodeECS), nil
+	}
+	return nil, fmt.Errorf("unknown access log format: %q", name)
+}
+
+// AccessLogConfig defines the config for AccessLog middleware.
+type AccessLogConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// Format is name of the built-in access log format. Possible values: "common", "combined", "logfmt", "ecs".
+	// Optional. Default value "combined".
+	Format string
+
+	// Encoder is custom access log encoder. When set Format is ignored.
+	// Optional.
+	Encoder AccessLogEncoder
+
+	// Output is a writer where access log lines are written. Writes are done synchronously in request goroutine, wrap
+	// slow writers with `NewAsyncWriter` (and close it on shutdown).
+	// Optional. Default value os.Stdout.
+	Output io.Writer
+
+	// Redactor redacts sensitive data from logged URI.
+	// Optional. Default value nil (nothing is redacted).
+	Redactor *Redactor
+
+	// Sampler decides which requests are logged.
+	// Optional. Default value nil (all requests are logged).
+	Sampler *LogSampler
+}
+
+// DefaultAccessLogConfig is the default AccessLog middleware config.
+var DefaultAccessLogConfig = AccessLogConfig{
+	Skipper: DefaultSkipper,
+	Format:  AccessLogFormatCombined,
+}
+
+// AccessLog returns a middleware that writes access log lines in given format.
+func AccessLog(format string) echo.MiddlewareFunc {
+	config := DefaultAccessLogConfig
+	config.Format = format
+	return AccessLogWithConfig(config)
+}
+
+// AccessLogWithConfig returns an AccessLog middleware with config.
+// See: `AccessLog()`.
+func AccessLogWithConfig(config AccessLogConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultAccessLogConfig.Skipper
+	}
+	if config.Format == "" {
+		config.Format = DefaultAccessLogConfig.Format
+	}
+	if config.Encoder == nil {
+		enc, err := AccessLogEncoderByName(config.Format)
+		if err != nil {
+			panic(fmt.Errorf("echo: %w", err))
+		}
+		config.Encoder = enc
+	}
+	if config.Output == nil {
+		config.Output = os.Stdout
+	}
+
+	pool := sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+	return RequestLoggerWithConfig(RequestLoggerConfig{
+		Skipper:          config.Skipper,
+		HandleError:      true,
+		LogLatency:       true,
+		LogProtocol:      true,
+		LogRemoteIP:      true,
+		LogHost:          true,
+		LogMethod:        true,
+		LogURI:           true,
+		LogURIPath:       true,
+		LogRoutePath:     true,
+		LogRequestID:     true,
+		LogTraceID:       true,
+		LogReferer:       true,
+		LogUserAgent:     true,
+		LogStatus:        true,
+		LogError:         true,
+		LogContentLength: true,
+		LogResponseSize:  true,
+		Redactor:         config.Redactor,
+		Sampler:          config.Sampler,
+		LogValuesFunc: func(c echo.Context, v RequestLoggerValues) error {
+			buf := pool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer pool.Put(buf)
+
+			config.Encoder.Encode(buf, v)
+			_, err := config.Output.Write(buf.Bytes())
+			return err
+		},
+	})
+}
+
+const commonLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+func encodeCommonLog(buf *bytes.Buffer, v RequestLoggerValues) {
+	writeCommonLog(buf, v)
+	buf.WriteByte('\n')
+}
+
+func encodeCombinedLog(buf *bytes.Buffer, v RequestLoggerValues) {
+	writeCommonLog(buf, v)
+	buf.WriteString(` "`)
+	writeCommonLogString(buf, v.Referer)
+	buf.WriteString(`" "`)
+	writeCommonLogString(buf, v.UserAgent)
+	buf.WriteString("\"\n")
+}
+
+// writeCommonLog writes `host ident authuser [date] "request" status bytes` part of the line.
+func writeCommonLog(buf *bytes.Buffer, v RequestLoggerValues) {
+	writeCommonLogField(buf, v.RemoteIP)
+	buf.WriteString(" - - [")
+	buf.WriteString(v.StartTime.Format(commonLogTimeFormat))
+	buf.WriteString(`] "`)
+	writeCommonLogString(buf, v.Method+" "+v.URI+" "+v.Protocol)
+	buf.WriteString(`" `)
+	buf.WriteString(strconv.Itoa(v.Status))
+	buf.WriteByte(' ')
+	if v.ResponseSize > 0 {
+		buf.WriteString(strconv.FormatInt(v.ResponseSize, 10))
+	} else {
+		buf.WriteByte('-')
+	}
+}
+
+func writeCommonLogField(buf *bytes.Buffer, s string) {
+	if s == "" {
+		buf.WriteByte('-')
+		return
+	}
+	buf.WriteString(s)
+}
+
+// writeCommonLogString writes string escaping quotes, backslashes and control characters as Apache does.
+func writeCommonLogString(buf *bytes.Buffer, s string) {
+	if s == "" {
+		buf.WriteByte('-')
+		return
+	}
+	for i := 0; i < len(s); i++ {
+		switch b := s[i]; {
+		case b == '"' || b == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(b)
+		case b < 0x20 || b == 0x7f:
+			fmt.Fprintf(buf, `\x%02x`, b)
+		default:
+			buf.WriteByte(b)
+		}
+	}
+}
+
+func encodeLogfmt(buf *bytes.Buffer, v RequestLoggerValues) {
+	writeLogfmtPair(buf, "time", v.StartTime.Format(time.RFC3339Nano))
+	writeLogfmtPair(buf, "id", v.RequestID)
+	writeLogfmtPair(buf, "trace_id", v.TraceID)
+	writeLogfmtPair(buf, "span_id", v.SpanID)
+	writeLogfmtPair(buf, "remote_ip", v.RemoteIP)
+	writeLogfmtPair(buf, "host", v.Host)
+	writeLogfmtPair(buf, "method", v.Method)
+	writeLogfmtPair(buf, "uri", v.URI)
+	writeLogfmtPair(buf, "route", v.RoutePath)
+	writeLogfmtPair(buf, "protocol", v.Protocol)
+	writeLogfmtPair(buf, "status", strconv.Itoa(v.Status))
+	writeLogfmtPair(buf, "latency", v.Latency.String())
+	writeLogfmtPair(buf, "bytes_in", v.ContentLength)
+	writeLogfmtPair(buf, "bytes_out", strconv.FormatInt(v.ResponseSize, 10))
+	writeLogfmtPair(buf, "referer", v.Referer)
+	writeLogfmtPair(buf, "user_agent", v.UserAgent)
+	if v.Error != nil {
+		writeLogfmtPair(buf, "error", v.Error.Error())
+	}
+	buf.WriteByte('\n')
+}
+
+// writeLogfmtPair writes `key=value` pair. Empty values are omitted and values with spaces, quotes or `=` are quoted.
+func writeLogfmtPair(buf *bytes.Buffer, key, value string) {
+	if value == "" {
+		return
+	}
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(key)
+	buf.WriteByte('=')
+	if strings.ContainsAny(value, " =\"\\") || strings.IndexFunc(value, func(r rune) bool { return r < 0x20 }) != -1 {
+		buf.WriteString(strconv.Quote(value))
+		return
+	}
+	buf.WriteString(value)
+}
+
+type ecsLog struct {
+	Timestamp string     `json:"@timestamp"`
+	ECS       ecsVersion `json:"ecs"`
+	Event     ecsEvent   `json:"event"`
+	HTTP      ecsHTTP    `json:"http"`
+	URL       ecsURL     `json:"url"`
+	Client    ecsClient  `json:"client"`
+	UserAgent *ecsString `json:"user_agent,omitempty"`
+	Trace     *ecsString `json:"trace,omitempty"`
+	Span      *ecsString `json:"span,omitempty"`
+	Error     *ecsError  `json:"error,omitempty"`
+}
+
+type ecsVersion struct {
+	Version string `json:"version"`
+}
+
+type ecsEvent struct {
+	Kind     string `json:"kind"`
+	Category string `json:"category"`
+	Duration int64  `json:"duration"`
+	ID       string `json:"id,omitempty"`
+}
+
+type ecsHTTP struct {
+	Version  string          `json:"version,omitempty"`
+	Route    string          `json:"route,omitempty"`
+	Request  ecsHTTPRequest  `json:"request"`
+	Response ecsHTTPResponse `json:"response"`
+}
+
+type ecsHTTPRequest struct {
+	Method   string   `json:"method"`
+	Referrer string   `json:"referrer,omitempty"`
+	Body     *ecsBody `json:"body,omitempty"`
+}
+
+type ecsHTTPResponse struct {
+	StatusCode int     `json:"status_code"`
+	Body       ecsBody `json:"body"`
+}
+
+type ecsBody struct {
+	Bytes int64 `json:"bytes"`
+}
+
+type ecsURL struct {
+	Original string `json:"original"`
+	Path     string `json:"path"`
+	Domain   string `json:"domain,omitempty"`
+}
+
+type ecsClient struct {
+	IP string `json:"ip,omitempty"`
+}
+
+type ecsString struct {
+	ID       string `json:"id,omitempty"`
+	Original string `json:"original,omitempty"`
+}
+
+type ecsError struct {
+	Message string `json:"message"`
+}
+
+func encodeECS(buf *bytes.Buffer, v RequestLoggerValues) {
+	l := ecsLog{
+		Timestamp: v.StartTime.UTC().Format(time.RFC3339Nano),
+		ECS:       ecsVersion{Version: "8.11.0"},
+		Event:     ecsEvent{Kind: "event", Category: "web", Duration: int64(v.Latency), ID: v.RequestID},
+		HTTP: ecsHTTP{
+			Version:  strings.TrimPrefix(v.Protocol, "HTTP/"),
+			Route:    v.RoutePath,
+			Request:  ecsHTTPRequest{Method: v.Method, Referrer: v.Referer},
+			Response: ecsHTTPResponse{StatusCode: v.Status, Body: ecsBody{Bytes: v.ResponseSize}},
+		},
+		URL:    ecsURL{Original: v.URI, Path: v.URIPath, Domain: v.Host},
+		Client: ecsClient{IP: v.RemoteIP},
+	}
+	if n, err := strconv.ParseInt(v.ContentLength, 10, 64); err == nil {
+		l.HTTP.Request.Body = &ecsBody{Bytes: n}
+	}
+	if v.UserAgent != "" {
+		l.UserAgent = &ecsString{Original: v.UserAgent}
+	}
+	if v.TraceID != "" {
+		l.Trace = &ecsString{ID: v.TraceID}
+		l.Span = &ecsString{ID: v.SpanID}
+	}
+	if v.Error != nil {
+		l.Error = &ecsError{Message: v.Error.Error()}
+	}
+	// Encoder appends newline. Marshalling these types can not fail.
+	_ = json.NewEncoder(buf).Encode(l)
+}
+
+// DefaultAsyncWriterBufferSize is default number of pending writes AsyncWriter buffers before dropping new writes.
+const DefaultAsyncWriterBufferSize = 1024
+
+// AsyncWriter is a writer that never blocks the caller. Writes are copied and queued, and written to underlying writer
+// by background goroutine through buffered writer. When the queue is full the write is dropped and counted.
+type AsyncWriter struct {
+	// counters are first in struct for 64-bit alignment on 32-bit platforms
+	dropped uint64
+	written uint64
+	errors  uint64
+
+	out    *bufio.Writer
+	queue  chan []byte
+	done   chan struct{}
+	closed int32
+	mutex  sync.RWMutex
+}
+
+// NewAsyncWriter creates new AsyncWriter writing to w and starts its background goroutine. bufferSize is number of
+// pending writes queued before new writes are dropped.
+func NewAsyncWriter(w io.Writer, bufferSize int) *AsyncWriter {
+	if bufferSize <= 0 {
+		bufferSize = DefaultAsyncWriterBufferSize
+	}
+	aw := &AsyncWriter{
+		out:   bufio.NewWriter(w),
+		queue: make(chan []byte, bufferSize),
+		done:  make(chan struct{}),
+	}
+	go aw.run()
+	return aw
+}
+
+// Write queues copy of p for writing. It never blocks and always reports len(p) bytes written. Writes after Close
+// and writes not fitting into the queue are dropped.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	if atomic.LoadInt32(&w.closed) == 1 {
+		atomic.AddUint64(&w.dropped, 1)
+		return len(p), nil
+	}
+	b := make([]byte, len(p))
+	copy(b, p)
+	select {
+	case w.queue <- b:
+	default:
+		atomic.AddUint64(&w.dropped, 1)
+	}
+	return len(p), nil
+}
+
+// Dropped returns number of writes dropped because queue was full or writer was closed.
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Written returns number of writes written to underlying writer.
+func (w *AsyncWriter) Written() uint64 {
+	return atomic.LoadUint64(&w.written)
+}
+
+// Errors returns number of writes that failed to be written to underlying writer.
+func (w *AsyncWriter) Errors() uint64 {
+	return atomic.LoadUint64(&w.errors)
+}
+
+// Close stops accepting writes, writes all queued writes to underlying writer and flushes it.
+func (w *AsyncWriter) Close() error {
+	w.mutex.Lock()
+	if !atomic.CompareAndSwapInt32(&w.closed, 0, 1) {
+		w.mutex.Unlock()
+		return nil
+	}
+	close(w.queue)
+	w.mutex.Unlock()
+
+	<-w.done
+	return nil
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	for b := range w.queue {
+		w.write(b)
+		// flush when there is nothing more to write right now so lines do not stay in buffer while idle
+		if len(w.queue) == 0 {
+			if err := w.out.Flush(); err != nil {
+				atomic.AddUint64(&w.errors, 1)
+			}
+		}
+	}
+	if err := w.out.Flush(); err != nil {
+		atomic.AddUint64(&w.errors, 1)
+	}
+}
+
+func (w *AsyncWriter) write(b []byte) {
+	if _, err := w.out.Write(b); err != nil {
+		atomic.AddUint64(&w.errors, 1)
+		return
+	}
+	atomic.AddUint64(&w.written, 1)
+}
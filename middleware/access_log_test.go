@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	echo "github.com/jialequ/agent"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAccessLogValues() RequestLoggerValues {
+	return RequestLoggerValues{
+		StartTime:     time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*60*60)),
+		Latency:       1500 * time.Microsecond,
+		Protocol:      "HTTP/1.1",
+		RemoteIP:      "127.0.0.1",
+		Host:          "example.com",
+		Method:        http.MethodGet,
+		URI:           "/apache_pb.gif?a=1",
+		URIPath:       "/apache_pb.gif",
+		RoutePath:     "/:file",
+		RequestID:     "abc",
+		Referer:       "http://www.example.com/start.html",
+		UserAgent:     `Mozilla/4.08 "test"`,
+		Status:        http.StatusOK,
+		ContentLength: "10",
+		ResponseSize:  2326,
+	}
+}
+
+func TestAccessLogEncoders(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		whenFormat string
+		expect     string
+	}{
+		{
+			name:       "common",
+			whenFormat: AccessLogFormatCommon,
+			expect:     `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif?a=1 HTTP/1.1" 200 2326` + "\n",
+		},
+		{
+			name:       "combined",
+			whenFormat: "Combined",
+			expect: `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif?a=1 HTTP/1.1" 200 2326 ` +
+				`"http://www.example.com/start.html" "Mozilla/4.08 \"test\""` + "\n",
+		},
+		{
+			name:       "logfmt",
+			whenFormat: AccessLogFormatLogfmt,
+			expect: `time=2000-10-10T13:55:36-07:00 id=abc remote_ip=127.0.0.1 host=example.com method=GET ` +
+				`uri="/apache_pb.gif?a=1" route=/:file protocol=HTTP/1.1 status=200 latency=1.5ms bytes_in=10 ` +
+				`bytes_out=2326 referer=http://www.example.com/start.html user_agent="Mozilla/4.08 \"test\""` + "\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			enc, err := AccessLogEncoderByName(tc.whenFormat)
+			assert.NoError(t, err)
+
+			buf := new(bytes.Buffer)
+			enc.Encode(buf, testAccessLogValues())
+			assert.Equal(t, tc.expect, buf.String())
+		})
+	}
+}
+
+func TestAccessLogEncoderECS(t *testing.T) {
+	enc, err := AccessLogEncoderByName(AccessLogFormatECS)
+	assert.NoError(t, err)
+
+	v := testAccessLogValues()
+	v.TraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	v.SpanID = "00f067aa0ba902b7"
+	v.Error = errors.New("boom")
+	buf := new(bytes.Buffer)
+	enc.Encode(buf, v)
+
+	assert.True(t, strings.HasSuffix(buf.String(), "}\n"))
+	m := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	assert.Equal(t, "2000-10-10T20:55:36Z", m["@timestamp"])
+	assert.Equal(t, map[string]interface{}{"kind": "event", "category": "web", "duration": float64(1500000), "id": "abc"}, m["event"])
+	assert.Equal(t, map[string]interface{}{
+		"version":  "1.1",
+		"route":    "/:file",
+		"request":  map[string]interface{}{"method": "GET", "referrer": "http://www.example.com/start.html", "body": map[string]interface{}{"bytes": float64(10)}},
+		"response": map[string]interface{}{"status_code": float64(200), "body": map[string]interface{}{"bytes": float64(2326)}},
+	}, m["http"])
+	assert.Equal(t, map[string]interface{}{"original": "/apache_pb.gif?a=1", "path": "/apache_pb.gif", "domain": "example.com"}, m["url"])
+	assert.Equal(t, map[string]interface{}{"ip": "127.0.0.1"}, m["client"])
+	assert.Equal(t, map[string]interface{}{"id": "4bf92f3577b34da6a3ce929d0e0e4736"}, m["trace"])
+	assert.Equal(t, map[string]interface{}{"id": "00f067aa0ba902b7"}, m["span"])
+	assert.Equal(t, map[string]interface{}{"message": "boom"}, m["error"])
+}
+
+func TestAccessLogEncoderByNameUnknown(t *testing.T) {
+	_, err := AccessLogEncoderByName("xml")
+	assert.EqualError(t, err, `unknown access log format: "xml"`)
+
+	assert.PanicsWithError(t, `echo: unknown access log format: "xml"`, func() {
+		AccessLog("xml")
+	})
+}
+
+func TestAccessLog(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(AccessLogWithConfig(AccessLogConfig{Format: AccessLogFormatLogfmt, Output: buf}))
+	e.GET("/users/:id", func(c echo.Context) error {
+		return echo.ErrNotFound
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	line := buf.String()
+	assert.Contains(t, line, " method=GET uri=/users/1 route=/users/:id protocol=HTTP/1.1 status=404 ")
+	assert.Contains(t, line, ` error="code=404, message=Not Found"`)
+	assert.True(t, strings.HasSuffix(line, "\n"))
+}
+
+type blockingWriter struct {
+	mutex   sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestAsyncWriter(t *testing.T) {
+	out := &blockingWriter{release: make(chan struct{})}
+	w := NewAsyncWriter(out, 2)
+
+	for i := 0; i < 10; i++ {
+		n, err := w.Write([]byte("line\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, 5, n)
+	}
+	// at most 2 writes are queued and 1 is taken by background goroutine
+	assert.GreaterOrEqual(t, w.Dropped(), uint64(7))
+
+	close(out.release)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, uint64(10), w.Dropped()+w.Written())
+	assert.Equal(t, uint64(0), w.Errors())
+	assert.Equal(t, strings.Repeat("line\n", int(w.Written())), out.buf.String())
+
+	_, err := w.Write([]byte("after close\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(11), w.Dropped()+w.Written())
+	assert.NoError(t, w.Close())
+}
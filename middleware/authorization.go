@@ -0,0 +1,354 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+	echo "github.com/jialequ/agent"
+)
+
+// AuthorizationConfig defines the config for Authorization middleware.
+type AuthorizationConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// ContextKey is the key authentication middlewares (JWT, KeyAuth validator etc.) use to store the authenticated
+	// principal into context.
+	// Optional. Default value "user".
+	ContextKey string
+
+	// PrincipalExtractor converts value stored in context under ContextKey into AuthorizationPrincipal.
+	// Optional. Default implementation understands `*AuthorizationPrincipal`, `*jwt.Token` (claims "sub", "roles",
+	// "permissions" and "scope") and `string` values.
+	PrincipalExtractor AuthorizationPrincipalExtractor
+
+	// Permissions are required for every request passing through this middleware instance. This is useful when
+	// middleware is added to a group or a single route.
+	// Optional.
+	Permissions []string
+
+	// Rules defines permissions required by routes. Key is in the form of "<method> <path>" or "<path>" (any method)
+	// where path is the route path as it was registered (see `Context.Path()`). Path ending with `*` matches all
+	// routes having that prefix. Scopes from route metadata (`RouteMeta.RequireScopes()`, `Group.RequireScopes()`) are
+	// required in addition.
+	// Optional.
+	// Example:
+	// "GET /users/:id": {"users:read"},
+	// "/admin/*":       {"admin"},
+	Rules map[string][]string
+
+	// Roles defines role based access control (RBAC) rules. Key is role name and value is list of permissions that
+	// the role grants. Permission `*` grants everything.
+	// Optional.
+	Roles map[string][]string
+
+	// Policies are additional pluggable authorization policies. All policies must allow the request.
+	// Optional.
+	Policies []AuthorizationPolicy
+
+	// ErrorHandler defines a function which is executed when authorization fails. Error is either
+	// ErrAuthorizationPrincipalMissing (401) or ErrAuthorizationForbidden (403) or error returned by a policy.
+	// Optional.
+	ErrorHandler AuthorizationErrorHandler
+}
+
+// AuthorizationPrincipal is the authenticated subject whose permissions are checked.
+type AuthorizationPrincipal struct {
+	// ID is the identifier of the principal (i.e. JWT subject)
+	ID string
+	// Roles are role names assigned to principal. Role permissions are resolved with AuthorizationConfig.Roles.
+	Roles []string
+	// Permissions are granted to principal directly.
+	Permissions []string
+	// Value is the original value found in context.
+	Value interface{}
+}
+
+// AuthorizationPrincipalExtractor defines a function to convert authenticated value from context to principal.
+type AuthorizationPrincipalExtractor func(value interface{}, c echo.Context) (*AuthorizationPrincipal, error)
+
+// AuthorizationPolicy defines a function to decide if principal is allowed to access current route. `required`
+// contains permissions required by the route.
+type AuthorizationPolicy func(c echo.Context, principal *AuthorizationPrincipal, required []string) (bool, error)
+
+// AuthorizationErrorHandler defines a function which is executed when authorization fails.
+type AuthorizationErrorHandler func(err error, c echo.Context) error
+
+// RoutePermissions describes permissions required by a route.
+type RoutePermissions struct {
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+type authorizationRule struct {
+	method      string
+	path        string
+	isPrefix    bool
+	permissions []string
+}
+
+// ErrAuthorizationPrincipalMissing is returned when there is no authenticated principal in context.
+var ErrAuthorizationPrincipalMissing = echo.NewHTTPError(http.StatusUnauthorized, "missing or invalid principal")
+
+// ErrAuthorizationForbidden is returned when principal does not have required permissions.
+var ErrAuthorizationForbidden = echo.NewHTTPError(http.StatusForbidden, "insufficient permissions")
+
+// DefaultAuthorizationConfig is the default Authorization middleware config.
+var DefaultAuthorizationConfig = AuthorizationConfig{
+	Skipper:            DefaultSkipper,
+	ContextKey:         "user",
+	PrincipalExtractor: DefaultAuthorizationPrincipalExtractor,
+}
+
+// RequirePermissions returns an Authorization middleware that requires all given permissions. Principal is read
+// from context key "user" which is the default key used by JWT middleware.
+//
+// Example: `admin := e.Group("/admin", middleware.RequirePermissions("admin"))`
+func RequirePermissions(permissions ...string) echo.MiddlewareFunc {
+	c := DefaultAuthorizationConfig
+	c.Permissions = permissions
+	return AuthorizationWithConfig(c)
+}
+
+// AuthorizationWithConfig returns an Authorization middleware with config.
+//
+// For requests without authenticated principal it returns "401 - Unauthorized" error.
+// For principals without required permissions it returns "403 - Forbidden" error.
+func AuthorizationWithConfig(config AuthorizationConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultAuthorizationConfig.Skipper
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultAuthorizationConfig.ContextKey
+	}
+	if config.PrincipalExtractor == nil {
+		config.PrincipalExtractor = DefaultAuthorizationConfig.PrincipalExtractor
+	}
+	rules := config.compileRules()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			if err := config.authorize(c, rules); err != nil {
+				if config.ErrorHandler != nil {
+					return config.ErrorHandler(err, c)
+				}
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+func (config *AuthorizationConfig) authorize(c echo.Context, rules []authorizationRule) error {
+	value := c.Get(config.ContextKey)
+	if value == nil {
+		return ErrAuthorizationPrincipalMissing
+	}
+	principal, err := config.PrincipalExtractor(value, c)
+	if err != nil {
+		return ErrAuthorizationPrincipalMissing.WithInternal(err)
+	}
+	if principal == nil {
+		return ErrAuthorizationPrincipalMissing
+	}
+
+	required := requiredPermissions(config.Permissions, rules, c.Request().Method, c.Path())
+	required = withRouteScopes(required, echo.RouteMetaOf(c))
+	if !hasPermissions(principal, config.Roles, required) {
+		return ErrAuthorizationForbidden
+	}
+	for _, policy := range config.Policies {
+		allowed, err := policy(c, principal, required)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ErrAuthorizationForbidden
+		}
+	}
+	return nil
+}
+
+// RoutePermissions returns permissions required by each of given routes of the Echo instance according to this config
+// and route metadata. Routes are usually obtained with `Echo.Routes()`. Only routes that require at least one
+// permission are returned. Scopes of routes of mounted Echo instances are read from the mounted instance.
+func (config AuthorizationConfig) RoutePermissions(e *echo.Echo, routes []*echo.Route) []RoutePermissions {
+	rules := config.compileRules()
+
+	result := make([]RoutePermissions, 0, len(routes))
+	for _, r := range routes {
+		if r.Method == echo.RouteNotFound {
+			continue
+		}
+		required := requiredPermissions(config.Permissions, rules, r.Method, r.Path)
+		required = withRouteScopes(required, e.Meta(r))
+		if len(required) == 0 {
+			continue
+		}
+		result = append(result, RoutePermissions{
+			Method:      r.Method,
+			Path:        r.Path,
+			Name:        r.Name,
+			Permissions: required,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Path == result[j].Path {
+			return result[i].Method < result[j].Method
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+func (config *AuthorizationConfig) compileRules() []authorizationRule {
+	rules := make([]authorizationRule, 0, len(config.Rules))
+	for k, permissions := range config.Rules {
+		rule := authorizationRule{path: strings.TrimSpace(k), permissions: permissions}
+		if i := strings.IndexByte(rule.path, ' '); i != -1 {
+			rule.method = strings.ToUpper(rule.path[:i])
+			rule.path = strings.TrimSpace(rule.path[i+1:])
+		}
+		if strings.HasSuffix(rule.path, "*") {
+			rule.isPrefix = true
+			rule.path = strings.TrimSuffix(rule.path, "*")
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (r authorizationRule) matches(method, path string) bool {
+	if r.method != "" && r.method != method {
+		return false
+	}
+	if r.isPrefix {
+		return strings.HasPrefix(path, r.path)
+	}
+	return r.path == path
+}
+
+func requiredPermissions(base []string, rules []authorizationRule, method, path string) []string {
+	required := append([]string(nil), base...)
+	for _, rule := range rules {
+		if !rule.matches(method, path) {
+			continue
+		}
+		for _, p := range rule.permissions {
+			if !containsString(required, p) {
+				required = append(required, p)
+			}
+		}
+	}
+	return required
+}
+
+// withRouteScopes adds scopes from route metadata (`RouteMeta.RequireScopes()`) to required permissions.
+func withRouteScopes(required []string, meta *echo.RouteMeta) []string {
+	if meta == nil {
+		return required
+	}
+	for _, p := range meta.Scopes {
+		if !containsString(required, p) {
+			required = append(required, p)
+		}
+	}
+	return required
+}
+
+func hasPermissions(principal *AuthorizationPrincipal, roles map[string][]string, required []string) bool {
+	if len(required) == 0 {
+		return true
+	}
+	granted := make(map[string]struct{}, len(principal.Permissions))
+	for _, p := range principal.Permissions {
+		granted[p] = struct{}{}
+	}
+	for _, role := range principal.Roles {
+		for _, p := range roles[role] {
+			granted[p] = struct{}{}
+		}
+	}
+	if _, ok := granted["*"]; ok {
+		return true
+	}
+	for _, p := range required {
+		if _, ok := granted[p]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultAuthorizationPrincipalExtractor converts values set by JWT and KeyAuth middlewares into principal.
+func DefaultAuthorizationPrincipalExtractor(value interface{}, c echo.Context) (*AuthorizationPrincipal, error) {
+	switch v := value.(type) {
+	case *AuthorizationPrincipal:
+		return v, nil
+	case AuthorizationPrincipal:
+		return &v, nil
+	case *jwt.Token:
+		if !v.Valid {
+			return nil, nil
+		}
+		claims, ok := v.Claims.(jwt.MapClaims)
+		if !ok {
+			return &AuthorizationPrincipal{Value: v}, nil
+		}
+		p := &AuthorizationPrincipal{
+			Roles:       claimStrings(claims["roles"]),
+			Permissions: claimStrings(claims["permissions"]),
+			Value:       v,
+		}
+		if sub, ok := claims["sub"].(string); ok {
+			p.ID = sub
+		}
+		if scope, ok := claims["scope"].(string); ok {
+			p.Permissions = append(p.Permissions, strings.Fields(scope)...)
+		}
+		return p, nil
+	case string:
+		return &AuthorizationPrincipal{ID: v, Value: v}, nil
+	default:
+		return &AuthorizationPrincipal{Value: v}, nil
+	}
+}
+
+func claimStrings(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return strings.Fields(v)
+	case []string:
+		return v
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, s := range v {
+			if str, ok := s.(string); ok {
+				result = append(result, str)
+			}
+		}
+		return result
+	}
+	return nil
+}
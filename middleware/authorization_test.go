@@ -0,0 +1,281 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	echo "github.com/jialequ/agent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizationWithConfig(t *testing.T) {
+	var testCases = []struct {
+		name          string
+		givenUser     interface{}
+		whenConfig    AuthorizationConfig
+		whenMethod    string
+		whenPath      string
+		expectError   string
+		expectHandled bool
+	}{
+		{
+			name:        "nok, missing principal",
+			whenConfig:  AuthorizationConfig{Permissions: []string{"users:read"}},
+			expectError: "code=401, message=missing or invalid principal",
+		},
+		{
+			name:          "ok, principal has direct permission",
+			givenUser:     &AuthorizationPrincipal{ID: "1", Permissions: []string{"users:read"}},
+			whenConfig:    AuthorizationConfig{Permissions: []string{"users:read"}},
+			expectHandled: true,
+		},
+		{
+			name:        "nok, principal does not have permission",
+			givenUser:   &AuthorizationPrincipal{ID: "1", Permissions: []string{"users:write"}},
+			whenConfig:  AuthorizationConfig{Permissions: []string{"users:read"}},
+			expectError: "code=403, message=insufficient permissions",
+		},
+		{
+			name:      "ok, permission granted by role",
+			givenUser: &AuthorizationPrincipal{ID: "1", Roles: []string{"admin"}},
+			whenConfig: AuthorizationConfig{
+				Permissions: []string{"users:read"},
+				Roles:       map[string][]string{"admin": {"*"}},
+			},
+			expectHandled: true,
+		},
+		{
+			name:      "ok, rule for route matches with method",
+			givenUser: &AuthorizationPrincipal{ID: "1", Permissions: []string{"users:read"}},
+			whenConfig: AuthorizationConfig{
+				Rules: map[string][]string{
+					"GET /users/:id":    {"users:read"},
+					"DELETE /users/:id": {"users:delete"},
+				},
+			},
+			whenMethod:    http.MethodGet,
+			whenPath:      "/users/:id",
+			expectHandled: true,
+		},
+		{
+			name:      "nok, rule for route requires missing permission",
+			givenUser: &AuthorizationPrincipal{ID: "1", Permissions: []string{"users:read"}},
+			whenConfig: AuthorizationConfig{
+				Rules: map[string][]string{
+					"GET /users/:id":    {"users:read"},
+					"DELETE /users/:id": {"users:delete"},
+				},
+			},
+			whenMethod:  http.MethodDelete,
+			whenPath:    "/users/:id",
+			expectError: "code=403, message=insufficient permissions",
+		},
+		{
+			name:      "nok, prefix rule",
+			givenUser: &AuthorizationPrincipal{ID: "1"},
+			whenConfig: AuthorizationConfig{
+				Rules: map[string][]string{"/admin/*": {"admin"}},
+			},
+			whenPath:    "/admin/users",
+			expectError: "code=403, message=insufficient permissions",
+		},
+		{
+			name: "ok, jwt token claims",
+			givenUser: &jwt.Token{
+				Valid:  true,
+				Claims: jwt.MapClaims{"sub": "1", "roles": []interface{}{"reader"}, "scope": "users:write"},
+			},
+			whenConfig: AuthorizationConfig{
+				Permissions: []string{"users:read", "users:write"},
+				Roles:       map[string][]string{"reader": {"users:read"}},
+			},
+			expectHandled: true,
+		},
+		{
+			name:      "nok, policy denies",
+			givenUser: "api-key",
+			whenConfig: AuthorizationConfig{
+				Policies: []AuthorizationPolicy{
+					func(c echo.Context, p *AuthorizationPrincipal, required []string) (bool, error) {
+						return p.ID == "other-key", nil
+					},
+				},
+			},
+			expectError: "code=403, message=insufficient permissions",
+		},
+		{
+			name:      "nok, policy error is returned",
+			givenUser: "api-key",
+			whenConfig: AuthorizationConfig{
+				Policies: []AuthorizationPolicy{
+					func(c echo.Context, p *AuthorizationPrincipal, required []string) (bool, error) {
+						return false, errors.New("policy backend unavailable")
+					},
+				},
+			},
+			expectError: "policy backend unavailable",
+		},
+		{
+			name:      "nok, custom error handler",
+			givenUser: &AuthorizationPrincipal{ID: "1"},
+			whenConfig: AuthorizationConfig{
+				Permissions: []string{"users:read"},
+				ErrorHandler: func(err error, c echo.Context) error {
+					return echo.NewHTTPError(http.StatusNotFound)
+				},
+			},
+			expectError: "code=404, message=Not Found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			method := tc.whenMethod
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath(tc.whenPath)
+			if tc.givenUser != nil {
+				c.Set("user", tc.givenUser)
+			}
+
+			handled := false
+			h := AuthorizationWithConfig(tc.whenConfig)(func(c echo.Context) error {
+				handled = true
+				return nil
+			})
+
+			err := h(c)
+			assert.Equal(t, tc.expectHandled, handled)
+			if tc.expectError != "" {
+				assert.EqualError(t, err, tc.expectError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRequirePermissions(t *testing.T) {
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user", &AuthorizationPrincipal{ID: "1", Permissions: []string{"admin"}})
+			return next(c)
+		}
+	})
+	admin := e.Group("/admin", RequirePermissions("admin"))
+	admin.GET("/users", func(c echo.Context) error { return c.String(http.StatusOK, "ok") })
+	e.GET("/root", func(c echo.Context) error { return c.String(http.StatusOK, "ok") }, RequirePermissions("root"))
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/root", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestAuthorizationRouteScopes(t *testing.T) {
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user", &AuthorizationPrincipal{ID: "1", Permissions: []string{"reports:read"}})
+			return next(c)
+		}
+	})
+	e.Use(AuthorizationWithConfig(AuthorizationConfig{}))
+	h := func(c echo.Context) error { return c.String(http.StatusOK, "ok") }
+	e.Meta(e.GET("/reports", h)).RequireScopes("reports:read")
+	e.Meta(e.DELETE("/reports", h)).RequireScopes("reports:delete")
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/reports", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestAuthorizationGroupScopes(t *testing.T) {
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user", &AuthorizationPrincipal{ID: "1", Permissions: []string{"admin"}})
+			return next(c)
+		}
+	})
+	config := AuthorizationConfig{}
+	e.Use(AuthorizationWithConfig(config))
+	h := func(c echo.Context) error { return c.String(http.StatusOK, "ok") }
+	admin := e.Group("/admin").RequireScopes("admin")
+	admin.GET("/stats", h)
+	admin.Group("/audit").RequireScopes("audit").GET("/log", h)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/stats", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/audit/log", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	assert.Equal(t, []RoutePermissions{
+		{Method: http.MethodGet, Path: "/admin/audit/log", Name: "github.com/jialequ/agent/middleware.TestAuthorizationGroupScopes.func2", Permissions: []string{"admin", "audit"}},
+		{Method: http.MethodGet, Path: "/admin/stats", Name: "github.com/jialequ/agent/middleware.TestAuthorizationGroupScopes.func2", Permissions: []string{"admin"}},
+	}, config.RoutePermissions(e, e.Routes()))
+}
+
+func TestAuthorizationConfigRoutePermissions(t *testing.T) {
+	e := echo.New()
+	h := func(c echo.Context) error { return nil }
+	e.GET("/users/:id", h)
+	e.DELETE("/users/:id", h)
+	e.GET("/admin/stats", h)
+	e.GET("/public", h)
+	e.Meta(e.GET("/reports", h)).RequireScopes("reports:read")
+
+	config := AuthorizationConfig{
+		Rules: map[string][]string{
+			"GET /users/:id":    {"users:read"},
+			"DELETE /users/:id": {"users:delete"},
+			"/admin/*":          {"admin"},
+		},
+	}
+
+	assert.Equal(t, []RoutePermissions{
+		{Method: http.MethodGet, Path: "/admin/stats", Name: "github.com/jialequ/agent/middleware.TestAuthorizationConfigRoutePermissions.func1", Permissions: []string{"admin"}},
+		{Method: http.MethodGet, Path: "/reports", Name: "github.com/jialequ/agent/middleware.TestAuthorizationConfigRoutePermissions.func1", Permissions: []string{"reports:read"}},
+		{Method: http.MethodDelete, Path: "/users/:id", Name: "github.com/jialequ/agent/middleware.TestAuthorizationConfigRoutePermissions.func1", Permissions: []string{"users:delete"}},
+		{Method: http.MethodGet, Path: "/users/:id", Name: "github.com/jialequ/agent/middleware.TestAuthorizationConfigRoutePermissions.func1", Permissions: []string{"users:read"}},
+	}, config.RoutePermissions(e, e.Routes()))
+}
+
+func TestAuthorizationConfigRoutePermissionsMounted(t *testing.T) {
+	h := func(c echo.Context) error { return nil }
+	admin := echo.New()
+	admin.Meta(admin.GET("/stats", h)).RequireScopes("stats:read")
+	admin.GET("/health", h)
+
+	e := echo.New()
+	e.Mount("/admin", admin)
+
+	config := AuthorizationConfig{}
+	assert.Equal(t, []RoutePermissions{
+		{Method: http.MethodGet, Path: "/admin/stats", Name: "github.com/jialequ/agent/middleware.TestAuthorizationConfigRoutePermissionsMounted.func1", Permissions: []string{"stats:read"}},
+	}, config.RoutePermissions(e, e.Routes()))
+}
@@ -10,6 +10,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"time"
 
 	echo "github.com/jialequ/agent"
 )
@@ -22,6 +23,14 @@ type BodyDumpConfig struct {
 	// Handler receives request and response payload.
 	// Required.
 	Handler BodyDumpHandler
+
+	// Redactor redacts sensitive data from request and response payload before it is passed to Handler.
+	// Optional. Default value nil (nothing is redacted).
+	Redactor *Redactor
+
+	// Sampler decides for which requests Handler is called.
+	// Optional. Default value nil (Handler is called for all requests).
+	Sampler *LogSampler
 }
 
 // BodyDumpHandler receives the request and response payload.
@@ -77,12 +86,21 @@ func BodyDumpWithConfig(config BodyDumpConfig) echo.MiddlewareFunc {
 			writer := &bodyDumpResponseWriter{Writer: mw, ResponseWriter: c.Response().Writer}
 			c.Response().Writer = writer
 
+			start := time.Now()
 			if err = next(c); err != nil {
 				c.Error(err)
 			}
+			if config.Sampler != nil && !config.Sampler.Sample(c.Response().Status, err, time.Since(start)) {
+				return
+			}
 
 			// Callback
-			config.Handler(c, reqBody, resBody.Bytes())
+			resBytes := resBody.Bytes()
+			if config.Redactor != nil {
+				reqBody = config.Redactor.Body(reqBody)
+				resBytes = config.Redactor.Body(resBytes)
+			}
+			config.Handler(c, reqBody, resBytes)
 
 			return
 		}
@@ -42,6 +42,7 @@ var DefaultBodyLimitConfig = BodyLimitConfig{
 // header and actual content read, which makes it super secure.
 // Limit can be specified as `4x` or `4xB`, where x is one of the multiple from K, M,
 // G, T or P.
+// Route metadata (`RouteMeta.WithBodyLimit()`) overrides the limit for the matched route.
 func BodyLimit(limit string) echo.MiddlewareFunc {
 	c := DefaultBodyLimitConfig
 	c.Limit = limit
@@ -70,15 +71,20 @@ func BodyLimitWithConfig(config BodyLimitConfig) echo.MiddlewareFunc {
 			}
 
 			req := c.Request()
+			limit := config.limit
+			if meta := echo.RouteMetaOf(c); meta != nil && meta.BodyLimit > 0 {
+				limit = meta.BodyLimit
+			}
 
 			// Based on content length
-			if req.ContentLength > config.limit {
+			if req.ContentLength > limit {
 				return echo.ErrStatusRequestEntityTooLarge
 			}
 
 			// Based on content read
 			r := pool.Get().(*limitedReader)
 			r.Reset(req.Body)
+			r.limit = limit
 			defer pool.Put(r)
 			req.Body = r
 
@@ -173,3 +173,40 @@ func TestBodyLimitpanicOnInvalidLimit(t *testing.T) {
 }
 
 const literal_5709 = "Hello, World!"
+
+func TestBodyLimitRouteMeta(t *testing.T) {
+	e := echo.New()
+	e.Use(BodyLimit("4B"))
+	h := func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, string(body))
+	}
+	e.POST("/default", h)
+	e.Meta(e.POST("/upload", h)).WithBodyLimit(1024)
+
+	var testCases = []struct {
+		name          string
+		whenPath      string
+		whenUnknownCL bool
+		expectCode    int
+	}{
+		{name: "nok, default limit", whenPath: "/default", expectCode: http.StatusRequestEntityTooLarge},
+		{name: "nok, default limit by read", whenPath: "/default", whenUnknownCL: true, expectCode: http.StatusRequestEntityTooLarge},
+		{name: "ok, route limit", whenPath: "/upload", expectCode: http.StatusOK},
+		{name: "ok, route limit by read", whenPath: "/upload", whenUnknownCL: true, expectCode: http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.whenPath, bytes.NewReader([]byte("Hello, World!")))
+			if tc.whenUnknownCL {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectCode, rec.Code)
+		})
+	}
+}
@@ -20,6 +20,7 @@ type ContextTimeoutConfig struct {
 	ErrorHandler func(err error, c echo.Context) error
 
 	// Timeout configures a timeout for the middleware, defaults to 0 for no timeout
+	// Route metadata (`RouteMeta.WithTimeout()`) overrides the timeout for the matched route.
 	Timeout time.Duration
 }
 
@@ -61,7 +62,12 @@ func (config ContextTimeoutConfig) ToMiddleware() (echo.MiddlewareFunc, error) {
 				return next(c)
 			}
 
-			timeoutContext, cancel := context.WithTimeout(c.Request().Context(), config.Timeout)
+			timeout := config.Timeout
+			if meta := echo.RouteMetaOf(c); meta != nil && meta.Timeout > 0 {
+				timeout = meta.Timeout
+			}
+
+			timeoutContext, cancel := context.WithTimeout(c.Request().Context(), timeout)
 			defer cancel()
 
 			c.SetRequest(c.Request().WithContext(timeoutContext))
@@ -226,3 +226,29 @@ func sleepWithContext(ctx context.Context, d time.Duration) error {
 		return nil
 	}
 }
+
+func TestContextTimeoutRouteMeta(t *testing.T) {
+	t.Parallel()
+	e := echo.New()
+	e.Use(ContextTimeout(time.Hour))
+
+	var deadline time.Duration
+	h := func(c echo.Context) error {
+		d, ok := c.Request().Context().Deadline()
+		assert.True(t, ok)
+		deadline = time.Until(d)
+		return c.NoContent(http.StatusOK)
+	}
+	e.GET("/default", h)
+	e.Meta(e.GET("/short", h)).WithTimeout(time.Second)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/short", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.LessOrEqual(t, deadline, time.Second)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/default", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Greater(t, deadline, time.Second)
+}
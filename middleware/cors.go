@@ -199,7 +199,9 @@ func CORSWithConfig(config CORSConfig) echo.MiddlewareFunc { //NOSONAR
 
 			// No Origin provided. This is (probably) not request from actual browser - proceed executing middleware chain
 			if origin == "" {
-				if !preflight {
+				// When automatic OPTIONS replies are disabled, non-CORS OPTIONS request is left for the router to
+				// answer (405 Method Not Allowed or user registered OPTIONS handler).
+				if !preflight || c.Echo().DisableAutoOptions {
 					return next(c)
 				}
 				return c.NoContent(http.StatusNoContent)
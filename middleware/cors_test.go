@@ -253,7 +253,7 @@ func TestCORS(t *testing.T) { //NOSONAR
 	}
 }
 
-func TestallowOriginScheme(t *testing.T) {
+func TestAllowOriginScheme(t *testing.T) {
 	tests := []struct {
 		domain, pattern string
 		expected        bool
@@ -300,7 +300,7 @@ func TestallowOriginScheme(t *testing.T) {
 	}
 }
 
-func TestallowOriginSubdomain(t *testing.T) {
+func TestAllowOriginSubdomain(t *testing.T) {
 	tests := []struct {
 		domain, pattern string
 		expected        bool
@@ -530,7 +530,7 @@ func TestCorsHeaders(t *testing.T) {
 			method:            http.MethodOptions,
 			expected:          false,
 			expectStatus:      http.StatusNoContent,
-			expectAllowHeader: literal_9218,
+			expectAllowHeader: "OPTIONS, GET, POST, HEAD", // HEAD is served by GET handler
 		},
 		{
 			name:              "preflight, allow any origin, existing origin header = CORS logic done",
@@ -539,7 +539,7 @@ func TestCorsHeaders(t *testing.T) {
 			method:            http.MethodOptions,
 			expected:          true,
 			expectStatus:      http.StatusNoContent,
-			expectAllowHeader: literal_9218,
+			expectAllowHeader: "OPTIONS, GET, POST, HEAD", // HEAD is served by GET handler
 		},
 		{
 			name:              "preflight, allow any origin, missing origin header = no CORS logic done",
@@ -548,7 +548,7 @@ func TestCorsHeaders(t *testing.T) {
 			method:            http.MethodOptions,
 			expected:          false,
 			expectStatus:      http.StatusNoContent,
-			expectAllowHeader: literal_9218,
+			expectAllowHeader: "OPTIONS, GET, POST, HEAD", // HEAD is served by GET handler
 		},
 		{
 			name:              "preflight, allow specific origin, different origin header = no CORS logic done",
@@ -557,7 +557,7 @@ func TestCorsHeaders(t *testing.T) {
 			method:            http.MethodOptions,
 			expected:          false,
 			expectStatus:      http.StatusNoContent,
-			expectAllowHeader: literal_9218,
+			expectAllowHeader: "OPTIONS, GET, POST, HEAD", // HEAD is served by GET handler
 		},
 		{
 			name:              "preflight, allow specific origin, matching origin header = CORS logic done",
@@ -566,7 +566,7 @@ func TestCorsHeaders(t *testing.T) {
 			method:            http.MethodOptions,
 			expected:          true,
 			expectStatus:      http.StatusNoContent,
-			expectAllowHeader: literal_9218,
+			expectAllowHeader: "OPTIONS, GET, POST, HEAD", // HEAD is served by GET handler
 		},
 	}
 
@@ -626,6 +626,47 @@ func TestCorsHeaders(t *testing.T) {
 	}
 }
 
+func TestCORSDisableAutoOptions(t *testing.T) {
+	e := echo.New()
+	e.DisableAutoOptions = true
+	e.Use(CORS())
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+
+	// non-CORS OPTIONS request is answered by router
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD", rec.Header().Get(echo.HeaderAllow))
+
+	// preflight request is still answered by CORS middleware
+	req = httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set(echo.HeaderOrigin, "http://example.com")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "GET,HEAD,PUT,PATCH,POST,DELETE", rec.Header().Get(echo.HeaderAccessControlAllowMethods))
+}
+
+func TestCORSDisableAutoOptionsRouterMethods(t *testing.T) {
+	e := echo.New()
+	e.DisableAutoOptions = true
+	e.Use(CORSWithConfig(CORSConfig{}))
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+
+	// without AllowMethods preflight response uses methods allowed by router
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set(echo.HeaderOrigin, "http://example.com")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "GET, HEAD", rec.Header().Get(echo.HeaderAccessControlAllowMethods))
+}
+
 func TestAllowOriginFunc(t *testing.T) {
 	returnTrue := func(origin string) (bool, error) {
 		return true, nil
@@ -687,5 +728,3 @@ const literal_4258 = "http://ccc.bbb.example.com"
 const literal_6197 = "OPTIONS, GET"
 
 const literal_5379 = "http://google.com"
-
-const literal_9218 = "OPTIONS, GET, POST"
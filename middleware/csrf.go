@@ -4,8 +4,16 @@
 package middleware
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
+	"encoding/base64"
+	"html/template"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	echo "github.com/jialequ/agent"
@@ -65,6 +73,64 @@ type CSRFConfig struct {
 
 	// ErrorHandler defines a function which is executed for returning custom errors.
 	ErrorHandler CSRFErrorHandler
+
+	// Mode defines how token is stored and verified.
+	// Optional. Default value CSRFModeDoubleSubmit.
+	Mode CSRFMode `yaml:"mode"`
+
+	// Secret is the key used to sign tokens with HMAC-SHA256.
+	// Required for CSRFModeSignedDoubleSubmit mode.
+	Secret []byte `yaml:"-"`
+
+	// SessionIDExtractor returns identifier of the session current request belongs to. In CSRFModeSignedDoubleSubmit
+	// mode token signature is bound to session identifier so token fixated by an attacker (i.e. cookie set from
+	// a sibling subdomain) is not valid for the victim session. In CSRFModeSynchronizerToken mode it is the key
+	// tokens are stored with.
+	// Required for CSRFModeSignedDoubleSubmit and CSRFModeSynchronizerToken modes.
+	SessionIDExtractor func(c echo.Context) string
+
+	// TokenStore stores tokens on server side.
+	// Required for CSRFModeSynchronizerToken mode.
+	TokenStore CSRFTokenStore
+
+	// TrustedOrigins is list of origins (`scheme://host[:port]`) that are allowed to send unsafe requests. Origin is
+	// read from `Origin` header or from `Referer` header when Origin is not sent. Wildcard subdomains are supported
+	// (i.e. `https://*.example.com`). Origin of the request itself is always trusted. Requests without both
+	// headers are not checked as they are not sent by browsers.
+	// Optional. Origin verification is disabled when list is empty.
+	TrustedOrigins []string `yaml:"trusted_origins"`
+
+	// FetchMetadata enables rejection of unsafe requests by `Sec-Fetch-Site` request header. `cross-site` requests
+	// are rejected, `same-site` requests are allowed only when their origin is in TrustedOrigins.
+	// Optional. Default value false.
+	FetchMetadata bool `yaml:"fetch_metadata"`
+
+	// MaskToken enables masking token stored in context with one-time pad so every response contains different
+	// token value. This mitigates BREACH attack when responses are compressed. Masked and unmasked tokens are both
+	// accepted from client.
+	// Optional. Default value false.
+	MaskToken bool `yaml:"mask_token"`
+}
+
+// CSRFMode defines how CSRF token is stored and verified.
+type CSRFMode uint8
+
+const (
+	// CSRFModeDoubleSubmit stores random token in cookie and compares it to token sent by client.
+	CSRFModeDoubleSubmit CSRFMode = iota
+	// CSRFModeSignedDoubleSubmit stores HMAC signed token in cookie. Tokens with invalid signature are replaced with
+	// new ones. Signature is bound to session identifier returned by CSRFConfig.SessionIDExtractor.
+	CSRFModeSignedDoubleSubmit
+	// CSRFModeSynchronizerToken stores token in CSRFConfig.TokenStore keyed by session identifier. No cookie is set.
+	CSRFModeSynchronizerToken
+)
+
+// CSRFTokenStore is the interface to be implemented by server side token stores.
+type CSRFTokenStore interface {
+	// Get returns token stored for session. Empty string is returned when there is no token.
+	Get(sessionID string) (string, error)
+	// Set stores token for session.
+	Set(sessionID string, token string) error
 }
 
 // CSRFErrorHandler is a function which is executed for creating custom errors.
@@ -73,6 +139,24 @@ type CSRFErrorHandler func(err error, c echo.Context) error
 // ErrCSRFInvalid is returned when CSRF check fails
 var ErrCSRFInvalid = echo.NewHTTPError(http.StatusForbidden, "invalid csrf token")
 
+// ErrCSRFInvalidOrigin is returned when request origin is not trusted
+var ErrCSRFInvalidOrigin = echo.NewHTTPError(http.StatusForbidden, "invalid csrf origin")
+
+// ErrCSRFCrossSite is returned when Fetch Metadata headers indicate a cross-site request
+var ErrCSRFCrossSite = echo.NewHTTPError(http.StatusForbidden, "cross-site request rejected")
+
+// csrfContextKey is the context key for token information used by template helpers.
+const csrfContextKey = "echo_csrf_token"
+
+// DefaultCSRFFormField is name of the form field used by CSRFField when TokenLookup has no form source.
+const DefaultCSRFFormField = "_csrf"
+
+type csrfRequestToken struct {
+	token     string
+	mask      bool
+	formField string
+}
+
 // DefaultCSRFConfig is the default CSRF middleware config.
 var DefaultCSRFConfig = CSRFConfig{
 	Skipper:        DefaultSkipper,
@@ -118,10 +202,28 @@ func CSRFWithConfig(config CSRFConfig) echo.MiddlewareFunc { //NOSONAR
 		config.CookieSecure = true
 	}
 
+	switch config.Mode {
+	case CSRFModeSignedDoubleSubmit:
+		if len(config.Secret) == 0 || config.SessionIDExtractor == nil {
+			panic("echo: csrf middleware requires secret and session id extractor for signed double submit mode")
+		}
+	case CSRFModeSynchronizerToken:
+		if config.TokenStore == nil || config.SessionIDExtractor == nil {
+			panic("echo: csrf middleware requires token store and session id extractor for synchronizer token mode")
+		}
+	}
+
 	extractors, cErr := CreateExtractors(config.TokenLookup)
 	if cErr != nil {
 		panic(cErr)
 	}
+	formField := DefaultCSRFFormField
+	for _, source := range strings.Split(config.TokenLookup, ",") {
+		if parts := strings.Split(strings.TrimSpace(source), ":"); len(parts) > 1 && parts[0] == "form" {
+			formField = parts[1]
+			break
+		}
+	}
 
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -129,51 +231,22 @@ func CSRFWithConfig(config CSRFConfig) echo.MiddlewareFunc { //NOSONAR
 				return next(c)
 			}
 
-			token := ""
-			if k, err := c.Cookie(config.CookieName); err != nil {
-				token = randomString(config.TokenLength)
-			} else {
-				token = k.Value // Reuse token
+			sessionID := ""
+			if config.SessionIDExtractor != nil {
+				sessionID = config.SessionIDExtractor(c)
+			}
+			token, isNew, err := config.loadToken(c, sessionID)
+			if err != nil {
+				return err
 			}
 
 			switch c.Request().Method {
 			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
 			default:
 				// Validate token only for requests which are not defined as 'safe' by RFC7231
-				var lastExtractorErr error
-				var lastTokenErr error
-			outer:
-				for _, extractor := range extractors {
-					clientTokens, err := extractor(c)
-					if err != nil {
-						lastExtractorErr = err
-						continue
-					}
-
-					for _, clientToken := range clientTokens {
-						if validateCSRFToken(token, clientToken) {
-							lastTokenErr = nil
-							lastExtractorErr = nil
-							break outer
-						}
-						lastTokenErr = ErrCSRFInvalid
-					}
-				}
-				var finalErr error
-				if lastTokenErr != nil {
-					finalErr = lastTokenErr
-				} else if lastExtractorErr != nil {
-					// ugly part to preserve backwards compatible errors. someone could rely on them
-					if lastExtractorErr == errQueryExtractorValueMissing {
-						lastExtractorErr = echo.NewHTTPError(http.StatusBadRequest, "missing csrf token in the query string")
-					} else if lastExtractorErr == errFormExtractorValueMissing {
-						lastExtractorErr = echo.NewHTTPError(http.StatusBadRequest, "missing csrf token in the form parameter")
-					} else if lastExtractorErr == errHeaderExtractorValueMissing {
-						lastExtractorErr = echo.NewHTTPError(http.StatusBadRequest, "missing csrf token in request header")
-					} else {
-						lastExtractorErr = echo.NewHTTPError(http.StatusBadRequest, lastExtractorErr.Error())
-					}
-					finalErr = lastExtractorErr
+				finalErr := config.checkOrigin(c)
+				if finalErr == nil {
+					finalErr = validateCSRFRequestToken(c, extractors, token)
 				}
 
 				if finalErr != nil {
@@ -184,26 +257,17 @@ func CSRFWithConfig(config CSRFConfig) echo.MiddlewareFunc { //NOSONAR
 				}
 			}
 
-			// Set CSRF cookie
-			cookie := new(http.Cookie)
-			cookie.Name = config.CookieName
-			cookie.Value = token
-			if config.CookiePath != "" {
-				cookie.Path = config.CookiePath
-			}
-			if config.CookieDomain != "" {
-				cookie.Domain = config.CookieDomain
+			if err := config.storeToken(c, sessionID, token, isNew); err != nil {
+				return err
 			}
-			if config.CookieSameSite != http.SameSiteDefaultMode {
-				cookie.SameSite = config.CookieSameSite
-			}
-			cookie.Expires = time.Now().Add(time.Duration(config.CookieMaxAge) * time.Second)
-			cookie.Secure = config.CookieSecure
-			cookie.HttpOnly = config.CookieHTTPOnly
-			c.SetCookie(cookie)
 
 			// Store token in the context
-			c.Set(config.ContextKey, token)
+			c.Set(csrfContextKey, &csrfRequestToken{token: token, mask: config.MaskToken, formField: formField})
+			if config.MaskToken {
+				c.Set(config.ContextKey, maskCSRFToken(token))
+			} else {
+				c.Set(config.ContextKey, token)
+			}
 
 			// Protect clients from caching the response
 			c.Response().Header().Add(echo.HeaderVary, echo.HeaderCookie)
@@ -213,6 +277,324 @@ func CSRFWithConfig(config CSRFConfig) echo.MiddlewareFunc { //NOSONAR
 	}
 }
 
+func validateCSRFRequestToken(c echo.Context, extractors []ValuesExtractor, token string) error {
+	var lastExtractorErr error
+	var lastTokenErr error
+outer:
+	for _, extractor := range extractors {
+		clientTokens, err := extractor(c)
+		if err != nil {
+			lastExtractorErr = err
+			continue
+		}
+
+		for _, clientToken := range clientTokens {
+			if validateCSRFToken(token, clientToken) || validateCSRFToken(token, unmaskCSRFToken(clientToken)) {
+				lastTokenErr = nil
+				lastExtractorErr = nil
+				break outer
+			}
+			lastTokenErr = ErrCSRFInvalid
+		}
+	}
+	if lastTokenErr != nil {
+		return lastTokenErr
+	}
+	if lastExtractorErr != nil {
+		// ugly part to preserve backwards compatible errors. someone could rely on them
+		if lastExtractorErr == errQueryExtractorValueMissing {
+			return echo.NewHTTPError(http.StatusBadRequest, "missing csrf token in the query string")
+		} else if lastExtractorErr == errFormExtractorValueMissing {
+			return echo.NewHTTPError(http.StatusBadRequest, "missing csrf token in the form parameter")
+		} else if lastExtractorErr == errHeaderExtractorValueMissing {
+			return echo.NewHTTPError(http.StatusBadRequest, "missing csrf token in request header")
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, lastExtractorErr.Error())
+	}
+	return nil
+}
+
+// loadToken returns token for current request. isNew is true when there was no existing (valid) token and a new
+// one was generated.
+func (config *CSRFConfig) loadToken(c echo.Context, sessionID string) (token string, isNew bool, err error) {
+	switch config.Mode {
+	case CSRFModeSynchronizerToken:
+		if sessionID != "" {
+			if token, err = config.TokenStore.Get(sessionID); err != nil {
+				return "", false, err
+			}
+		}
+	case CSRFModeSignedDoubleSubmit:
+		if k, err := c.Cookie(config.CookieName); err == nil && config.verifyTokenSignature(k.Value, sessionID) {
+			token = k.Value
+		}
+	default:
+		if k, err := c.Cookie(config.CookieName); err == nil {
+			token = k.Value // Reuse token
+		}
+	}
+	if token != "" {
+		return token, false, nil
+	}
+
+	token = randomString(config.TokenLength)
+	if config.Mode == CSRFModeSignedDoubleSubmit {
+		token = token + "." + config.tokenSignature(token, sessionID)
+	}
+	return token, true, nil
+}
+
+func (config *CSRFConfig) storeToken(c echo.Context, sessionID string, token string, isNew bool) error {
+	if config.Mode == CSRFModeSynchronizerToken {
+		if !isNew || sessionID == "" {
+			return nil
+		}
+		return config.TokenStore.Set(sessionID, token)
+	}
+
+	// Set CSRF cookie
+	cookie := new(http.Cookie)
+	cookie.Name = config.CookieName
+	cookie.Value = token
+	if config.CookiePath != "" {
+		cookie.Path = config.CookiePath
+	}
+	if config.CookieDomain != "" {
+		cookie.Domain = config.CookieDomain
+	}
+	if config.CookieSameSite != http.SameSiteDefaultMode {
+		cookie.SameSite = config.CookieSameSite
+	}
+	cookie.Expires = time.Now().Add(time.Duration(config.CookieMaxAge) * time.Second)
+	cookie.Secure = config.CookieSecure
+	cookie.HttpOnly = config.CookieHTTPOnly
+	c.SetCookie(cookie)
+	return nil
+}
+
+func (config *CSRFConfig) tokenSignature(value string, sessionID string) string {
+	mac := hmac.New(sha256.New, config.Secret)
+	mac.Write([]byte(value))
+	mac.Write([]byte{0})
+	mac.Write([]byte(sessionID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (config *CSRFConfig) verifyTokenSignature(token string, sessionID string) bool {
+	i := strings.LastIndexByte(token, '.')
+	if i <= 0 {
+		return false
+	}
+	expected := config.tokenSignature(token[:i], sessionID)
+	return hmac.Equal([]byte(token[i+1:]), []byte(expected))
+}
+
+// checkOrigin verifies Fetch Metadata and Origin/Referer headers of the request.
+func (config *CSRFConfig) checkOrigin(c echo.Context) error {
+	if !config.FetchMetadata && len(config.TrustedOrigins) == 0 {
+		return nil
+	}
+	req := c.Request()
+	origin := req.Header.Get(echo.HeaderOrigin)
+	if origin == "" {
+		if referer := req.Header.Get(echo.HeaderReferer); referer != "" {
+			if u, err := url.Parse(referer); err == nil && u.Host != "" {
+				origin = u.Scheme + "://" + u.Host
+			}
+		}
+	}
+
+	if config.FetchMetadata {
+		switch req.Header.Get(echo.HeaderSecFetchSite) {
+		case "cross-site":
+			return ErrCSRFCrossSite
+		case "same-site":
+			if !config.isTrustedOrigin(origin) {
+				return ErrCSRFCrossSite
+			}
+		}
+	}
+
+	if len(config.TrustedOrigins) == 0 || origin == "" {
+		return nil
+	}
+	if strings.EqualFold(origin, c.Scheme()+"://"+req.Host) || config.isTrustedOrigin(origin) {
+		return nil
+	}
+	return ErrCSRFInvalidOrigin
+}
+
+func (config *CSRFConfig) isTrustedOrigin(origin string) bool {
+	if origin == "" || origin == "null" {
+		return false
+	}
+	for _, trusted := range config.TrustedOrigins {
+		if strings.EqualFold(origin, trusted) {
+			return true
+		}
+		if strings.Contains(trusted, "://*.") && matchSubdomain(origin, trusted) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskCSRFToken masks token with random one-time pad. Result is base64 encoded `pad + (pad XOR token)`.
+func maskCSRFToken(token string) string {
+	pad := make([]byte, len(token))
+	if _, err := rand.Read(pad); err != nil {
+		panic("unexpected error happened when reading from crypto/rand.Reader")
+	}
+	masked := make([]byte, 2*len(token))
+	copy(masked, pad)
+	for i := 0; i < len(token); i++ {
+		masked[len(token)+i] = pad[i] ^ token[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+// unmaskCSRFToken reverses maskCSRFToken. Empty string is returned for values that are not masked tokens.
+func unmaskCSRFToken(masked string) string {
+	b, err := base64.RawURLEncoding.DecodeString(masked)
+	if err != nil || len(b) == 0 || len(b)%2 != 0 {
+		return ""
+	}
+	l := len(b) / 2
+	token := make([]byte, l)
+	for i := 0; i < l; i++ {
+		token[i] = b[i] ^ b[l+i]
+	}
+	return string(token)
+}
+
+// CSRFToken returns CSRF token for the current request to be embedded into forms or pages. When masking is enabled
+// (CSRFConfig.MaskToken) every call returns differently masked token. Empty string is returned when CSRF middleware
+// was not executed for the request.
+func CSRFToken(c echo.Context) string {
+	rt, ok := c.Get(csrfContextKey).(*csrfRequestToken)
+	if !ok {
+		return ""
+	}
+	if rt.mask {
+		return maskCSRFToken(rt.token)
+	}
+	return rt.token
+}
+
+// CSRFField returns hidden form input element containing CSRF token for the current request. Name of the field is
+// taken from the first `form:<name>` source of CSRFConfig.TokenLookup or DefaultCSRFFormField when there is none.
+func CSRFField(c echo.Context) template.HTML {
+	rt, ok := c.Get(csrfContextKey).(*csrfRequestToken)
+	if !ok {
+		return ""
+	}
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(rt.formField) +
+		`" value="` + template.HTMLEscapeString(CSRFToken(c)) + `">`)
+}
+
+// CSRFTemplateFuncs returns template functions `csrfToken` and `csrfField` bound to the current request. It is meant
+// to be used by `echo.Renderer` implementations to inject CSRF token into rendered output.
+//
+// Example:
+//
+//	func (t *Template) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
+//		tmpl, err := t.templates.Clone()
+//		if err != nil {
+//			return err
+//		}
+//		return tmpl.Funcs(middleware.CSRFTemplateFuncs(c)).ExecuteTemplate(w, name, data)
+//	}
+func CSRFTemplateFuncs(c echo.Context) template.FuncMap {
+	return template.FuncMap{
+		"csrfToken": func() string { return CSRFToken(c) },
+		"csrfField": func() template.HTML { return CSRFField(c) },
+	}
+}
+
+// CSRFMemoryTokenStore is the built-in in-memory store for CSRFModeSynchronizerToken mode. Tokens that have not been
+// used for ExpiresIn are removed. Zero value is ready to use.
+type CSRFMemoryTokenStore struct {
+	// ExpiresIn is duration after which unused token is removed.
+	// Optional. Default value 24 hours.
+	ExpiresIn time.Duration
+
+	mutex       sync.Mutex
+	tokens      map[string]*csrfStoredToken
+	lastCleanup time.Time
+	timeNow     func() time.Time
+}
+
+type csrfStoredToken struct {
+	token    string
+	lastSeen time.Time
+}
+
+// NewCSRFMemoryTokenStore returns an instance of CSRFMemoryTokenStore. Default value for expiresIn is 24 hours.
+func NewCSRFMemoryTokenStore(expiresIn time.Duration) *CSRFMemoryTokenStore {
+	if expiresIn <= 0 {
+		expiresIn = 24 * time.Hour
+	}
+	return &CSRFMemoryTokenStore{
+		ExpiresIn: expiresIn,
+		tokens:    map[string]*csrfStoredToken{},
+		timeNow:   time.Now,
+	}
+}
+
+func (store *CSRFMemoryTokenStore) now() time.Time {
+	if store.timeNow == nil {
+		return time.Now()
+	}
+	return store.timeNow()
+}
+
+func (store *CSRFMemoryTokenStore) expiresIn() time.Duration {
+	if store.ExpiresIn <= 0 {
+		return 24 * time.Hour
+	}
+	return store.ExpiresIn
+}
+
+// Get implements CSRFTokenStore.Get.
+func (store *CSRFMemoryTokenStore) Get(sessionID string) (string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := store.now()
+	t, ok := store.tokens[sessionID]
+	if !ok {
+		return "", nil
+	}
+	if now.Sub(t.lastSeen) > store.expiresIn() {
+		delete(store.tokens, sessionID)
+		return "", nil
+	}
+	t.lastSeen = now
+	return t.token, nil
+}
+
+// Set implements CSRFTokenStore.Set.
+func (store *CSRFMemoryTokenStore) Set(sessionID string, token string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := store.now()
+	expiresIn := store.expiresIn()
+	if store.tokens == nil {
+		store.tokens = map[string]*csrfStoredToken{}
+	}
+	store.tokens[sessionID] = &csrfStoredToken{token: token, lastSeen: now}
+	if now.Sub(store.lastCleanup) > expiresIn {
+		for id, t := range store.tokens {
+			if now.Sub(t.lastSeen) > expiresIn {
+				delete(store.tokens, id)
+			}
+		}
+		store.lastCleanup = now
+	}
+	return nil
+}
+
 func validateCSRFToken(token, clientToken string) bool {
 	return subtle.ConstantTimeCompare([]byte(token), []byte(clientToken)) == 1
 }
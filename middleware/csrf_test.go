@@ -4,11 +4,13 @@
 package middleware
 
 import (
+	"html/template"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	echo "github.com/jialequ/agent"
 	"github.com/stretchr/testify/assert"
@@ -383,6 +385,284 @@ func TestCSRFErrorHandling(t *testing.T) {
 	assert.Equal(t, "{\"message\":\"error_handler_executed\"}\n", res.Body.String())
 }
 
+func TestCSRFSignedDoubleSubmit(t *testing.T) {
+	config := CSRFConfig{
+		Mode:               CSRFModeSignedDoubleSubmit,
+		Secret:             []byte("secret"),
+		SessionIDExtractor: func(c echo.Context) string { return c.Request().Header.Get("X-Session") },
+	}
+	e := echo.New()
+	e.Use(CSRFWithConfig(config))
+	e.Any("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Get("csrf").(string))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Session", "session1")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	token := rec.Body.String()
+	assert.Contains(t, token, ".")
+	assert.Contains(t, rec.Header().Get(echo.HeaderSetCookie), "_csrf="+token)
+
+	var testCases = []struct {
+		name         string
+		givenSession string
+		givenCookie  string
+		givenToken   string
+		expectCode   int
+	}{
+		{
+			name:         "ok, signed token for same session",
+			givenSession: "session1",
+			givenCookie:  token,
+			givenToken:   token,
+			expectCode:   http.StatusOK,
+		},
+		{
+			name:         "nok, token bound to other session",
+			givenSession: "session2",
+			givenCookie:  token,
+			givenToken:   token,
+			expectCode:   http.StatusForbidden,
+		},
+		{
+			name:         "nok, unsigned fixated token",
+			givenSession: "session1",
+			givenCookie:  "attacker",
+			givenToken:   "attacker",
+			expectCode:   http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set("X-Session", tc.givenSession)
+			req.Header.Set(echo.HeaderCookie, "_csrf="+tc.givenCookie)
+			req.Header.Set(echo.HeaderXCSRFToken, tc.givenToken)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectCode, rec.Code)
+		})
+	}
+}
+
+func TestCSRFSynchronizerToken(t *testing.T) {
+	store := NewCSRFMemoryTokenStore(time.Hour)
+	e := echo.New()
+	e.Use(CSRFWithConfig(CSRFConfig{
+		Mode:               CSRFModeSynchronizerToken,
+		TokenStore:         store,
+		SessionIDExtractor: func(c echo.Context) string { return "session1" },
+	}))
+	e.Any("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Get("csrf").(string))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(echo.HeaderSetCookie))
+	token := rec.Body.String()
+
+	stored, err := store.Get("session1")
+	assert.NoError(t, err)
+	assert.Equal(t, token, stored)
+
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(echo.HeaderXCSRFToken, token)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(echo.HeaderXCSRFToken, "invalid")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestCSRFMemoryTokenStoreExpiration(t *testing.T) {
+	now := time.Now()
+	store := NewCSRFMemoryTokenStore(time.Minute)
+	store.timeNow = func() time.Time { return now }
+
+	assert.NoError(t, store.Set("a", "token"))
+	token, err := store.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token)
+
+	now = now.Add(2 * time.Minute)
+	token, err = store.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "", token)
+}
+
+func TestCSRFMemoryTokenStoreZeroValue(t *testing.T) {
+	store := &CSRFMemoryTokenStore{}
+
+	token, err := store.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "", token)
+
+	assert.NoError(t, store.Set("a", "token"))
+	token, err = store.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token)
+}
+
+func TestCSRFModeConfigValidation(t *testing.T) {
+	assert.PanicsWithValue(t, "echo: csrf middleware requires secret and session id extractor for signed double submit mode", func() {
+		CSRFWithConfig(CSRFConfig{Mode: CSRFModeSignedDoubleSubmit, Secret: []byte("secret")})
+	})
+	assert.PanicsWithValue(t, "echo: csrf middleware requires token store and session id extractor for synchronizer token mode", func() {
+		CSRFWithConfig(CSRFConfig{Mode: CSRFModeSynchronizerToken, TokenStore: &CSRFMemoryTokenStore{}})
+	})
+}
+
+func TestCSRFOriginChecks(t *testing.T) {
+	var testCases = []struct {
+		name          string
+		whenConfig    CSRFConfig
+		givenHeaders  map[string]string
+		expectError   string
+		expectHandled bool
+	}{
+		{
+			name:          "ok, trusted origin",
+			whenConfig:    CSRFConfig{TrustedOrigins: []string{"https://app.example.com"}},
+			givenHeaders:  map[string]string{echo.HeaderOrigin: "https://app.example.com"},
+			expectHandled: true,
+		},
+		{
+			name:          "ok, trusted wildcard origin",
+			whenConfig:    CSRFConfig{TrustedOrigins: []string{"https://*.example.com"}},
+			givenHeaders:  map[string]string{echo.HeaderOrigin: "https://app.example.com"},
+			expectHandled: true,
+		},
+		{
+			name:          "ok, same origin as request",
+			whenConfig:    CSRFConfig{TrustedOrigins: []string{"https://app.example.com"}},
+			givenHeaders:  map[string]string{echo.HeaderOrigin: "http://example.com"},
+			expectHandled: true,
+		},
+		{
+			name:         "nok, untrusted origin",
+			whenConfig:   CSRFConfig{TrustedOrigins: []string{"https://app.example.com"}},
+			givenHeaders: map[string]string{echo.HeaderOrigin: "https://evil.com"},
+			expectError:  "code=403, message=invalid csrf origin",
+		},
+		{
+			name:         "nok, untrusted referer",
+			whenConfig:   CSRFConfig{TrustedOrigins: []string{"https://app.example.com"}},
+			givenHeaders: map[string]string{echo.HeaderReferer: "https://evil.com/form"},
+			expectError:  "code=403, message=invalid csrf origin",
+		},
+		{
+			name:         "nok, cross-site fetch metadata",
+			whenConfig:   CSRFConfig{FetchMetadata: true},
+			givenHeaders: map[string]string{echo.HeaderSecFetchSite: "cross-site"},
+			expectError:  "code=403, message=cross-site request rejected",
+		},
+		{
+			name:         "nok, same-site fetch metadata with untrusted origin",
+			whenConfig:   CSRFConfig{FetchMetadata: true},
+			givenHeaders: map[string]string{echo.HeaderSecFetchSite: "same-site", echo.HeaderOrigin: "https://other.example.com"},
+			expectError:  "code=403, message=cross-site request rejected",
+		},
+		{
+			name:          "ok, same-origin fetch metadata",
+			whenConfig:    CSRFConfig{FetchMetadata: true},
+			givenHeaders:  map[string]string{echo.HeaderSecFetchSite: "same-origin"},
+			expectHandled: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set(echo.HeaderCookie, "_csrf=token")
+			req.Header.Set(echo.HeaderXCSRFToken, "token")
+			for k, v := range tc.givenHeaders {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			handled := false
+			h := CSRFWithConfig(tc.whenConfig)(func(c echo.Context) error {
+				handled = true
+				return nil
+			})
+
+			err := h(c)
+			assert.Equal(t, tc.expectHandled, handled)
+			if tc.expectError != "" {
+				assert.EqualError(t, err, tc.expectError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCSRFMaskedToken(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderCookie, "_csrf=token")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := CSRFWithConfig(CSRFConfig{MaskToken: true, TokenLookup: "form:csrf"})(func(c echo.Context) error {
+		return nil
+	})
+	assert.NoError(t, h(c))
+
+	masked := c.Get("csrf").(string)
+	assert.NotEqual(t, "token", masked)
+	assert.Equal(t, "token", unmaskCSRFToken(masked))
+
+	first := CSRFToken(c)
+	second := CSRFToken(c)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, "token", unmaskCSRFToken(second))
+
+	field := string(CSRFField(c))
+	assert.True(t, strings.HasPrefix(field, `<input type="hidden" name="csrf" value="`))
+
+	// masked token is accepted
+	f := make(url.Values)
+	f.Set("csrf", first)
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(f.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.Header.Set(echo.HeaderCookie, "_csrf=token")
+	c = e.NewContext(req, httptest.NewRecorder())
+	assert.NoError(t, h(c))
+}
+
+func TestCSRFTemplateFuncs(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderCookie, "_csrf=token")
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	assert.Equal(t, "", CSRFToken(c))
+
+	h := CSRFWithConfig(CSRFConfig{})(func(c echo.Context) error {
+		return nil
+	})
+	assert.NoError(t, h(c))
+
+	tmpl := template.Must(template.New("form").Funcs(CSRFTemplateFuncs(c)).Parse(`<form>{{ csrfField }}</form>{{ csrfToken }}`))
+	buf := new(strings.Builder)
+	assert.NoError(t, tmpl.Execute(buf, nil))
+	assert.Equal(t, `<form><input type="hidden" name="_csrf" value="token"></form>token`, buf.String())
+}
+
 const literal_2931 = "form:csrf"
 
 const literal_5391 = "code=403, message=invalid csrf token"
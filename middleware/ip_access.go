@@ -0,0 +1,331 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	echo "github.com/jialequ/agent"
+)
+
+// IPAccessConfig defines the config for IPAccess middleware.
+type IPAccessConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// Allow is list of IP addresses and CIDR ranges (IPv4 and IPv6) that are allowed. When list is not empty all
+	// other addresses are denied.
+	// Optional. Not used when AccessList is set.
+	Allow []string
+
+	// Deny is list of IP addresses and CIDR ranges (IPv4 and IPv6) that are denied. Deny takes precedence over Allow.
+	// Optional. Not used when AccessList is set.
+	Deny []string
+
+	// AccessList is access list that can be updated while server is running (i.e. reloaded from file).
+	// Optional. Takes precedence over Allow and Deny.
+	AccessList *IPAccessList
+
+	// IPExtractor extracts client IP address from request.
+	// Optional. Default value uses `Context.RealIP()` which is configured with `Echo.IPExtractor`.
+	IPExtractor func(c echo.Context) string
+
+	// DenyHandler is called when client IP address is denied. Returned error is returned by middleware.
+	// Optional. Default value returns ErrIPAccessDenied.
+	DenyHandler func(c echo.Context, ip string) error
+}
+
+// ErrIPAccessDenied is returned when client IP address is not allowed to access the resource.
+var ErrIPAccessDenied = echo.NewHTTPError(http.StatusForbidden, "access denied")
+
+// DefaultIPAccessConfig is the default IPAccess middleware config.
+var DefaultIPAccessConfig = IPAccessConfig{
+	Skipper: DefaultSkipper,
+	IPExtractor: func(c echo.Context) string {
+		return c.RealIP()
+	},
+	DenyHandler: func(c echo.Context, ip string) error {
+		return ErrIPAccessDenied
+	},
+}
+
+// IPAllowList returns an IPAccess middleware that allows only given IP addresses and CIDR ranges. Panics when list
+// contains invalid entry.
+//
+// Example: `admin := e.Group("/admin", middleware.IPAllowList("10.0.0.0/8", "2001:db8::/32"))`
+func IPAllowList(allow ...string) echo.MiddlewareFunc {
+	c := DefaultIPAccessConfig
+	c.Allow = allow
+	return IPAccessWithConfig(c)
+}
+
+// IPDenyList returns an IPAccess middleware that denies given IP addresses and CIDR ranges. Panics when list
+// contains invalid entry.
+func IPDenyList(deny ...string) echo.MiddlewareFunc {
+	c := DefaultIPAccessConfig
+	c.Deny = deny
+	return IPAccessWithConfig(c)
+}
+
+// IPAccessWithConfig returns an IPAccess middleware with config.
+// See: `IPAllowList()`, `IPDenyList()`.
+func IPAccessWithConfig(config IPAccessConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultIPAccessConfig.Skipper
+	}
+	if config.IPExtractor == nil {
+		config.IPExtractor = DefaultIPAccessConfig.IPExtractor
+	}
+	if config.DenyHandler == nil {
+		config.DenyHandler = DefaultIPAccessConfig.DenyHandler
+	}
+	if config.AccessList == nil {
+		list, err := NewIPAccessList(config.Allow, config.Deny)
+		if err != nil {
+			panic(err)
+		}
+		config.AccessList = list
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			ip := config.IPExtractor(c)
+			if !config.AccessList.Allowed(net.ParseIP(ip)) {
+				return config.DenyHandler(c, ip)
+			}
+			return next(c)
+		}
+	}
+}
+
+// IPAccessList holds allow and deny lists of IP ranges. Lookups are done with radix tree so lists with thousands of
+// ranges are cheap to check. List is safe for concurrent use and can be replaced at runtime with `Update`,
+// `LoadFile` or `WatchFile`.
+type IPAccessList struct {
+	mutex sync.RWMutex
+	allow *ipRadixTree
+	deny  *ipRadixTree
+}
+
+// NewIPAccessList creates new IPAccessList from allow and deny lists. Entries are single IP addresses or CIDR ranges.
+func NewIPAccessList(allow []string, deny []string) (*IPAccessList, error) {
+	l := &IPAccessList{}
+	if err := l.Update(allow, deny); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Update atomically replaces allow and deny lists. Lists are left unchanged when any of the entries is invalid.
+func (l *IPAccessList) Update(allow []string, deny []string) error {
+	allowTree, err := newIPRadixTree(allow)
+	if err != nil {
+		return err
+	}
+	denyTree, err := newIPRadixTree(deny)
+	if err != nil {
+		return err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.allow = allowTree
+	l.deny = denyTree
+	return nil
+}
+
+// Allowed returns true when IP address is allowed by the lists. Denied ranges take precedence over allowed ones.
+// When allow list is empty all addresses not in deny list are allowed. Invalid (nil) IP is allowed only when both
+// lists are empty.
+func (l *IPAccessList) Allowed(ip net.IP) bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	if ip == nil {
+		return l.allow.size == 0 && l.deny.size == 0
+	}
+	if l.deny.contains(ip) {
+		return false
+	}
+	return l.allow.size == 0 || l.allow.contains(ip)
+}
+
+// LoadFile replaces lists with entries read from file. Each line is in the form of `allow <ip-or-cidr>` or
+// `deny <ip-or-cidr>`. Empty lines and lines starting with `#` are ignored.
+func (l *IPAccessList) LoadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	allow, deny, err := parseIPAccessFile(content)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return l.Update(allow, deny)
+}
+
+// WatchFile loads the file and polls it for changes with given interval, reloading lists when modification time or
+// size changes. Errors from reloading are passed to onError (when set) and previous lists are kept. Returned
+// function stops watching.
+func (l *IPAccessList) WatchFile(path string, interval time.Duration, onError func(err error)) (stop func(), err error) {
+	if err := l.LoadFile(path); err != nil {
+		return nil, err
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		lastMod, lastSize := stat.ModTime(), stat.Size()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			stat, err := os.Stat(path)
+			if err == nil && stat.ModTime().Equal(lastMod) && stat.Size() == lastSize {
+				continue
+			}
+			if err == nil {
+				lastMod, lastSize = stat.ModTime(), stat.Size()
+				err = l.LoadFile(path)
+			}
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }, nil
+}
+
+func parseIPAccessFile(content []byte) (allow []string, deny []string, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNr := 0
+	for scanner.Scan() {
+		lineNr++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, nil, fmt.Errorf("line %d: expected `allow <ip-or-cidr>` or `deny <ip-or-cidr>`", lineNr)
+		}
+		switch strings.ToLower(fields[0]) {
+		case "allow":
+			allow = append(allow, fields[1])
+		case "deny":
+			deny = append(deny, fields[1])
+		default:
+			return nil, nil, fmt.Errorf("line %d: unknown action %q", lineNr, fields[0])
+		}
+	}
+	return allow, deny, scanner.Err()
+}
+
+// ipRadixTree is a binary radix tree of IP ranges. IPv4 and IPv6 addresses are kept in separate trees.
+type ipRadixTree struct {
+	v4   *ipRadixNode
+	v6   *ipRadixNode
+	size int
+}
+
+type ipRadixNode struct {
+	children [2]*ipRadixNode
+	// terminal marks that node is end of an inserted range. All addresses below this node are contained.
+	terminal bool
+}
+
+func newIPRadixTree(entries []string) (*ipRadixTree, error) {
+	t := &ipRadixTree{v4: &ipRadixNode{}, v6: &ipRadixNode{}}
+	for _, entry := range entries {
+		ipNet, err := parseIPOrCIDR(entry)
+		if err != nil {
+			return nil, err
+		}
+		t.insert(ipNet)
+	}
+	return t, nil
+}
+
+func parseIPOrCIDR(entry string) (*net.IPNet, error) {
+	entry = strings.TrimSpace(entry)
+	if strings.Contains(entry, "/") {
+		_, ipNet, err := net.ParseCIDR(entry)
+		return ipNet, err
+	}
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address: %q", entry)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+func (t *ipRadixTree) root(ip net.IP) (*ipRadixNode, net.IP) {
+	if ip4 := ip.To4(); ip4 != nil {
+		return t.v4, ip4
+	}
+	return t.v6, ip.To16()
+}
+
+func (t *ipRadixTree) insert(ipNet *net.IPNet) {
+	node, ip := t.root(ipNet.IP)
+	ones, bits := ipNet.Mask.Size()
+	if bits == 8*net.IPv6len && len(ip) == net.IPv4len {
+		ones -= 8 * (net.IPv6len - net.IPv4len) // IPv4-mapped IPv6 range i.e. `::ffff:10.0.0.0/104`
+	}
+	for i := 0; i < ones; i++ {
+		if node.terminal {
+			return // wider range already covers this one
+		}
+		bit := ip[i/8] >> (7 - uint(i%8)) & 1
+		if node.children[bit] == nil {
+			node.children[bit] = &ipRadixNode{}
+		}
+		node = node.children[bit]
+	}
+	node.terminal = true
+	node.children = [2]*ipRadixNode{} // narrower ranges are now redundant
+	t.size++
+}
+
+func (t *ipRadixTree) contains(ip net.IP) bool {
+	node, ip := t.root(ip)
+	if ip == nil {
+		return false
+	}
+	for i := 0; node != nil; i++ {
+		if node.terminal {
+			return true
+		}
+		if i == len(ip)*8 {
+			return false
+		}
+		node = node.children[ip[i/8]>>(7-uint(i%8))&1]
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	echo "github.com/jialequ/agent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPAccessList(t *testing.T) {
+	list, err := NewIPAccessList(
+		[]string{"10.0.0.0/8", "192.168.1.1", "2001:db8::/32", "::ffff:172.16.0.0/108"},
+		[]string{"10.1.0.0/16", "2001:db8:1::1"},
+	)
+	assert.NoError(t, err)
+
+	var testCases = []struct {
+		whenIP string
+		expect bool
+	}{
+		{whenIP: "10.0.0.1", expect: true},
+		{whenIP: "10.255.255.255", expect: true},
+		{whenIP: "10.1.2.3", expect: false},
+		{whenIP: "11.0.0.1", expect: false},
+		{whenIP: "192.168.1.1", expect: true},
+		{whenIP: "192.168.1.2", expect: false},
+		{whenIP: "::ffff:10.0.0.1", expect: true},
+		{whenIP: "172.16.5.5", expect: true},
+		{whenIP: "172.32.0.1", expect: false},
+		{whenIP: "2001:db8::1", expect: true},
+		{whenIP: "2001:db8:1::1", expect: false},
+		{whenIP: "2001:db9::1", expect: false},
+		{whenIP: "", expect: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.whenIP, func(t *testing.T) {
+			assert.Equal(t, tc.expect, list.Allowed(net.ParseIP(tc.whenIP)))
+		})
+	}
+}
+
+func TestIPAccessListDenyOnly(t *testing.T) {
+	list, err := NewIPAccessList(nil, []string{"203.0.113.0/24"})
+	assert.NoError(t, err)
+
+	assert.True(t, list.Allowed(net.ParseIP("198.51.100.1")))
+	assert.False(t, list.Allowed(net.ParseIP("203.0.113.7")))
+}
+
+func TestIPAccessListUpdateInvalidKeepsPrevious(t *testing.T) {
+	list, err := NewIPAccessList([]string{"10.0.0.0/8"}, nil)
+	assert.NoError(t, err)
+
+	err = list.Update([]string{"not-an-ip"}, nil)
+	assert.EqualError(t, err, `invalid IP address: "not-an-ip"`)
+	assert.True(t, list.Allowed(net.ParseIP("10.0.0.1")))
+}
+
+func TestIPAccessWithConfig(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		whenConfig  IPAccessConfig
+		givenIP     string
+		expectCode  int
+		expectError string
+	}{
+		{
+			name:       "ok, allowed ip",
+			whenConfig: IPAccessConfig{Allow: []string{"192.0.2.0/24"}},
+			givenIP:    "192.0.2.1",
+			expectCode: http.StatusOK,
+		},
+		{
+			name:       "nok, ip not in allow list",
+			whenConfig: IPAccessConfig{Allow: []string{"10.0.0.0/8"}},
+			givenIP:    "192.0.2.1",
+			expectCode: http.StatusForbidden,
+		},
+		{
+			name:       "nok, denied ip",
+			whenConfig: IPAccessConfig{Deny: []string{"192.0.2.1"}},
+			givenIP:    "192.0.2.1",
+			expectCode: http.StatusForbidden,
+		},
+		{
+			name: "nok, custom deny handler",
+			whenConfig: IPAccessConfig{
+				Deny: []string{"192.0.2.0/24"},
+				DenyHandler: func(c echo.Context, ip string) error {
+					return echo.NewHTTPError(http.StatusNotFound, "denied "+ip)
+				},
+			},
+			givenIP:    "192.0.2.1",
+			expectCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			e.IPExtractor = echo.ExtractIPDirect()
+			e.GET("/", func(c echo.Context) error {
+				return c.String(http.StatusOK, "ok")
+			}, IPAccessWithConfig(tc.whenConfig))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.givenIP + ":1234"
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+		})
+	}
+}
+
+func TestIPAccessWithConfigPanicsOnInvalidEntry(t *testing.T) {
+	assert.Panics(t, func() {
+		IPAllowList("10.0.0.0/33")
+	})
+}
+
+func TestIPAccessListWatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("# office\nallow 10.0.0.0/8\ndeny 10.0.0.1\n"), 0o600))
+
+	list, err := NewIPAccessList(nil, nil)
+	assert.NoError(t, err)
+
+	stop, err := list.WatchFile(path, 10*time.Millisecond, nil)
+	assert.NoError(t, err)
+	defer stop()
+
+	assert.True(t, list.Allowed(net.ParseIP("10.0.0.2")))
+	assert.False(t, list.Allowed(net.ParseIP("10.0.0.1")))
+
+	assert.NoError(t, os.WriteFile(path, []byte("allow 192.168.0.0/16\n\n"), 0o600))
+	assert.Eventually(t, func() bool {
+		return list.Allowed(net.ParseIP("192.168.0.1")) && !list.Allowed(net.ParseIP("10.0.0.2"))
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestIPAccessListLoadFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("permit 10.0.0.0/8\n"), 0o600))
+
+	list, err := NewIPAccessList(nil, nil)
+	assert.NoError(t, err)
+	assert.EqualError(t, list.LoadFile(path), path+`: line 1: unknown action "permit"`)
+}
@@ -32,6 +32,8 @@ type LoggerConfig struct {
 	// - time_rfc3339_nano
 	// - time_custom
 	// - id (Request ID)
+	// - trace_id (Trace ID of the span recorded by Tracing middleware)
+	// - span_id (Span ID of the span recorded by Tracing middleware)
 	// - remote_ip
 	// - uri
 	// - host
@@ -69,6 +71,14 @@ type LoggerConfig struct {
 	// Optional. Default value os.Stdout.
 	Output io.Writer
 
+	// Redactor redacts sensitive data from `uri`, `referer`, `header:`, `query:`, `form:` and `cookie:` tags.
+	// Optional. Default value nil (nothing is redacted).
+	Redactor *Redactor
+
+	// Sampler decides which requests are logged.
+	// Optional. Default value nil (all requests are logged).
+	Sampler *LogSampler
+
 	template *fasttemplate.Template
 	colorer  *color.Color
 	pool     *sync.Pool
@@ -126,6 +136,9 @@ func LoggerWithConfig(config LoggerConfig) echo.MiddlewareFunc { //NOSONAR
 				c.Error(err)
 			}
 			stop := time.Now()
+			if config.Sampler != nil && !config.Sampler.Sample(res.Status, err, stop.Sub(start)) {
+				return nil
+			}
 			buf := config.pool.Get().(*bytes.Buffer)
 			buf.Reset()
 			defer config.pool.Put(buf)
@@ -159,11 +172,22 @@ func LoggerWithConfig(config LoggerConfig) echo.MiddlewareFunc { //NOSONAR
 						id = res.Header().Get(echo.HeaderXRequestID)
 					}
 					return buf.WriteString(id)
+				case "trace_id":
+					if span := SpanFromContext(c.Request().Context()); span != nil {
+						return buf.WriteString(span.SpanContext.TraceID.String())
+					}
+				case "span_id":
+					if span := SpanFromContext(c.Request().Context()); span != nil {
+						return buf.WriteString(span.SpanContext.SpanID.String())
+					}
 				case "remote_ip":
 					return buf.WriteString(c.RealIP())
 				case "host":
 					return buf.WriteString(req.Host)
 				case "uri":
+					if config.Redactor != nil {
+						return buf.WriteString(config.Redactor.URI(req.RequestURI))
+					}
 					return buf.WriteString(req.RequestURI)
 				case "method":
 					return buf.WriteString(req.Method)
@@ -178,6 +202,9 @@ func LoggerWithConfig(config LoggerConfig) echo.MiddlewareFunc { //NOSONAR
 				case "protocol":
 					return buf.WriteString(req.Proto)
 				case "referer":
+					if config.Redactor != nil {
+						return buf.WriteString(config.Redactor.URI(req.Referer()))
+					}
 					return buf.WriteString(req.Referer())
 				case "user_agent":
 					return buf.WriteString(req.UserAgent())
@@ -216,15 +243,31 @@ func LoggerWithConfig(config LoggerConfig) echo.MiddlewareFunc { //NOSONAR
 				default:
 					switch {
 					case strings.HasPrefix(tag, "header:"):
-						return buf.Write([]byte(c.Request().Header.Get(tag[7:])))
+						v := c.Request().Header.Get(tag[7:])
+						if config.Redactor != nil {
+							v = config.Redactor.Header(tag[7:], v)
+						}
+						return buf.Write([]byte(v))
 					case strings.HasPrefix(tag, "query:"):
-						return buf.Write([]byte(c.QueryParam(tag[6:])))
+						v := c.QueryParam(tag[6:])
+						if config.Redactor != nil {
+							v = config.Redactor.Value(tag[6:], v)
+						}
+						return buf.Write([]byte(v))
 					case strings.HasPrefix(tag, "form:"):
-						return buf.Write([]byte(c.FormValue(tag[5:])))
+						v := c.FormValue(tag[5:])
+						if config.Redactor != nil {
+							v = config.Redactor.Value(tag[5:], v)
+						}
+						return buf.Write([]byte(v))
 					case strings.HasPrefix(tag, "cookie:"):
 						cookie, err := c.Cookie(tag[7:])
 						if err == nil {
-							return buf.Write([]byte(cookie.Value))
+							v := cookie.Value
+							if config.Redactor != nil {
+								v = config.Redactor.Value(tag[7:], v)
+							}
+							return buf.Write([]byte(v))
 						}
 					}
 				}
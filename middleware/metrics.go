@@ -0,0 +1,329 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	echo "github.com/jialequ/agent"
+)
+
+// MetricsConfig defines the config for Metrics middleware.
+type MetricsConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// Registry is the registry metrics are recorded into.
+	// Required.
+	Registry *MetricsRegistry
+
+	// Buckets defines upper bounds (in seconds) of request latency histogram buckets.
+	// Optional. Default value DefaultMetricsBuckets.
+	Buckets []float64
+}
+
+// DefaultMetricsBuckets are default latency histogram buckets (in seconds).
+var DefaultMetricsBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// DefaultMetricsConfig is the default Metrics middleware config.
+var DefaultMetricsConfig = MetricsConfig{
+	Skipper: DefaultSkipper,
+	Buckets: DefaultMetricsBuckets,
+}
+
+const (
+	metricsTypeCounter   = "counter"
+	metricsTypeGauge     = "gauge"
+	metricsTypeHistogram = "histogram"
+	metricsTypeSummary   = "summary"
+)
+
+// MetricsRegistry holds metrics recorded by Metrics middleware and other middlewares (Proxy, RateLimiter, Recover)
+// configured with the same registry. Metrics are exposed in Prometheus text exposition format by `Handler`.
+//
+// Example:
+//
+//	registry := middleware.NewMetricsRegistry("myapp")
+//	e.Use(middleware.Metrics(registry))
+//	e.GET("/metrics", registry.Handler())
+type MetricsRegistry struct {
+	namespace string
+	mutex     sync.RWMutex
+	families  map[string]*metricFamily
+}
+
+type metricFamily struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64
+
+	mutex  sync.Mutex
+	series map[string]*metricSeries
+}
+
+type metricSeries struct {
+	labelValues  []string
+	value        float64
+	count        uint64
+	bucketCounts []uint64
+}
+
+// NewMetricsRegistry creates new MetricsRegistry. Namespace is used as prefix for metric names. Defaults to "echo".
+func NewMetricsRegistry(namespace string) *MetricsRegistry {
+	if namespace == "" {
+		namespace = "echo"
+	}
+	return &MetricsRegistry{
+		namespace: namespace,
+		families:  make(map[string]*metricFamily),
+	}
+}
+
+// Metrics returns a middleware that records request metrics into given registry.
+func Metrics(registry *MetricsRegistry) echo.MiddlewareFunc {
+	config := DefaultMetricsConfig
+	config.Registry = registry
+	return MetricsWithConfig(config)
+}
+
+// MetricsWithConfig returns a Metrics middleware with config.
+// See: `Metrics()`.
+//
+// Recorded metrics (names are prefixed with registry namespace):
+//   - `http_requests_total` counter by method, route and status class
+//   - `http_request_duration_seconds` histogram by method and route
+//   - `http_requests_in_flight` gauge
+//   - `http_request_size_bytes` and `http_response_size_bytes` summaries by method and route
+func MetricsWithConfig(config MetricsConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultMetricsConfig.Skipper
+	}
+	if len(config.Buckets) == 0 {
+		config.Buckets = DefaultMetricsConfig.Buckets
+	}
+	if config.Registry == nil {
+		panic("echo: metrics middleware requires registry")
+	}
+
+	reg := config.Registry
+	requests := reg.family("http_requests_total", "Total number of HTTP requests.", metricsTypeCounter, nil, "method", "route", "status")
+	duration := reg.family("http_request_duration_seconds", "HTTP request latency in seconds.", metricsTypeHistogram, config.Buckets, "method", "route")
+	inFlight := reg.family("http_requests_in_flight", "Number of HTTP requests being served.", metricsTypeGauge, nil)
+	requestSize := reg.family("http_request_size_bytes", "HTTP request body size in bytes.", metricsTypeSummary, nil, "method", "route")
+	responseSize := reg.family("http_response_size_bytes", "HTTP response body size in bytes.", metricsTypeSummary, nil, "method", "route")
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			inFlight.add(1)
+			defer inFlight.add(-1)
+
+			start := time.Now()
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+			elapsed := time.Since(start).Seconds()
+
+			req := c.Request()
+			res := c.Response()
+			route := c.Path()
+			requests.add(1, req.Method, route, statusClass(res.Status))
+			duration.observe(elapsed, req.Method, route)
+			if req.ContentLength >= 0 {
+				requestSize.observe(float64(req.ContentLength), req.Method, route)
+			}
+			responseSize.observe(float64(res.Size), req.Method, route)
+			return nil
+		}
+	}
+}
+
+// Handler returns handler that writes all registered metrics in Prometheus text exposition format.
+func (r *MetricsRegistry) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+		c.Response().WriteHeader(http.StatusOK)
+		return r.Expose(c.Response())
+	}
+}
+
+// Expose writes all registered metrics in Prometheus text exposition format to given writer.
+func (r *MetricsRegistry) Expose(w io.Writer) error {
+	r.mutex.RLock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	families := make([]*metricFamily, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		families = append(families, r.families[name])
+	}
+	r.mutex.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.writeTo(bw)
+	}
+	return bw.Flush()
+}
+
+// family returns registered metric family or registers a new one. Name is prefixed with registry namespace.
+func (r *MetricsRegistry) family(name, help, typ string, buckets []float64, labels ...string) *metricFamily {
+	name = r.namespace + "_" + name
+
+	r.mutex.RLock()
+	f, ok := r.families[name]
+	r.mutex.RUnlock()
+	if ok {
+		return f
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if f, ok = r.families[name]; ok {
+		return f
+	}
+	if typ == metricsTypeHistogram {
+		buckets = append([]float64(nil), buckets...)
+		sort.Float64s(buckets)
+	}
+	f = &metricFamily{
+		name:    name,
+		help:    help,
+		typ:     typ,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*metricSeries),
+	}
+	r.families[name] = f
+	return f
+}
+
+func (f *metricFamily) get(labelValues []string) *metricSeries {
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &metricSeries{labelValues: labelValues}
+		if f.typ == metricsTypeHistogram {
+			s.bucketCounts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// add adds value to counter or gauge.
+func (f *metricFamily) add(v float64, labelValues ...string) {
+	f.mutex.Lock()
+	f.get(labelValues).value += v
+	f.mutex.Unlock()
+}
+
+// observe records value in histogram or summary.
+func (f *metricFamily) observe(v float64, labelValues ...string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	s := f.get(labelValues)
+	s.value += v
+	s.count++
+	for i, upperBound := range f.buckets {
+		if v <= upperBound {
+			s.bucketCounts[i]++
+		}
+	}
+}
+
+func (f *metricFamily) writeTo(w *bufio.Writer) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w.WriteString("# HELP " + f.name + " " + f.help + "\n")
+	w.WriteString("# TYPE " + f.name + " " + f.typ + "\n")
+	for _, k := range keys {
+		s := f.series[k]
+		switch f.typ {
+		case metricsTypeHistogram:
+			for i, upperBound := range f.buckets {
+				f.writeSample(w, "_bucket", s.labelValues, "le", formatMetricValue(upperBound), float64(s.bucketCounts[i]))
+			}
+			f.writeSample(w, "_bucket", s.labelValues, "le", "+Inf", float64(s.count))
+			f.writeSample(w, "_sum", s.labelValues, "", "", s.value)
+			f.writeSample(w, "_count", s.labelValues, "", "", float64(s.count))
+		case metricsTypeSummary:
+			f.writeSample(w, "_sum", s.labelValues, "", "", s.value)
+			f.writeSample(w, "_count", s.labelValues, "", "", float64(s.count))
+		default:
+			f.writeSample(w, "", s.labelValues, "", "", s.value)
+		}
+	}
+}
+
+func (f *metricFamily) writeSample(w *bufio.Writer, suffix string, labelValues []string, extraLabel, extraValue string, v float64) {
+	w.WriteString(f.name + suffix)
+	if len(f.labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, l := range f.labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(l + `="` + escapeMetricLabel(labelValues[i]) + `"`)
+		}
+		if extraLabel != "" {
+			if len(f.labels) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extraLabel + `="` + extraValue + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatMetricValue(v))
+	w.WriteByte('\n')
+}
+
+var metricLabelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeMetricLabel(v string) string {
+	return metricLabelReplacer.Replace(v)
+}
+
+func formatMetricValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// statusClass returns status code class label value (i.e. "2xx") for HTTP status code.
+func statusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	echo "github.com/jialequ/agent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	e := echo.New()
+	registry := NewMetricsRegistry("")
+	e.Use(MetricsWithConfig(MetricsConfig{
+		Registry: registry,
+		Buckets:  []float64{1, 0.1},
+	}))
+	e.GET("/users/:id", func(c echo.Context) error {
+		return c.String(http.StatusOK, "user")
+	})
+	e.POST("/users", func(c echo.Context) error {
+		return echo.ErrBadRequest
+	})
+	e.GET("/metrics", registry.Handler())
+
+	for _, id := range []string{"1", "2"} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/"+id, nil))
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("abc")))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "# TYPE echo_http_requests_total counter\n")
+	assert.Contains(t, body, `echo_http_requests_total{method="GET",route="/users/:id",status="2xx"} 2`+"\n")
+	assert.Contains(t, body, `echo_http_requests_total{method="POST",route="/users",status="4xx"} 1`+"\n")
+	assert.Contains(t, body, "# TYPE echo_http_request_duration_seconds histogram\n")
+	assert.Contains(t, body, `echo_http_request_duration_seconds_bucket{method="GET",route="/users/:id",le="0.1"} 2`+"\n")
+	assert.Contains(t, body, `echo_http_request_duration_seconds_bucket{method="GET",route="/users/:id",le="1"} 2`+"\n")
+	assert.Contains(t, body, `echo_http_request_duration_seconds_bucket{method="GET",route="/users/:id",le="+Inf"} 2`+"\n")
+	assert.Contains(t, body, `echo_http_request_duration_seconds_count{method="GET",route="/users/:id"} 2`+"\n")
+	assert.Contains(t, body, `echo_http_request_size_bytes_sum{method="POST",route="/users"} 3`+"\n")
+	assert.Contains(t, body, `echo_http_response_size_bytes_sum{method="GET",route="/users/:id"} 8`+"\n")
+	assert.Contains(t, body, "echo_http_requests_in_flight 1\n") // metrics request itself
+}
+
+func TestMetricsRequiresRegistry(t *testing.T) {
+	assert.PanicsWithValue(t, "echo: metrics middleware requires registry", func() {
+		MetricsWithConfig(MetricsConfig{})
+	})
+}
+
+func TestMetricsRegistryEscapesLabels(t *testing.T) {
+	registry := NewMetricsRegistry("app")
+	registry.family("events_total", "Events.", metricsTypeCounter, nil, "name").add(2, "a\"b\\c\nd")
+
+	buf := new(strings.Builder)
+	assert.NoError(t, registry.Expose(buf))
+	assert.Equal(t, "# HELP app_events_total Events.\n# TYPE app_events_total counter\n"+
+		`app_events_total{name="a\"b\\c\nd"} 2`+"\n", buf.String())
+}
+
+func TestMetricsFromOtherMiddlewares(t *testing.T) {
+	registry := NewMetricsRegistry("")
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer upstream.Close()
+	upstreamURL, _ := url.Parse(upstream.URL)
+
+	e := echo.New()
+	e.Use(RecoverWithConfig(RecoverConfig{Metrics: registry, DisablePrintStack: true}))
+	e.GET("/proxy", func(c echo.Context) error { return nil }, ProxyWithConfig(ProxyConfig{
+		Balancer: NewRoundRobinBalancer([]*ProxyTarget{{Name: "upstream", URL: upstreamURL}}),
+		Metrics:  registry,
+	}))
+	e.GET("/limited", func(c echo.Context) error { return nil }, RateLimiterWithConfig(RateLimiterConfig{
+		Store:   denyAllStore{},
+		Metrics: registry,
+	}))
+	e.GET("/panic", func(c echo.Context) error {
+		panic(errors.New("boom"))
+	})
+
+	for _, path := range []string{"/proxy", "/limited", "/panic"} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	buf := new(strings.Builder)
+	assert.NoError(t, registry.Expose(buf))
+	body := buf.String()
+	assert.Contains(t, body, `echo_proxy_requests_total{target="upstream",status="2xx"} 1`+"\n")
+	assert.Contains(t, body, `echo_proxy_request_duration_seconds_count{target="upstream"} 1`+"\n")
+	assert.Contains(t, body, `echo_rate_limiter_denied_total{route="/limited"} 1`+"\n")
+	assert.Contains(t, body, `echo_panics_total{route="/panic"} 1`+"\n")
+}
+
+type denyAllStore struct{}
+
+func (denyAllStore) Allow(identifier string) (bool, error) {
+	return false, nil
+}
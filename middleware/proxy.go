@@ -82,6 +82,11 @@ type ProxyConfig struct {
 
 	// ModifyResponse defines function to modify response from ProxyTarget.
 	ModifyResponse func(*http.Response) error
+
+	// Metrics is the registry upstream request metrics (`proxy_requests_total` counter by target and status class,
+	// `proxy_request_duration_seconds` histogram by target) are recorded into.
+	// Optional. Default value nil (metrics are not recorded).
+	Metrics *MetricsRegistry
 }
 
 // ProxyTarget defines the upstream target.
@@ -309,6 +314,12 @@ func ProxyWithConfig(config ProxyConfig) echo.MiddlewareFunc { //NOSONAR
 
 	provider, isTargetProvider := config.Balancer.(TargetProvider)
 
+	var proxyRequests, proxyDuration *metricFamily
+	if config.Metrics != nil {
+		proxyRequests = config.Metrics.family("proxy_requests_total", "Total number of requests proxied to upstream targets.", metricsTypeCounter, nil, "target", "status")
+		proxyDuration = config.Metrics.family("proxy_request_duration_seconds", "Upstream request latency in seconds.", metricsTypeHistogram, DefaultMetricsBuckets, "target")
+	}
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			if config.Skipper(c) {
@@ -359,8 +370,11 @@ func ProxyWithConfig(config ProxyConfig) echo.MiddlewareFunc { //NOSONAR
 				// This is needed for ProxyConfig.ModifyResponse and/or ProxyConfig.Transport to be able to process the Request
 				// that Balancer may have replaced with c.SetRequest.
 				req = c.Request()
+				// Propagate span recorded by Tracing middleware to the upstream target
+				InjectTraceContext(req.Context(), req.Header)
 
 				// Proxy
+				start := time.Now()
 				switch {
 				case c.IsWebSocket():
 					proxyRaw(tgt, c).ServeHTTP(res, req)
@@ -369,6 +383,18 @@ func ProxyWithConfig(config ProxyConfig) echo.MiddlewareFunc { //NOSONAR
 				}
 
 				err, hasError := c.Get("_error").(error)
+				if config.Metrics != nil {
+					status := res.Status
+					if httpErr, ok := err.(*echo.HTTPError); ok && hasError {
+						status = httpErr.Code
+					}
+					target := tgt.Name
+					if target == "" {
+						target = tgt.URL.String()
+					}
+					proxyRequests.add(1, target, statusClass(status))
+					proxyDuration.observe(time.Since(start).Seconds(), target)
+				}
 				if !hasError {
 					return nil
 				}
@@ -30,6 +30,9 @@ type RateLimiterConfig struct {
 	ErrorHandler func(context echo.Context, err error) error
 	// DenyHandler provides a handler to be called when RateLimiter denies access
 	DenyHandler func(context echo.Context, identifier string, err error) error
+	// Metrics is the registry denied requests (`rate_limiter_denied_total` counter by route) are recorded into.
+	// Optional. Default value nil (metrics are not recorded).
+	Metrics *MetricsRegistry
 }
 
 // Extractor is used to extract data from echo.Context
@@ -124,6 +127,10 @@ func RateLimiterWithConfig(config RateLimiterConfig) echo.MiddlewareFunc { //NOS
 	if config.Store == nil {
 		panic("Store configuration must be provided")
 	}
+	var denied *metricFamily
+	if config.Metrics != nil {
+		denied = config.Metrics.family("rate_limiter_denied_total", "Total number of requests denied by rate limiter.", metricsTypeCounter, nil, "route")
+	}
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			if config.Skipper(c) {
@@ -140,6 +147,9 @@ func RateLimiterWithConfig(config RateLimiterConfig) echo.MiddlewareFunc { //NOS
 			}
 
 			if allow, err := config.Store.Allow(identifier); !allow {
+				if denied != nil {
+					denied.add(1, c.Path())
+				}
 				c.Error(config.DenyHandler(c, identifier, err))
 				return nil
 			}
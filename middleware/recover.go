@@ -46,6 +46,10 @@ type RecoverConfig struct {
 	// The recovered error is then passed back to upstream middleware, instead of swallowing the error.
 	// Optional. Default value false.
 	DisableErrorHandler bool `yaml:"disable_error_handler"`
+
+	// Metrics is the registry recovered panics (`panics_total` counter by route) are recorded into.
+	// Optional. Default value nil (metrics are not recorded).
+	Metrics *MetricsRegistry `yaml:"-"`
 }
 
 // DefaultRecoverConfig is the default Recover middleware config.
@@ -75,6 +79,10 @@ func RecoverWithConfig(config RecoverConfig) echo.MiddlewareFunc { //NOSONAR
 	if config.StackSize == 0 {
 		config.StackSize = DefaultRecoverConfig.StackSize
 	}
+	var panics *metricFamily
+	if config.Metrics != nil {
+		panics = config.Metrics.family("panics_total", "Total number of panics recovered by Recover middleware.", metricsTypeCounter, nil, "route")
+	}
 
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) (returnErr error) {
@@ -87,6 +95,9 @@ func RecoverWithConfig(config RecoverConfig) echo.MiddlewareFunc { //NOSONAR
 					if r == http.ErrAbortHandler {
 						panic(r)
 					}
+					if panics != nil {
+						panics.add(1, c.Path())
+					}
 					err, ok := r.(error)
 					if !ok {
 						err = fmt.Errorf("%v", r)
@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRedactionReplacement is the value redacted data is replaced with.
+const DefaultRedactionReplacement = "[REDACTED]"
+
+// Redactor removes sensitive data from values logged by Logger, RequestLogger and BodyDump middlewares.
+type Redactor struct {
+	// Headers is list of header names which values are redacted. Names are case-insensitive.
+	Headers []string
+
+	// Keys is list of query parameter and form value names which values are redacted. Names are case-insensitive.
+	Keys []string
+
+	// JSONPaths is list of paths to values in JSON bodies that are redacted. Path segments are separated by dots and
+	// `*` segment matches any object key or array element (i.e. `user.password`, `cards.*.number`).
+	JSONPaths []string
+
+	// Patterns is list of regular expressions. Matching parts of logged values (headers, query, URI, bodies) are
+	// redacted.
+	Patterns []*regexp.Regexp
+
+	// Replacement is the value redacted data is replaced with.
+	// Optional. Default value DefaultRedactionReplacement.
+	Replacement string
+
+	once      sync.Once
+	headers   map[string]struct{}
+	keys      map[string]struct{}
+	jsonPaths [][]string
+}
+
+// DefaultRedactor redacts credentials in common headers, common secret query/form keys, bearer tokens and card
+// numbers.
+var DefaultRedactor = &Redactor{
+	Headers: []string{
+		"Authorization",
+		"Proxy-Authorization",
+		"Cookie",
+		"Set-Cookie",
+		"X-Api-Key",
+		"X-CSRF-Token",
+	},
+	Keys:      []string{"password", "passwd", "secret", "token", "access_token", "refresh_token", "api_key", "client_secret"},
+	JSONPaths: []string{"password", "secret", "token", "access_token", "refresh_token", "client_secret"},
+	Patterns: []*regexp.Regexp{
+		regexp.MustCompile(`(?i)bearer\s+[a-z0-9\-._~+/]+=*`),
+		regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), // payment card numbers
+	},
+}
+
+func (r *Redactor) init() {
+	r.once.Do(func() {
+		if r.Replacement == "" {
+			r.Replacement = DefaultRedactionReplacement
+		}
+		r.headers = make(map[string]struct{}, len(r.Headers))
+		for _, h := range r.Headers {
+			r.headers[http.CanonicalHeaderKey(h)] = struct{}{}
+		}
+		r.keys = make(map[string]struct{}, len(r.Keys))
+		for _, k := range r.Keys {
+			r.keys[strings.ToLower(k)] = struct{}{}
+		}
+		r.jsonPaths = make([][]string, 0, len(r.JSONPaths))
+		for _, p := range r.JSONPaths {
+			r.jsonPaths = append(r.jsonPaths, strings.Split(p, "."))
+		}
+	})
+}
+
+// String redacts parts of s matching Patterns.
+func (r *Redactor) String(s string) string {
+	r.init()
+	for _, p := range r.Patterns {
+		s = p.ReplaceAllLiteralString(s, r.Replacement)
+	}
+	return s
+}
+
+// Header returns redacted value of header with given name.
+func (r *Redactor) Header(name, value string) string {
+	r.init()
+	if _, ok := r.headers[http.CanonicalHeaderKey(name)]; ok {
+		return r.Replacement
+	}
+	return r.String(value)
+}
+
+// HeaderValues returns copy of headers with values redacted. Header names must be in canonical form.
+func (r *Redactor) HeaderValues(headers map[string][]string) map[string][]string {
+	if headers == nil {
+		return nil
+	}
+	result := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		redacted := make([]string, len(values))
+		for i, v := range values {
+			redacted[i] = r.Header(name, v)
+		}
+		result[name] = redacted
+	}
+	return result
+}
+
+// Value returns redacted value of query parameter or form value with given name.
+func (r *Redactor) Value(name, value string) string {
+	r.init()
+	if _, ok := r.keys[strings.ToLower(name)]; ok {
+		return r.Replacement
+	}
+	return r.String(value)
+}
+
+// Values returns copy of query parameters or form values with values redacted.
+func (r *Redactor) Values(values map[string][]string) map[string][]string {
+	if values == nil {
+		return nil
+	}
+	result := make(map[string][]string, len(values))
+	for name, vs := range values {
+		redacted := make([]string, len(vs))
+		for i, v := range vs {
+			redacted[i] = r.Value(name, v)
+		}
+		result[name] = redacted
+	}
+	return result
+}
+
+// URI returns request URI with query parameter values redacted.
+func (r *Redactor) URI(uri string) string {
+	r.init()
+	path, query, ok := strings.Cut(uri, "?")
+	if !ok || query == "" {
+		return r.String(uri)
+	}
+	parts := strings.Split(query, "&")
+	for i, part := range parts {
+		key, _, hasValue := strings.Cut(part, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if _, redact := r.keys[strings.ToLower(name)]; redact && hasValue {
+			parts[i] = key + "=" + url.QueryEscape(r.Replacement)
+		}
+	}
+	return r.String(path + "?" + strings.Join(parts, "&"))
+}
+
+// Body returns redacted copy of body. Values at JSONPaths are redacted when body is JSON and Patterns are applied
+// to the whole body. Body is returned unchanged when nothing was redacted.
+func (r *Redactor) Body(body []byte) []byte {
+	r.init()
+	if len(body) == 0 {
+		return body
+	}
+	if len(r.jsonPaths) > 0 {
+		if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			var doc interface{}
+			dec := json.NewDecoder(bytes.NewReader(trimmed))
+			dec.UseNumber()
+			if err := dec.Decode(&doc); err == nil {
+				redacted := false
+				for _, path := range r.jsonPaths {
+					var changed bool
+					doc, changed = r.redactJSONPath(doc, path)
+					redacted = redacted || changed
+				}
+				if redacted {
+					if b, err := json.Marshal(doc); err == nil {
+						body = b
+					}
+				}
+			}
+		}
+	}
+	for _, p := range r.Patterns {
+		if p.Match(body) {
+			return []byte(r.String(string(body)))
+		}
+	}
+	return body
+}
+
+// redactJSONPath replaces values at path in doc and reports whether anything was replaced.
+func (r *Redactor) redactJSONPath(doc interface{}, path []string) (interface{}, bool) {
+	if len(path) == 0 {
+		return r.Replacement, true
+	}
+	redacted := false
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if path[0] == "*" || key == path[0] {
+				var changed bool
+				v[key], changed = r.redactJSONPath(child, path[1:])
+				redacted = redacted || changed
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			var changed bool
+			if path[0] == "*" {
+				v[i], changed = r.redactJSONPath(child, path[1:])
+			} else {
+				// path without `*` segment for array elements applies to each element (i.e. `password` in list of users)
+				v[i], changed = r.redactJSONPath(child, path)
+			}
+			redacted = redacted || changed
+		}
+	}
+	return doc, redacted
+}
+
+// LogSampler decides which requests are logged to keep log volume down.
+type LogSampler struct {
+	// Rate is fraction (0.0-1.0] of requests that are logged.
+	// Optional. Default value 0 logs all requests. Negative value logs only requests matched by AlwaysLogErrors or
+	// SlowThreshold.
+	Rate float64
+
+	// AlwaysLogErrors logs all requests that returned an error or responded with 5xx status code.
+	AlwaysLogErrors bool
+
+	// SlowThreshold logs all requests that took at least given duration. Zero disables.
+	SlowThreshold time.Duration
+
+	// Random returns random number in [0.0,1.0).
+	// Optional. Default value math/rand.Float64.
+	Random func() float64
+}
+
+// Sample returns true when request with given outcome should be logged.
+func (s *LogSampler) Sample(status int, err error, latency time.Duration) bool {
+	if s.AlwaysLogErrors && (err != nil || status >= http.StatusInternalServerError) {
+		return true
+	}
+	if s.SlowThreshold > 0 && latency >= s.SlowThreshold {
+		return true
+	}
+	if s.Rate == 0 || s.Rate >= 1 {
+		return true
+	}
+	if s.Rate < 0 {
+		return false
+	}
+	random := s.Random
+	if random == nil {
+		random = rand.Float64
+	}
+	return random() < s.Rate
+}
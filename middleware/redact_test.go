@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	echo "github.com/jialequ/agent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor(t *testing.T) {
+	r := &Redactor{
+		Headers:   []string{"authorization"},
+		Keys:      []string{"Password"},
+		JSONPaths: []string{"password", "cards.*.number", "user.token"},
+		Patterns:  []*regexp.Regexp{regexp.MustCompile(`sk_[a-z0-9]+`)},
+	}
+
+	assert.Equal(t, "[REDACTED]", r.Header("Authorization", "Basic dXNlcjpwYXNz"))
+	assert.Equal(t, "key [REDACTED]", r.Header("X-Key", "key sk_abc123"))
+	assert.Equal(t, "[REDACTED]", r.Value("password", "secret"))
+	assert.Equal(t, "john", r.Value("username", "john"))
+
+	assert.Equal(t, "/login?user=john&PASSWORD=%5BREDACTED%5D&key=[REDACTED]", r.URI("/login?user=john&PASSWORD=secret&key=sk_abc123"))
+	assert.Equal(t, "/login", r.URI("/login"))
+
+	assert.Equal(t,
+		map[string][]string{"Authorization": {"[REDACTED]"}, "Accept": {"*/*"}},
+		r.HeaderValues(map[string][]string{"Authorization": {"Bearer x"}, "Accept": {"*/*"}}),
+	)
+	assert.Equal(t,
+		map[string][]string{"password": {"[REDACTED]", "[REDACTED]"}, "q": {"a"}},
+		r.Values(map[string][]string{"password": {"a", "b"}, "q": {"a"}}),
+	)
+
+	body := `{"password":"x","cards":[{"number":"4111"},{"number":"5500"}],"user":{"name":"john","token":"sk_abc"},"n":1.50}`
+	assert.Equal(t,
+		`{"cards":[{"number":"[REDACTED]"},{"number":"[REDACTED]"}],"n":1.50,"password":"[REDACTED]","user":{"name":"john","token":"[REDACTED]"}}`,
+		string(r.Body([]byte(body))),
+	)
+	assert.Equal(t, `[{"password":"[REDACTED]"}]`, string(r.Body([]byte(`[{"password":"x"}]`))))
+	assert.Equal(t, "plain [REDACTED] text", string(r.Body([]byte("plain sk_abc text"))))
+	assert.Equal(t, `{ "name": "john", "age": 1 }`, string(r.Body([]byte(`{ "name": "john", "age": 1 }`))))
+}
+
+func TestDefaultRedactor(t *testing.T) {
+	assert.Equal(t, "[REDACTED]", DefaultRedactor.Header("Cookie", "session=abc"))
+	assert.Equal(t, "token [REDACTED]", DefaultRedactor.String("token Bearer eyJhbGciOiJIUzI1NiJ9.e30.abc"))
+	assert.Equal(t, "card [REDACTED]", DefaultRedactor.String("card 4111 1111 1111 1111"))
+	assert.Equal(t, "order 12345", DefaultRedactor.String("order 12345"))
+}
+
+func TestLogSampler(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		givenSampler LogSampler
+		whenStatus   int
+		whenErr      error
+		whenLatency  time.Duration
+		expect       bool
+	}{
+		{name: "rate 1", givenSampler: LogSampler{Rate: 1}, whenStatus: http.StatusOK, expect: true},
+		{name: "rate 0 logs everything", givenSampler: LogSampler{Rate: 0}, whenStatus: http.StatusOK, expect: true},
+		{name: "negative rate", givenSampler: LogSampler{Rate: -1}, whenStatus: http.StatusOK, expect: false},
+		{name: "random below rate", givenSampler: LogSampler{Rate: 0.5, Random: func() float64 { return 0.4 }}, whenStatus: http.StatusOK, expect: true},
+		{name: "random above rate", givenSampler: LogSampler{Rate: 0.5, Random: func() float64 { return 0.6 }}, whenStatus: http.StatusOK, expect: false},
+		{name: "always log errors, 5xx", givenSampler: LogSampler{AlwaysLogErrors: true}, whenStatus: http.StatusBadGateway, expect: true},
+		{name: "always log errors, error", givenSampler: LogSampler{AlwaysLogErrors: true}, whenStatus: http.StatusOK, whenErr: errors.New("x"), expect: true},
+		{name: "always log errors, 4xx", givenSampler: LogSampler{Rate: -1, AlwaysLogErrors: true}, whenStatus: http.StatusNotFound, expect: false},
+		{name: "slow request", givenSampler: LogSampler{SlowThreshold: time.Second}, whenStatus: http.StatusOK, whenLatency: 2 * time.Second, expect: true},
+		{name: "fast request", givenSampler: LogSampler{Rate: -1, SlowThreshold: time.Second}, whenStatus: http.StatusOK, whenLatency: time.Millisecond, expect: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, tc.givenSampler.Sample(tc.whenStatus, tc.whenErr, tc.whenLatency))
+		})
+	}
+}
+
+func TestRedactionInLoggers(t *testing.T) {
+	e := echo.New()
+	buf := new(bytes.Buffer)
+	var logged RequestLoggerValues
+	var dumpedReq, dumpedRes []byte
+
+	e.Use(LoggerWithConfig(LoggerConfig{
+		Format:   "${uri} ${referer} ${header:Authorization} ${query:password}\n",
+		Output:   buf,
+		Redactor: DefaultRedactor,
+	}))
+	e.Use(RequestLoggerWithConfig(RequestLoggerConfig{
+		LogURI:         true,
+		LogReferer:     true,
+		LogHeaders:     []string{"Authorization"},
+		LogQueryParams: []string{"password"},
+		Redactor:       DefaultRedactor,
+		LogValuesFunc: func(c echo.Context, v RequestLoggerValues) error {
+			logged = v
+			return nil
+		},
+	}))
+	e.Use(BodyDumpWithConfig(BodyDumpConfig{
+		Redactor: DefaultRedactor,
+		Handler: func(c echo.Context, reqBody, resBody []byte) {
+			dumpedReq, dumpedRes = reqBody, resBody
+		},
+	}))
+	e.POST("/login", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"access_token": "abc"})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/login?password=secret", strings.NewReader(`{"password":"secret"}`))
+	req.Header.Set(echo.HeaderAuthorization, "Basic dXNlcjpwYXNz")
+	req.Header.Set("Referer", "https://example.com/reset?token=abc")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, `{"access_token":"abc"}`+"\n", rec.Body.String())
+	assert.Equal(t, "/login?password=%5BREDACTED%5D https://example.com/reset?token=%5BREDACTED%5D [REDACTED] [REDACTED]\n", buf.String())
+	assert.Equal(t, "/login?password=%5BREDACTED%5D", logged.URI)
+	assert.Equal(t, "https://example.com/reset?token=%5BREDACTED%5D", logged.Referer)
+	assert.Equal(t, map[string][]string{"Authorization": {"[REDACTED]"}}, logged.Headers)
+	assert.Equal(t, map[string][]string{"password": {"[REDACTED]"}}, logged.QueryParams)
+	assert.Equal(t, `{"password":"[REDACTED]"}`, string(dumpedReq))
+	assert.Equal(t, `{"access_token":"[REDACTED]"}`, string(dumpedRes))
+}
+
+func TestSamplingInLoggers(t *testing.T) {
+	e := echo.New()
+	buf := new(bytes.Buffer)
+	logged := 0
+	dumped := 0
+	sampler := &LogSampler{Rate: -1, AlwaysLogErrors: true}
+
+	e.Use(LoggerWithConfig(LoggerConfig{Format: "${status}\n", Output: buf, Sampler: sampler}))
+	e.Use(RequestLoggerWithConfig(RequestLoggerConfig{
+		Sampler: sampler,
+		LogValuesFunc: func(c echo.Context, v RequestLoggerValues) error {
+			logged++
+			return nil
+		},
+	}))
+	e.Use(BodyDumpWithConfig(BodyDumpConfig{
+		Sampler: sampler,
+		Handler: func(c echo.Context, reqBody, resBody []byte) {
+			dumped++
+		},
+	}))
+	e.GET("/ok", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+	e.GET("/error", func(c echo.Context) error {
+		return errors.New("boom")
+	})
+
+	for _, path := range []string{"/ok", "/error", "/ok"} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, "500\n", buf.String())
+	assert.Equal(t, 1, logged)
+	assert.Equal(t, 1, dumped)
+}
@@ -159,6 +159,8 @@ type RequestLoggerConfig struct {
 	LogRoutePath bool
 	// LogRequestID instructs logger to extract request ID from request `X-Request-ID` header or response if request did not have value.
 	LogRequestID bool
+	// LogTraceID instructs logger to extract trace and span IDs of the span recorded by Tracing middleware.
+	LogTraceID bool
 	// LogReferer instructs logger to extract request referer values.
 	LogReferer bool
 	// LogUserAgent instructs logger to extract request user agent values.
@@ -187,6 +189,14 @@ type RequestLoggerConfig struct {
 	// contain more than one form value with same name so slice of values is been logger for each given form value name.
 	LogFormValues []string
 
+	// Redactor redacts sensitive data from extracted URI, referer, headers, query parameters and form values.
+	// Optional. Default value nil (nothing is redacted).
+	Redactor *Redactor
+
+	// Sampler decides which requests are logged. LogValuesFunc is not called for requests that are not sampled.
+	// Optional. Default value nil (all requests are logged).
+	Sampler *LogSampler
+
 	timeNow func() time.Time
 }
 
@@ -212,6 +222,10 @@ type RequestLoggerValues struct {
 	RoutePath string
 	// RequestID is request ID from request `X-Request-ID` header or response if request did not have value.
 	RequestID string
+	// TraceID is trace ID of the span recorded by Tracing middleware.
+	TraceID string
+	// SpanID is span ID of the span recorded by Tracing middleware.
+	SpanID string
 	// Referer is request referer values.
 	Referer string
 	// UserAgent is request user agent values.
@@ -288,11 +302,23 @@ func (config RequestLoggerConfig) ToMiddleware() (echo.MiddlewareFunc, error) {
 				c.Error(err)
 			}
 
+			latency := now().Sub(start)
+			if config.Sampler != nil {
+				status := res.Status
+				var httpErr *echo.HTTPError
+				if err != nil && !config.HandleError && errors.As(err, &httpErr) {
+					status = httpErr.Code
+				}
+				if !config.Sampler.Sample(status, err, latency) {
+					return err
+				}
+			}
+
 			v := RequestLoggerValues{
 				StartTime: start,
 			}
 			if config.LogLatency {
-				v.Latency = now().Sub(start)
+				v.Latency = latency
 			}
 			if config.LogProtocol {
 				v.Protocol = req.Proto
@@ -326,6 +352,13 @@ func (config RequestLoggerConfig) ToMiddleware() (echo.MiddlewareFunc, error) {
 				}
 				v.RequestID = id
 			}
+			if config.LogTraceID {
+				// Tracing middleware replaces request so span is looked up from the current request
+				if span := SpanFromContext(c.Request().Context()); span != nil {
+					v.TraceID = span.SpanContext.TraceID.String()
+					v.SpanID = span.SpanContext.SpanID.String()
+				}
+			}
 			if config.LogReferer {
 				v.Referer = req.Referer()
 			}
@@ -378,6 +411,14 @@ func (config RequestLoggerConfig) ToMiddleware() (echo.MiddlewareFunc, error) {
 				}
 			}
 
+			if config.Redactor != nil {
+				v.URI = config.Redactor.URI(v.URI)
+				v.Referer = config.Redactor.URI(v.Referer)
+				v.Headers = config.Redactor.HeaderValues(v.Headers)
+				v.QueryParams = config.Redactor.Values(v.QueryParams)
+				v.FormValues = config.Redactor.Values(v.FormValues)
+			}
+
 			if errOnLog := config.LogValuesFunc(c, v); errOnLog != nil {
 				return errOnLog
 			}
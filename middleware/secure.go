@@ -73,6 +73,14 @@ type SecureConfig struct {
 	// leaking potentially sensitive request paths to third parties.
 	// Optional. Default value "".
 	ReferrerPolicy string `yaml:"referrer_policy"`
+
+	// Policy is structured builder for `Content-Security-Policy`, `Permissions-Policy`,
+	// `Cross-Origin-Opener-Policy`, `Cross-Origin-Embedder-Policy`, `Referrer-Policy` and `Reporting-Endpoints`
+	// headers. Headers set by Policy take precedence over ContentSecurityPolicy and ReferrerPolicy fields. When
+	// CSP uses CSPNonceSource a new nonce is generated for each request and stored into context (see `CSPNonce()`).
+	// CSPReportOnly applies to Policy as well.
+	// Optional. Default value nil.
+	Policy *SecurityPolicy `yaml:"-"`
 }
 
 // DefaultSecureConfig is the default Secure middleware config.
@@ -99,6 +107,7 @@ func SecureWithConfig(config SecureConfig) echo.MiddlewareFunc { //NOSONAR
 	if config.Skipper == nil {
 		config.Skipper = DefaultSecureConfig.Skipper
 	}
+	usesNonce := config.Policy != nil && config.Policy.UsesNonce()
 
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -138,6 +147,14 @@ func SecureWithConfig(config SecureConfig) echo.MiddlewareFunc { //NOSONAR
 			if config.ReferrerPolicy != "" {
 				res.Header().Set(echo.HeaderReferrerPolicy, config.ReferrerPolicy)
 			}
+			if config.Policy != nil {
+				nonce := ""
+				if usesNonce {
+					nonce = generateCSPNonce()
+					c.Set(CSPNonceContextKey, nonce)
+				}
+				config.Policy.Apply(res.Header(), nonce, config.CSPReportOnly)
+			}
 			return next(c)
 		}
 	}
@@ -0,0 +1,394 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	echo "github.com/jialequ/agent"
+)
+
+// Content-Security-Policy source expressions
+const (
+	CSPSelf           = "'self'"
+	CSPNone           = "'none'"
+	CSPUnsafeInline   = "'unsafe-inline'"
+	CSPUnsafeEval     = "'unsafe-eval'"
+	CSPStrictDynamic  = "'strict-dynamic'"
+	CSPReportSample   = "'report-sample'"
+	CSPWasmUnsafeEval = "'wasm-unsafe-eval'"
+	// CSPNonceSource is a placeholder source that is replaced with `'nonce-<value>'` where value is cryptographic nonce
+	// generated for each request. Nonce can be read with `CSPNonce(c)`.
+	CSPNonceSource = "'nonce'"
+)
+
+// CSPNonceContextKey is the context key Secure middleware stores generated nonce into.
+const CSPNonceContextKey = "csp_nonce"
+
+// SecurityPolicy is a builder for security related response headers: `Content-Security-Policy`,
+// `Permissions-Policy`, `Cross-Origin-Opener-Policy`, `Cross-Origin-Embedder-Policy`, `Referrer-Policy` and
+// `Reporting-Endpoints`.
+//
+// Example:
+//
+//	policy := middleware.NewSecurityPolicy().
+//		CSP("default-src", middleware.CSPSelf).
+//		CSP("script-src", middleware.CSPSelf, middleware.CSPNonceSource).
+//		CSP("report-to", "csp").
+//		ReportingEndpoint("csp", "/csp-reports").
+//		Permission("geolocation").
+//		Permission("camera", "self", "https://video.example.com").
+//		CrossOriginOpenerPolicy("same-origin").
+//		ReferrerPolicy("strict-origin-when-cross-origin")
+//	e.Use(middleware.SecureWithConfig(middleware.SecureConfig{Policy: policy}))
+type SecurityPolicy struct {
+	csp                []policyDirective
+	permissions        []policyDirective
+	reportingEndpoints []policyDirective
+	coop               string
+	coep               string
+	referrerPolicy     string
+}
+
+type policyDirective struct {
+	name   string
+	values []string
+}
+
+// NewSecurityPolicy creates new empty SecurityPolicy.
+func NewSecurityPolicy() *SecurityPolicy {
+	return &SecurityPolicy{}
+}
+
+// CSP adds sources to Content-Security-Policy directive. Directives are rendered in order they were first added.
+// Directives without sources (i.e. `upgrade-insecure-requests`) are rendered as name only.
+func (p *SecurityPolicy) CSP(directive string, sources ...string) *SecurityPolicy {
+	p.csp = addPolicyDirective(p.csp, directive, sources)
+	return p
+}
+
+// Permission adds allowlist for Permissions-Policy feature. Allowlist values `self` and `*` are rendered as-is and
+// origins are quoted. Feature without allowlist is disabled for all origins, i.e. `geolocation=()`.
+func (p *SecurityPolicy) Permission(feature string, allowlist ...string) *SecurityPolicy {
+	p.permissions = addPolicyDirective(p.permissions, feature, allowlist)
+	return p
+}
+
+// ReportingEndpoint adds named endpoint to `Reporting-Endpoints` header. Name can be referenced by `report-to`
+// CSP directive.
+func (p *SecurityPolicy) ReportingEndpoint(name, url string) *SecurityPolicy {
+	p.reportingEndpoints = append(p.reportingEndpoints, policyDirective{name: name, values: []string{url}})
+	return p
+}
+
+// CrossOriginOpenerPolicy sets `Cross-Origin-Opener-Policy` header value (i.e. `same-origin`).
+func (p *SecurityPolicy) CrossOriginOpenerPolicy(value string) *SecurityPolicy {
+	p.coop = value
+	return p
+}
+
+// CrossOriginEmbedderPolicy sets `Cross-Origin-Embedder-Policy` header value (i.e. `require-corp`).
+func (p *SecurityPolicy) CrossOriginEmbedderPolicy(value string) *SecurityPolicy {
+	p.coep = value
+	return p
+}
+
+// ReferrerPolicy sets `Referrer-Policy` header value (i.e. `no-referrer`).
+func (p *SecurityPolicy) ReferrerPolicy(value string) *SecurityPolicy {
+	p.referrerPolicy = value
+	return p
+}
+
+// UsesNonce returns true when CSP contains CSPNonceSource placeholder.
+func (p *SecurityPolicy) UsesNonce() bool {
+	for _, d := range p.csp {
+		for _, v := range d.values {
+			if v == CSPNonceSource {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ContentSecurityPolicy returns `Content-Security-Policy` header value. CSPNonceSource placeholders are replaced with
+// given nonce.
+func (p *SecurityPolicy) ContentSecurityPolicy(nonce string) string {
+	buf := new(strings.Builder)
+	for i, d := range p.csp {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(d.name)
+		for _, v := range d.values {
+			buf.WriteByte(' ')
+			if v == CSPNonceSource {
+				buf.WriteString("'nonce-" + nonce + "'")
+				continue
+			}
+			buf.WriteString(v)
+		}
+	}
+	return buf.String()
+}
+
+// PermissionsPolicy returns `Permissions-Policy` header value.
+func (p *SecurityPolicy) PermissionsPolicy() string {
+	buf := new(strings.Builder)
+	for i, d := range p.permissions {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(d.name)
+		buf.WriteString("=(")
+		for j, v := range d.values {
+			if j > 0 {
+				buf.WriteByte(' ')
+			}
+			if v == "self" || v == "*" || strings.HasPrefix(v, `"`) {
+				buf.WriteString(v)
+			} else {
+				buf.WriteString(`"` + v + `"`)
+			}
+		}
+		buf.WriteByte(')')
+	}
+	return buf.String()
+}
+
+// ReportingEndpoints returns `Reporting-Endpoints` header value.
+func (p *SecurityPolicy) ReportingEndpoints() string {
+	buf := new(strings.Builder)
+	for i, d := range p.reportingEndpoints {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(d.name + `="` + d.values[0] + `"`)
+	}
+	return buf.String()
+}
+
+// Apply sets all configured headers to given header map. When reportOnly is true CSP is sent with
+// `Content-Security-Policy-Report-Only` header.
+func (p *SecurityPolicy) Apply(header http.Header, nonce string, reportOnly bool) {
+	if len(p.csp) > 0 {
+		if reportOnly {
+			header.Set(echo.HeaderContentSecurityPolicyReportOnly, p.ContentSecurityPolicy(nonce))
+		} else {
+			header.Set(echo.HeaderContentSecurityPolicy, p.ContentSecurityPolicy(nonce))
+		}
+	}
+	if len(p.permissions) > 0 {
+		header.Set(echo.HeaderPermissionsPolicy, p.PermissionsPolicy())
+	}
+	if len(p.reportingEndpoints) > 0 {
+		header.Set(echo.HeaderReportingEndpoints, p.ReportingEndpoints())
+	}
+	if p.coop != "" {
+		header.Set(echo.HeaderCrossOriginOpenerPolicy, p.coop)
+	}
+	if p.coep != "" {
+		header.Set(echo.HeaderCrossOriginEmbedderPolicy, p.coep)
+	}
+	if p.referrerPolicy != "" {
+		header.Set(echo.HeaderReferrerPolicy, p.referrerPolicy)
+	}
+}
+
+func addPolicyDirective(directives []policyDirective, name string, values []string) []policyDirective {
+	for i, d := range directives {
+		if d.name == name {
+			directives[i].values = append(d.values, values...)
+			return directives
+		}
+	}
+	return append(directives, policyDirective{name: name, values: append([]string(nil), values...)})
+}
+
+func generateCSPNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("unexpected error happened when reading from crypto/rand.Reader")
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// CSPNonce returns Content-Security-Policy nonce generated by Secure middleware for the current request. Empty
+// string is returned when no nonce was generated.
+func CSPNonce(c echo.Context) string {
+	nonce, _ := c.Get(CSPNonceContextKey).(string)
+	return nonce
+}
+
+// CSPTemplateFuncs returns template function `cspNonce` bound to the current request. It is meant to be used by
+// `echo.Renderer` implementations, i.e. `<script nonce="{{ cspNonce }}">`.
+func CSPTemplateFuncs(c echo.Context) template.FuncMap {
+	return template.FuncMap{
+		"cspNonce": func() string { return CSPNonce(c) },
+	}
+}
+
+// CSPReport is a normalized Content-Security-Policy violation report. It is created both from legacy
+// `application/csp-report` reports (`report-uri` directive) and from Reporting API `application/reports+json`
+// reports (`report-to` directive).
+type CSPReport struct {
+	// Type is the Reporting API report type. Legacy reports have type "csp-violation".
+	Type               string `json:"type"`
+	DocumentURI        string `json:"document_uri"`
+	Referrer           string `json:"referrer"`
+	BlockedURI         string `json:"blocked_uri"`
+	EffectiveDirective string `json:"effective_directive"`
+	OriginalPolicy     string `json:"original_policy"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"source_file"`
+	Sample             string `json:"sample"`
+	StatusCode         int    `json:"status_code"`
+	LineNumber         int    `json:"line_number"`
+	ColumnNumber       int    `json:"column_number"`
+	// UserAgent is set for Reporting API reports
+	UserAgent string `json:"user_agent"`
+	// Body is the raw report body. Useful for non CSP reports sent to Reporting API endpoint.
+	Body json.RawMessage `json:"body"`
+}
+
+// CSPReportHandlerFunc is called with reports received by CSP report endpoint.
+type CSPReportHandlerFunc func(c echo.Context, reports []CSPReport) error
+
+const cspReportMaxBodySize = 64 * 1024
+
+type legacyCSPReport struct {
+	Report struct {
+		DocumentURI        string `json:"document-uri"`
+		Referrer           string `json:"referrer"`
+		BlockedURI         string `json:"blocked-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		OriginalPolicy     string `json:"original-policy"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"source-file"`
+		ScriptSample       string `json:"script-sample"`
+		StatusCode         int    `json:"status-code"`
+		LineNumber         int    `json:"line-number"`
+		ColumnNumber       int    `json:"column-number"`
+	} `json:"csp-report"`
+}
+
+type reportingAPIReport struct {
+	Type      string          `json:"type"`
+	URL       string          `json:"url"`
+	UserAgent string          `json:"user_agent"`
+	Body      json.RawMessage `json:"body"`
+}
+
+type reportingAPICSPBody struct {
+	DocumentURL        string `json:"documentURL"`
+	Referrer           string `json:"referrer"`
+	BlockedURL         string `json:"blockedURL"`
+	EffectiveDirective string `json:"effectiveDirective"`
+	OriginalPolicy     string `json:"originalPolicy"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"sourceFile"`
+	Sample             string `json:"sample"`
+	StatusCode         int    `json:"statusCode"`
+	LineNumber         int    `json:"lineNumber"`
+	ColumnNumber       int    `json:"columnNumber"`
+}
+
+// CSPReportHandler returns handler that ingests CSP and Reporting API violation reports and forwards them to
+// callback. Responds with "204 - No Content" on success and "400 - Bad Request" for malformed reports.
+//
+// Example:
+//
+//	e.POST("/csp-reports", middleware.CSPReportHandler(func(c echo.Context, reports []middleware.CSPReport) error {
+//		for _, r := range reports {
+//			c.Logger().Warnf("csp violation: %s blocked %s", r.EffectiveDirective, r.BlockedURI)
+//		}
+//		return nil
+//	}))
+func CSPReportHandler(callback CSPReportHandlerFunc) echo.HandlerFunc {
+	if callback == nil {
+		panic("echo: csp report handler requires callback function")
+	}
+	return func(c echo.Context) error {
+		body, err := io.ReadAll(io.LimitReader(c.Request().Body, cspReportMaxBodySize))
+		if err != nil {
+			return echo.ErrBadRequest.WithInternal(err)
+		}
+		reports, err := parseCSPReports(c.Request().Header.Get(echo.HeaderContentType), body)
+		if err != nil {
+			return echo.ErrBadRequest.WithInternal(err)
+		}
+		if err := callback(c, reports); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+func parseCSPReports(contentType string, body []byte) ([]CSPReport, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "application/reports+json" {
+		legacy := legacyCSPReport{}
+		if err := json.Unmarshal(body, &legacy); err != nil {
+			return nil, err
+		}
+		r := legacy.Report
+		directive := r.EffectiveDirective
+		if directive == "" {
+			directive = r.ViolatedDirective
+		}
+		return []CSPReport{{
+			Type:               "csp-violation",
+			DocumentURI:        r.DocumentURI,
+			Referrer:           r.Referrer,
+			BlockedURI:         r.BlockedURI,
+			EffectiveDirective: directive,
+			OriginalPolicy:     r.OriginalPolicy,
+			Disposition:        r.Disposition,
+			SourceFile:         r.SourceFile,
+			Sample:             r.ScriptSample,
+			StatusCode:         r.StatusCode,
+			LineNumber:         r.LineNumber,
+			ColumnNumber:       r.ColumnNumber,
+			Body:               body,
+		}}, nil
+	}
+
+	raw := make([]reportingAPIReport, 0)
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	reports := make([]CSPReport, 0, len(raw))
+	for _, r := range raw {
+		report := CSPReport{Type: r.Type, DocumentURI: r.URL, UserAgent: r.UserAgent, Body: r.Body}
+		if r.Type == "csp-violation" {
+			b := reportingAPICSPBody{}
+			if err := json.Unmarshal(r.Body, &b); err != nil {
+				return nil, err
+			}
+			if b.DocumentURL != "" {
+				report.DocumentURI = b.DocumentURL
+			}
+			report.Referrer = b.Referrer
+			report.BlockedURI = b.BlockedURL
+			report.EffectiveDirective = b.EffectiveDirective
+			report.OriginalPolicy = b.OriginalPolicy
+			report.Disposition = b.Disposition
+			report.SourceFile = b.SourceFile
+			report.Sample = b.Sample
+			report.StatusCode = b.StatusCode
+			report.LineNumber = b.LineNumber
+			report.ColumnNumber = b.ColumnNumber
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
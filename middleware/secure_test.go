@@ -4,8 +4,10 @@
 package middleware
 
 import (
+	"html/template"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	echo "github.com/jialequ/agent"
@@ -93,4 +95,150 @@ func TestSecure(t *testing.T) {
 	assert.Equal(t, "max-age=3600; preload", rec.Header().Get(echo.HeaderStrictTransportSecurity))
 }
 
+func TestSecureWithPolicy(t *testing.T) {
+	policy := NewSecurityPolicy().
+		CSP("default-src", CSPSelf).
+		CSP("script-src", CSPSelf, CSPNonceSource).
+		CSP("upgrade-insecure-requests").
+		CSP("script-src", CSPStrictDynamic).
+		CSP("report-to", "csp").
+		ReportingEndpoint("csp", "/csp-reports").
+		Permission("geolocation").
+		Permission("camera", "self", "https://video.example.com").
+		CrossOriginOpenerPolicy("same-origin").
+		CrossOriginEmbedderPolicy("require-corp").
+		ReferrerPolicy("no-referrer")
+
+	e := echo.New()
+	e.Use(SecureWithConfig(SecureConfig{Policy: policy, ReferrerPolicy: "origin"}))
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, CSPNonce(c))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	nonce := rec.Body.String()
+	assert.NotEmpty(t, nonce)
+	assert.Equal(t,
+		"default-src 'self'; script-src 'self' 'nonce-"+nonce+"' 'strict-dynamic'; upgrade-insecure-requests; report-to csp",
+		rec.Header().Get(echo.HeaderContentSecurityPolicy),
+	)
+	assert.Equal(t, `geolocation=(), camera=(self "https://video.example.com")`, rec.Header().Get(echo.HeaderPermissionsPolicy))
+	assert.Equal(t, `csp="/csp-reports"`, rec.Header().Get(echo.HeaderReportingEndpoints))
+	assert.Equal(t, "same-origin", rec.Header().Get(echo.HeaderCrossOriginOpenerPolicy))
+	assert.Equal(t, "require-corp", rec.Header().Get(echo.HeaderCrossOriginEmbedderPolicy))
+	assert.Equal(t, "no-referrer", rec.Header().Get(echo.HeaderReferrerPolicy))
+
+	// nonce is different for every request
+	rec2 := httptest.NewRecorder()
+	e.ServeHTTP(rec2, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NotEqual(t, nonce, rec2.Body.String())
+}
+
+func TestSecureWithPolicyReportOnly(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := SecureWithConfig(SecureConfig{
+		Policy:        NewSecurityPolicy().CSP("default-src", CSPNone),
+		CSPReportOnly: true,
+	})(func(c echo.Context) error {
+		return nil
+	})
+	assert.NoError(t, h(c))
+	assert.Equal(t, "default-src 'none'", rec.Header().Get(echo.HeaderContentSecurityPolicyReportOnly))
+	assert.Equal(t, "", rec.Header().Get(echo.HeaderContentSecurityPolicy))
+	assert.Equal(t, "", CSPNonce(c))
+}
+
+func TestCSPTemplateFuncs(t *testing.T) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	c.Set(CSPNonceContextKey, "abc")
+
+	tmpl := template.Must(template.New("page").Funcs(CSPTemplateFuncs(c)).Parse(`<script nonce="{{ cspNonce }}"></script>`))
+	buf := new(strings.Builder)
+	assert.NoError(t, tmpl.Execute(buf, nil))
+	assert.Equal(t, `<script nonce="abc"></script>`, buf.String())
+}
+
+func TestCSPReportHandler(t *testing.T) {
+	var testCases = []struct {
+		name              string
+		givenContentType  string
+		givenBody         string
+		expectCode        int
+		expectReports     []CSPReport
+		expectHandlerCall bool
+	}{
+		{
+			name:             "ok, legacy report-uri report",
+			givenContentType: "application/csp-report",
+			givenBody:        `{"csp-report":{"document-uri":"https://example.com/","blocked-uri":"https://evil.com/x.js","violated-directive":"script-src","original-policy":"script-src 'self'","line-number":10}}`,
+			expectCode:       http.StatusNoContent,
+			expectReports: []CSPReport{{
+				Type:               "csp-violation",
+				DocumentURI:        "https://example.com/",
+				BlockedURI:         "https://evil.com/x.js",
+				EffectiveDirective: "script-src",
+				OriginalPolicy:     "script-src 'self'",
+				LineNumber:         10,
+			}},
+		},
+		{
+			name:             "ok, reporting api report",
+			givenContentType: "application/reports+json",
+			givenBody:        `[{"type":"csp-violation","url":"https://example.com/","user_agent":"test","body":{"documentURL":"https://example.com/page","blockedURL":"inline","effectiveDirective":"script-src-elem","disposition":"enforce","statusCode":200}},{"type":"deprecation","url":"https://example.com/","body":{"id":"x"}}]`,
+			expectCode:       http.StatusNoContent,
+			expectReports: []CSPReport{
+				{
+					Type:               "csp-violation",
+					DocumentURI:        "https://example.com/page",
+					BlockedURI:         "inline",
+					EffectiveDirective: "script-src-elem",
+					Disposition:        "enforce",
+					StatusCode:         200,
+					UserAgent:          "test",
+				},
+				{
+					Type:        "deprecation",
+					DocumentURI: "https://example.com/",
+				},
+			},
+		},
+		{
+			name:             "nok, malformed report",
+			givenContentType: "application/csp-report",
+			givenBody:        `{`,
+			expectCode:       http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var reports []CSPReport
+			e := echo.New()
+			e.POST("/csp", CSPReportHandler(func(c echo.Context, r []CSPReport) error {
+				reports = r
+				return nil
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/csp", strings.NewReader(tc.givenBody))
+			req.Header.Set(echo.HeaderContentType, tc.givenContentType)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			for i := range reports {
+				reports[i].Body = nil
+			}
+			assert.Equal(t, tc.expectReports, reports)
+		})
+	}
+}
+
 const literal_6172 = "default-src 'self'"
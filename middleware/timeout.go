@@ -111,7 +111,14 @@ func (config TimeoutConfig) ToMiddleware() (echo.MiddlewareFunc, error) {
 
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			if config.Skipper(c) || config.Timeout == 0 {
+			if config.Skipper(c) {
+				return next(c)
+			}
+			timeout := config.Timeout
+			if meta := echo.RouteMetaOf(c); meta != nil && meta.Timeout > 0 {
+				timeout = meta.Timeout
+			}
+			if timeout == 0 {
 				return next(c)
 			}
 
@@ -123,7 +130,7 @@ func (config TimeoutConfig) ToMiddleware() (echo.MiddlewareFunc, error) {
 				errChan:    errChan,
 				errHandler: config.OnTimeoutRouteErrorHandler,
 			}
-			handler := http.TimeoutHandler(handlerWrapper, config.Timeout, config.ErrorMessage)
+			handler := http.TimeoutHandler(handlerWrapper, timeout, config.ErrorMessage)
 			handler.ServeHTTP(handlerWrapper.writer, c.Request())
 
 			select {
@@ -0,0 +1,374 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	echo "github.com/jialequ/agent"
+)
+
+const (
+	// HeaderTraceparent is W3C Trace Context header carrying trace ID, parent span ID and trace flags.
+	// See: https://www.w3.org/TR/trace-context/#traceparent-header
+	HeaderTraceparent = "traceparent"
+	// HeaderTracestate is W3C Trace Context header carrying vendor specific trace data.
+	// See: https://www.w3.org/TR/trace-context/#tracestate-header
+	HeaderTracestate = "tracestate"
+)
+
+// ErrInvalidTraceparent denotes an error raised when `traceparent` header value is malformed.
+var ErrInvalidTraceparent = errors.New("invalid traceparent header value")
+
+// TraceID is W3C Trace Context trace identifier.
+type TraceID [16]byte
+
+// SpanID is W3C Trace Context span identifier.
+type SpanID [8]byte
+
+// String returns trace ID as lowercase hex string.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid checks if trace ID is not all zeroes.
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// String returns span ID as lowercase hex string.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid checks if span ID is not all zeroes.
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext identifies span within a trace and is propagated with `traceparent` and `tracestate` headers.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+}
+
+// Sampled returns true when sampled flag is set in trace flags.
+func (sc SpanContext) Sampled() bool {
+	return sc.Flags&0x01 == 0x01
+}
+
+// Traceparent returns `traceparent` header value for span context.
+func (sc SpanContext) Traceparent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+// ParseTraceparent parses `traceparent` header value. Versions other than `00` are accepted as long as they start with
+// fields known to version `00`.
+func ParseTraceparent(value string) (SpanContext, error) {
+	sc := SpanContext{}
+	value = strings.TrimSpace(value)
+	if len(value) < 55 || (len(value) > 55 && value[55] != '-') {
+		return sc, ErrInvalidTraceparent
+	}
+	if value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return sc, ErrInvalidTraceparent
+	}
+	version, err := decodeLowerHex(value[0:2])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(value) != 55) {
+		return sc, ErrInvalidTraceparent
+	}
+	traceID, err := decodeLowerHex(value[3:35])
+	if err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	spanID, err := decodeLowerHex(value[36:52])
+	if err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	flags, err := decodeLowerHex(value[53:55])
+	if err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Flags = flags[0]
+	if !sc.TraceID.IsValid() || !sc.SpanID.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	return sc, nil
+}
+
+func decodeLowerHex(s string) ([]byte, error) {
+	if strings.ToLower(s) != s {
+		return nil, ErrInvalidTraceparent
+	}
+	return hex.DecodeString(s)
+}
+
+// Span is a server span recorded for a request by Tracing middleware.
+type Span struct {
+	// Name is span name, request method and route template (i.e. `GET /users/:id`).
+	Name string `json:"name"`
+	// SpanContext identifies the span.
+	SpanContext SpanContext `json:"-"`
+	// ParentSpanID is span ID of the remote parent from `traceparent` header. Zero when request started new trace.
+	ParentSpanID SpanID `json:"-"`
+	// StartTime is time when span was started.
+	StartTime time.Time `json:"start_time"`
+	// EndTime is time when span was ended.
+	EndTime time.Time `json:"end_time"`
+	// Status is response status code.
+	Status int `json:"status"`
+	// Error is error returned from handler chain.
+	Error string `json:"error,omitempty"`
+	// Attributes are additional span attributes (method, route, URI etc.).
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Duration returns span duration.
+func (s *Span) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// MarshalJSON marshals span with IDs as hex strings.
+func (s *Span) MarshalJSON() ([]byte, error) {
+	type span Span // avoid recursion
+	out := struct {
+		TraceID      string `json:"trace_id"`
+		SpanID       string `json:"span_id"`
+		ParentSpanID string `json:"parent_span_id,omitempty"`
+		TraceFlags   byte   `json:"trace_flags"`
+		TraceState   string `json:"trace_state,omitempty"`
+		*span
+		DurationNano int64 `json:"duration_nano"`
+	}{
+		TraceID:      s.SpanContext.TraceID.String(),
+		SpanID:       s.SpanContext.SpanID.String(),
+		TraceFlags:   s.SpanContext.Flags,
+		TraceState:   s.SpanContext.TraceState,
+		span:         (*span)(s),
+		DurationNano: int64(s.Duration()),
+	}
+	if s.ParentSpanID.IsValid() {
+		out.ParentSpanID = s.ParentSpanID.String()
+	}
+	return json.Marshal(out)
+}
+
+type spanContextKey struct{}
+
+// SpanFromContext returns span stored in request context by Tracing middleware or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanContextKey{}).(*Span)
+	return s
+}
+
+// ContextWithSpan returns copy of context with given span stored in it.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// InjectTraceContext sets `traceparent` and `tracestate` headers for outgoing request so span stored in context is
+// the parent of spans created by the receiver. Does nothing when context has no span.
+func InjectTraceContext(ctx context.Context, header http.Header) {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	header.Set(HeaderTraceparent, span.SpanContext.Traceparent())
+	if span.SpanContext.TraceState != "" {
+		header.Set(HeaderTracestate, span.SpanContext.TraceState)
+	} else {
+		header.Del(HeaderTracestate)
+	}
+}
+
+// SpanExporter is the interface to be implemented by span exporters.
+type SpanExporter interface {
+	// ExportSpan exports ended span.
+	ExportSpan(span *Span) error
+}
+
+// InMemorySpanExporter stores exported spans in memory. Useful for tests.
+type InMemorySpanExporter struct {
+	mutex sync.Mutex
+	spans []*Span
+}
+
+// NewInMemorySpanExporter creates new InMemorySpanExporter.
+func NewInMemorySpanExporter() *InMemorySpanExporter {
+	return &InMemorySpanExporter{}
+}
+
+// ExportSpan stores span in memory.
+func (e *InMemorySpanExporter) ExportSpan(span *Span) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.spans = append(e.spans, span)
+	return nil
+}
+
+// Spans returns exported spans.
+func (e *InMemorySpanExporter) Spans() []*Span {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]*Span(nil), e.spans...)
+}
+
+// Reset removes all exported spans.
+func (e *InMemorySpanExporter) Reset() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.spans = nil
+}
+
+// JSONFileSpanExporter appends exported spans to file as JSON lines.
+type JSONFileSpanExporter struct {
+	mutex sync.Mutex
+	file  *os.File
+	enc   *json.Encoder
+}
+
+// NewJSONFileSpanExporter creates new JSONFileSpanExporter appending spans to file at given path. File is created
+// when it does not exist.
+func NewJSONFileSpanExporter(path string) (*JSONFileSpanExporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONFileSpanExporter{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+// ExportSpan appends span to file as JSON line.
+func (e *JSONFileSpanExporter) ExportSpan(span *Span) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.enc.Encode(span)
+}
+
+// Close closes underlying file.
+func (e *JSONFileSpanExporter) Close() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.file.Close()
+}
+
+// TracingConfig defines the config for Tracing middleware.
+type TracingConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// Exporter receives spans ended by the middleware. Spans of requests with incoming `traceparent` that has
+	// sampled flag cleared are not exported, their trace context is still propagated.
+	// Required.
+	Exporter SpanExporter
+
+	// IDGenerator generates random bytes for trace and span IDs.
+	// Optional. Default value reads from crypto/rand.
+	IDGenerator func(b []byte)
+
+	// DisableResponseHeaders disables sending `traceparent` and `tracestate` headers in response.
+	// Optional. Default value false.
+	DisableResponseHeaders bool
+}
+
+// DefaultTracingConfig is the default Tracing middleware config.
+var DefaultTracingConfig = TracingConfig{
+	Skipper:     DefaultSkipper,
+	IDGenerator: randomTraceBytes,
+}
+
+// Tracing returns a middleware that records a server span for each request and propagates W3C Trace Context.
+//
+// Span is stored in request context (see `SpanFromContext`) so Proxy middleware propagates it to upstream targets
+// and Logger and RequestLogger middlewares can log trace and span IDs.
+func Tracing(exporter SpanExporter) echo.MiddlewareFunc {
+	config := DefaultTracingConfig
+	config.Exporter = exporter
+	return TracingWithConfig(config)
+}
+
+// TracingWithConfig returns a Tracing middleware with config.
+// See: `Tracing()`.
+func TracingWithConfig(config TracingConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultTracingConfig.Skipper
+	}
+	if config.IDGenerator == nil {
+		config.IDGenerator = DefaultTracingConfig.IDGenerator
+	}
+	if config.Exporter == nil {
+		panic("echo: tracing middleware requires exporter")
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			span := &Span{StartTime: time.Now()}
+			if parent, err := ParseTraceparent(req.Header.Get(HeaderTraceparent)); err == nil {
+				span.SpanContext = parent
+				span.SpanContext.TraceState = req.Header.Get(HeaderTracestate)
+				span.ParentSpanID = parent.SpanID
+			} else {
+				for !span.SpanContext.TraceID.IsValid() {
+					config.IDGenerator(span.SpanContext.TraceID[:])
+				}
+				span.SpanContext.Flags = 0x01
+			}
+			for !span.SpanContext.SpanID.IsValid() || span.SpanContext.SpanID == span.ParentSpanID {
+				config.IDGenerator(span.SpanContext.SpanID[:])
+			}
+			c.SetRequest(req.WithContext(ContextWithSpan(req.Context(), span)))
+
+			if !config.DisableResponseHeaders {
+				InjectTraceContext(c.Request().Context(), c.Response().Header())
+			}
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+				span.Error = err.Error()
+			}
+
+			span.EndTime = time.Now()
+			span.Status = c.Response().Status
+			span.Name = req.Method + " " + c.Path()
+			span.Attributes = map[string]string{
+				"http.method": req.Method,
+				"http.route":  c.Path(),
+				"http.target": req.RequestURI,
+				"net.peer.ip": c.RealIP(),
+			}
+			if !span.SpanContext.Sampled() {
+				return nil
+			}
+			if expErr := config.Exporter.ExportSpan(span); expErr != nil {
+				c.Logger().Error(expErr)
+			}
+			return nil
+		}
+	}
+}
+
+func randomTraceBytes(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic("unexpected error happened when reading from crypto/rand.Reader")
+	}
+}
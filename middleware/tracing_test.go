@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	echo "github.com/jialequ/agent"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceparent(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		whenValue   string
+		expectError bool
+	}{
+		{name: "ok", whenValue: testTraceparent},
+		{name: "ok, future version with extra fields", whenValue: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{name: "nok, version 00 with extra fields", whenValue: testTraceparent + "-extra", expectError: true},
+		{name: "nok, version ff", whenValue: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", expectError: true},
+		{name: "nok, uppercase", whenValue: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", expectError: true},
+		{name: "nok, zero trace id", whenValue: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", expectError: true},
+		{name: "nok, zero span id", whenValue: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", expectError: true},
+		{name: "nok, too short", whenValue: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", expectError: true},
+		{name: "nok, empty", whenValue: "", expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sc, err := ParseTraceparent(tc.whenValue)
+			if tc.expectError {
+				assert.ErrorIs(t, err, ErrInvalidTraceparent)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+			assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+			assert.True(t, sc.Sampled())
+		})
+	}
+}
+
+func TestTracing(t *testing.T) {
+	exporter := NewInMemorySpanExporter()
+	e := echo.New()
+	e.Use(Tracing(exporter))
+	e.GET("/users/:id", func(c echo.Context) error {
+		span := SpanFromContext(c.Request().Context())
+		assert.NotNil(t, span)
+		return c.String(http.StatusOK, "user")
+	})
+	e.GET("/error", func(c echo.Context) error {
+		return errors.New("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(HeaderTraceparent, testTraceparent)
+	req.Header.Set(HeaderTracestate, "vendor=value")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	spans := exporter.Spans()
+	if assert.Len(t, spans, 1) {
+		span := spans[0]
+		assert.Equal(t, "GET /users/:id", span.Name)
+		assert.Equal(t, http.StatusOK, span.Status)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID.String())
+		assert.Equal(t, "00f067aa0ba902b7", span.ParentSpanID.String())
+		assert.NotEqual(t, span.ParentSpanID, span.SpanContext.SpanID)
+		assert.Equal(t, "vendor=value", span.SpanContext.TraceState)
+		assert.False(t, span.EndTime.Before(span.StartTime))
+
+		assert.Equal(t, span.SpanContext.Traceparent(), rec.Header().Get(HeaderTraceparent))
+		assert.Equal(t, "vendor=value", rec.Header().Get(HeaderTracestate))
+	}
+
+	exporter.Reset()
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/error", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	spans = exporter.Spans()
+	if assert.Len(t, spans, 1) {
+		span := spans[0]
+		assert.Equal(t, "boom", span.Error)
+		assert.Equal(t, http.StatusInternalServerError, span.Status)
+		assert.True(t, span.SpanContext.TraceID.IsValid())
+		assert.False(t, span.ParentSpanID.IsValid())
+	}
+}
+
+func TestTracingNotSampled(t *testing.T) {
+	exporter := NewInMemorySpanExporter()
+	e := echo.New()
+	e.Use(Tracing(exporter))
+	e.GET("/", func(c echo.Context) error {
+		span := SpanFromContext(c.Request().Context())
+		assert.NotNil(t, span)
+		return c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, exporter.Spans())
+	sc, err := ParseTraceparent(rec.Header().Get(HeaderTraceparent))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+		assert.False(t, sc.Sampled())
+	}
+}
+
+func TestTracingRequiresExporter(t *testing.T) {
+	assert.PanicsWithValue(t, "echo: tracing middleware requires exporter", func() {
+		TracingWithConfig(TracingConfig{})
+	})
+}
+
+func TestTracingPropagatesToProxyAndLoggers(t *testing.T) {
+	var upstreamTraceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get(HeaderTraceparent)
+	}))
+	defer upstream.Close()
+	upstreamURL, _ := url.Parse(upstream.URL)
+
+	buf := new(bytes.Buffer)
+	var logged RequestLoggerValues
+	exporter := NewInMemorySpanExporter()
+
+	e := echo.New()
+	e.Use(LoggerWithConfig(LoggerConfig{Format: "${trace_id} ${span_id}", Output: buf}))
+	e.Use(RequestLoggerWithConfig(RequestLoggerConfig{
+		LogTraceID: true,
+		LogValuesFunc: func(c echo.Context, v RequestLoggerValues) error {
+			logged = v
+			return nil
+		},
+	}))
+	e.Use(Tracing(exporter))
+	e.Use(Proxy(NewRoundRobinBalancer([]*ProxyTarget{{URL: upstreamURL}})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderTraceparent, testTraceparent)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	spans := exporter.Spans()
+	if assert.Len(t, spans, 1) {
+		sc := spans[0].SpanContext
+		assert.Equal(t, sc.Traceparent(), upstreamTraceparent)
+		assert.Equal(t, sc.TraceID.String()+" "+sc.SpanID.String(), buf.String())
+		assert.Equal(t, sc.TraceID.String(), logged.TraceID)
+		assert.Equal(t, sc.SpanID.String(), logged.SpanID)
+	}
+}
+
+func TestJSONFileSpanExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.json")
+	exporter, err := NewJSONFileSpanExporter(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	e := echo.New()
+	e.Use(Tracing(exporter))
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderTraceparent, testTraceparent)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	assert.NoError(t, exporter.Close())
+
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if assert.Len(t, lines, 1) {
+		span := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &span))
+		assert.Equal(t, "GET /", span["name"])
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span["trace_id"])
+		assert.Equal(t, "00f067aa0ba902b7", span["parent_span_id"])
+		assert.Equal(t, float64(http.StatusNoContent), span["status"])
+	}
+}
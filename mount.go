@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	stdContext "context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// mountPoint links routes registered by `Mount()` to the mounted Echo instance so its routes can be listed by
+// the parent `Echo#Routes()`. Mount points are kept in `Echo.mounts` by route.
+type mountPoint struct {
+	prefix string
+	echo   *Echo
+}
+
+type mountParamsKey struct{}
+
+// mountParams are path params collected from mount points the request has passed through. The innermost mount
+// point params come first.
+type mountParams struct {
+	names  []string
+	values []string
+}
+
+// Mount mounts an independent application under the prefix and optional route-level middleware. Application can
+// be another `*Echo` instance (with its own middleware, error handler, binder, renderer etc.) or any `http.Handler`
+// (i.e. `http.FileServer` or pprof handlers).
+//
+// Prefix is stripped from the request path before the request is passed to the mounted application. Path params of
+// the prefix are available with `Context#Param()` in the mounted Echo instance and with `MountParam()` in plain
+// `http.Handler`s. Routes of the mounted Echo instance are listed by `Echo#Routes()` with prefix prepended.
+//
+// Example:
+//
+//	admin := echo.New()
+//	admin.GET("/users", listUsers)
+//	e.Mount("/tenants/:tenant/admin", admin)
+//	e.Mount("/debug/pprof", http.DefaultServeMux)
+func (e *Echo) Mount(prefix string, h http.Handler, m ...MiddlewareFunc) []*Route {
+	return e.mount(prefix, h, e.Any, m...)
+}
+
+func (e *Echo) mount(prefix string, h http.Handler, anyMethod func(string, HandlerFunc, ...MiddlewareFunc) []*Route,
+	m ...MiddlewareFunc) []*Route {
+	prefix = strings.TrimSuffix(prefix, "/")
+	handler := mountHandler(h)
+
+	routes := anyMethod(prefix+"/*", handler, m...)
+	if prefix != "" {
+		routes = append(anyMethod(prefix, handler, m...), routes...)
+	}
+
+	if sub, ok := h.(*Echo); ok {
+		mp := &mountPoint{
+			prefix: strings.TrimSuffix(routes[len(routes)-1].Path, "/*"),
+			echo:   sub,
+		}
+		for _, r := range routes {
+			e.mounts.Store(r, mp)
+		}
+	}
+	return routes
+}
+
+// mountHandler strips matched prefix from the request path, stores path params of the mount point in the request
+// context and passes the request to the mounted handler.
+func mountHandler(h http.Handler) HandlerFunc {
+	return func(c Context) error {
+		req := c.Request()
+		rest := "/" + c.Param("*")
+
+		params := &mountParams{}
+		for i, name := range c.ParamNames() {
+			if name == "*" {
+				continue
+			}
+			params.names = append(params.names, name)
+			params.values = append(params.values, c.ParamValues()[i])
+		}
+		if outer, ok := req.Context().Value(mountParamsKey{}).(*mountParams); ok {
+			params.names = append(params.names, outer.names...)
+			params.values = append(params.values, outer.values...)
+		}
+
+		r := req.WithContext(stdContext.WithValue(req.Context(), mountParamsKey{}, params))
+		u := *req.URL
+		u.Path = rest
+		if u.RawPath != "" {
+			// router matched against the escaped path so the wildcard value is escaped too
+			u.RawPath = rest
+			if p, err := url.PathUnescape(rest); err == nil {
+				u.Path = p
+			}
+		}
+		r.URL = &u
+
+		h.ServeHTTP(c.Response(), r)
+		return nil
+	}
+}
+
+// MountParam returns the value of the path param from the prefix the request was mounted under with `Mount()`.
+// Returns empty string when there is no such param.
+func MountParam(r *http.Request, name string) string {
+	if r == nil {
+		return ""
+	}
+	if params, ok := r.Context().Value(mountParamsKey{}).(*mountParams); ok {
+		for i, n := range params.names {
+			if n == name {
+				return params.values[i]
+			}
+		}
+	}
+	return ""
+}
+
+// mountedRoutes replaces routes registered by mounting Echo instances with routes of the mounted instances.
+func (e *Echo) mountedRoutes(routes []*Route) []*Route {
+	result := make([]*Route, 0, len(routes))
+	seen := map[*mountPoint]bool{}
+	for _, r := range routes {
+		v, ok := e.mounts.Load(r)
+		if !ok {
+			result = append(result, r)
+			continue
+		}
+		mp := v.(*mountPoint)
+		if seen[mp] {
+			continue
+		}
+		seen[mp] = true
+		for _, sr := range mp.echo.Routes() {
+			mr := *sr
+			mr.Path = mp.prefix + sr.Path
+			result = append(result, &mr)
+		}
+	}
+	return result
+}
+
+// mountedMeta returns metadata of the route listed by `Echo#Routes()` for a mounted Echo instance. These routes are
+// copies with the mount prefix prepended, so metadata is looked up on the mounted instance owning the route.
+// Returns nil when the route does not belong to any mounted instance.
+func (e *Echo) mountedMeta(route *Route) *RouteMeta {
+	var meta *RouteMeta
+	seen := map[*mountPoint]bool{}
+	e.mounts.Range(func(_, v interface{}) bool {
+		mp := v.(*mountPoint)
+		if seen[mp] {
+			return true
+		}
+		seen[mp] = true
+		if !strings.HasPrefix(route.Path, mp.prefix) {
+			return true
+		}
+		for _, sr := range mp.echo.Routes() {
+			if sr.Method == route.Method && sr.Name == route.Name && mp.prefix+sr.Path == route.Path {
+				meta = mp.echo.Meta(sr)
+				return false
+			}
+		}
+		return true
+	})
+	return meta
+}
@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEchoMountEcho(t *testing.T) {
+	admin := New()
+	admin.HTTPErrorHandler = func(err error, c Context) {
+		_ = c.String(http.StatusTeapot, "admin: "+err.Error())
+	}
+	admin.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "admin index")
+	})
+	admin.GET("/users/:id", func(c Context) error {
+		return c.String(http.StatusOK, c.Param("tenant")+"/"+c.Param("id")+" "+c.Request().URL.Path)
+	})
+	admin.GET("/fail", func(c Context) error {
+		return errors.New("failed")
+	})
+
+	e := New()
+	e.Mount("/tenants/:tenant/admin", admin)
+	e.GET("/tenants/:tenant", func(c Context) error {
+		return c.String(http.StatusOK, "tenant "+c.Param("tenant"))
+	})
+
+	var testCases = []struct {
+		name       string
+		whenURL    string
+		expectCode int
+		expectBody string
+	}{
+		{
+			name:       "ok, mounted route with prefix params",
+			whenURL:    "/tenants/acme/admin/users/1",
+			expectCode: http.StatusOK,
+			expectBody: "acme/1 /users/1",
+		},
+		{
+			name:       "ok, prefix without trailing slash",
+			whenURL:    "/tenants/acme/admin",
+			expectCode: http.StatusOK,
+			expectBody: "admin index",
+		},
+		{
+			name:       "ok, prefix with trailing slash",
+			whenURL:    "/tenants/acme/admin/",
+			expectCode: http.StatusOK,
+			expectBody: "admin index",
+		},
+		{
+			name:       "ok, parent route",
+			whenURL:    "/tenants/acme",
+			expectCode: http.StatusOK,
+			expectBody: "tenant acme",
+		},
+		{
+			name:       "nok, mounted instance error handler is used",
+			whenURL:    "/tenants/acme/admin/fail",
+			expectCode: http.StatusTeapot,
+			expectBody: "admin: failed",
+		},
+		{
+			name:       "nok, mounted instance 404",
+			whenURL:    "/tenants/acme/admin/nope",
+			expectCode: http.StatusTeapot,
+			expectBody: "admin: code=404, message=Not Found",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.whenURL, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			assert.Equal(t, tc.expectBody, rec.Body.String())
+		})
+	}
+}
+
+func TestEchoMountHandler(t *testing.T) {
+	e := New()
+	var middlewareCalled bool
+	g := e.Group("/tenants/:tenant")
+	g.Mount("/files/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(MountParam(r, "tenant") + " " + r.URL.Path + " " + r.URL.RawPath))
+	}), func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			middlewareCalled = true
+			return next(c)
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/tenants/acme/files/a%2Fb/c.txt", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.True(t, middlewareCalled)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "acme /a/b/c.txt /a%2Fb/c.txt", rec.Body.String())
+}
+
+func TestEchoMountNested(t *testing.T) {
+	inner := New()
+	inner.GET("/items/:id", func(c Context) error {
+		return c.String(http.StatusOK, c.Param("org")+" "+c.Param("project")+" "+c.Param("id"))
+	})
+	middle := New()
+	middle.Mount("/projects/:project", inner)
+	e := New()
+	e.Mount("/orgs/:org", middle)
+
+	req := httptest.NewRequest(http.MethodGet, "/orgs/o1/projects/p1/items/i1", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "o1 p1 i1", rec.Body.String())
+}
+
+func TestEchoMountRoutes(t *testing.T) {
+	h := func(c Context) error { return nil }
+	inner := New()
+	inner.GET("/items/:id", h)
+	sub := New()
+	sub.GET("/users", h)
+	sub.POST("/users", h)
+	sub.Mount("/inner", inner)
+
+	e := New()
+	e.GET("/health", h)
+	e.Mount("/api/", sub)
+	fileRoutes := e.Mount("/files", http.NotFoundHandler())
+	assert.Len(t, fileRoutes, 2*len(methods))
+
+	paths := make([]string, 0)
+	for _, r := range e.Routes() {
+		if r.Method == http.MethodGet {
+			paths = append(paths, r.Path)
+		}
+	}
+	sort.Strings(paths)
+	assert.Equal(t, []string{"/api/inner/items/:id", "/api/users", "/files", "/files/*", "/health"}, paths)
+}
+
+func TestEchoMountRoutesMeta(t *testing.T) {
+	h := func(c Context) error { return nil }
+	inner := New()
+	inner.Meta(inner.GET("/items/:id", h)).RequireScopes("items:read")
+	sub := New()
+	sub.Meta(sub.POST("/users", h)).Describe("creates user")
+	sub.Mount("/inner", inner)
+
+	e := New()
+	e.GET("/health", h)
+	e.Mount("/api", sub)
+
+	scopes := map[string][]string{}
+	descriptions := map[string]string{}
+	for _, r := range e.Routes() {
+		meta := e.Meta(r)
+		scopes[r.Method+" "+r.Path] = meta.Scopes
+		descriptions[r.Method+" "+r.Path] = meta.Description
+	}
+	assert.Equal(t, []string{"items:read"}, scopes["GET /api/inner/items/:id"])
+	assert.Equal(t, "creates user", descriptions["POST /api/users"])
+	assert.Nil(t, scopes["GET /health"])
+
+	// metadata set through parent is kept by mounted instance owning the route
+	for _, r := range e.Routes() {
+		if r.Method == http.MethodPost && r.Path == "/api/users" {
+			e.Meta(r).Tag("users")
+		}
+	}
+	for _, r := range sub.Routes() {
+		if r.Method == http.MethodPost && r.Path == "/users" {
+			assert.Equal(t, []string{"users"}, sub.Meta(r).Tags)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	"strings"
+)
+
+// PathNormalizationConfig defines how request path is normalized before it is matched by the router. Request keeps
+// the original path, only routing uses the normalized path.
+type PathNormalizationConfig struct {
+	// CaseInsensitive matches path to routes case-insensitively (ASCII letters only). Param values keep the case
+	// they have in the request path and param constraints are checked against these values (i.e. `:code<[A-Z]+>`
+	// matches `/users/ABC` but not `/users/abc`).
+	// Routes added before this flag is changed are re-indexed before the first request is served, so the flag can be
+	// set before or after routes are added, but not after the server has started. Routes that differ only by case
+	// of static parts are the same route when matching is case-insensitive - the last added one is used.
+	CaseInsensitive bool
+
+	// CollapseSlashes replaces multiple consecutive slashes with a single slash. i.e. `/users//1` matches
+	// `/users/:id`.
+	CollapseSlashes bool
+
+	// CleanDotSegments removes `.` and `..` path segments. i.e. `/users/./1` and `/users/x/../1` match `/users/:id`.
+	CleanDotSegments bool
+
+	// RedirectCode is status code (i.e. `http.StatusMovedPermanently`) used to redirect to the normalized path
+	// instead of routing it silently. Only slash and dot segment changes are redirected, case is matched without
+	// redirect. Zero value means no redirect.
+	RedirectCode int
+}
+
+// routePath returns path used by the router and path param values are taken from. Normalized is true when slashes
+// or dot segments were changed.
+func (config PathNormalizationConfig) routePath(path string) (routePath string, values string, normalized bool) {
+	values = path
+	if config.CollapseSlashes || config.CleanDotSegments {
+		values = normalizePath(path, config.CollapseSlashes, config.CleanDotSegments)
+	}
+	routePath = values
+	if config.CaseInsensitive {
+		routePath = toLowerASCII(values)
+	}
+	return routePath, values, values != path
+}
+
+// normalizePath collapses slashes and removes dot segments from path. Trailing slash is preserved.
+func normalizePath(path string, collapseSlashes bool, cleanDots bool) string {
+	if path == "" {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	result := make([]string, 0, len(segments))
+	last := len(segments) - 1
+	for i, s := range segments {
+		switch {
+		case i == 0:
+			result = append(result, s) // part before the leading slash (empty for absolute path)
+		case cleanDots && s == ".":
+			if i == last {
+				result = append(result, "")
+			}
+		case cleanDots && s == "..":
+			if len(result) > 1 {
+				result = result[:len(result)-1]
+			}
+			if i == last {
+				result = append(result, "")
+			}
+		case collapseSlashes && s == "" && i != last:
+			// empty segment between two slashes
+		default:
+			result = append(result, s)
+		}
+	}
+	return strings.Join(result, "/")
+}
+
+func toLowerASCII(s string) string {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; 'A' <= c && c <= 'Z' {
+			b := []byte(s)
+			for j := i; j < len(b); j++ {
+				if 'A' <= b[j] && b[j] <= 'Z' {
+					b[j] += 'a' - 'A'
+				}
+			}
+			return string(b)
+		}
+	}
+	return s
+}
@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePath(t *testing.T) {
+	var testCases = []struct {
+		whenPath   string
+		whenSlash  bool
+		whenDots   bool
+		expectPath string
+	}{
+		{whenPath: "/users//1", whenSlash: true, expectPath: "/users/1"},
+		{whenPath: "//users///1//", whenSlash: true, expectPath: "/users/1/"},
+		{whenPath: "/users//1", whenDots: true, expectPath: "/users//1"},
+		{whenPath: "/users/./1", whenDots: true, expectPath: "/users/1"},
+		{whenPath: "/users/x/../1", whenDots: true, expectPath: "/users/1"},
+		{whenPath: "/users/1/.", whenDots: true, expectPath: "/users/1/"},
+		{whenPath: "/users/1/x/..", whenDots: true, expectPath: "/users/1/"},
+		{whenPath: "/../../a", whenDots: true, expectPath: "/a"},
+		{whenPath: "/a/..", whenDots: true, expectPath: "/"},
+		{whenPath: "/a/.../b", whenDots: true, expectPath: "/a/.../b"},
+		{whenPath: "//a/./b//../c", whenSlash: true, whenDots: true, expectPath: "/a/c"},
+		{whenPath: "/", whenSlash: true, whenDots: true, expectPath: "/"},
+		{whenPath: "", whenSlash: true, whenDots: true, expectPath: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.whenPath, func(t *testing.T) {
+			assert.Equal(t, tc.expectPath, normalizePath(tc.whenPath, tc.whenSlash, tc.whenDots))
+		})
+	}
+}
+
+func TestEchoPathNormalization(t *testing.T) {
+	e := New()
+	e.PathNormalization = PathNormalizationConfig{
+		CaseInsensitive:  true,
+		CollapseSlashes:  true,
+		CleanDotSegments: true,
+	}
+	e.GET("/Users/:userID", func(c Context) error {
+		return c.String(http.StatusOK, c.Path()+" "+c.Param("userID")+" "+c.Request().URL.Path)
+	})
+	e.GET("/files/*", func(c Context) error {
+		return c.String(http.StatusOK, c.Param("*"))
+	})
+
+	var testCases = []struct {
+		name       string
+		whenURL    string
+		expectCode int
+		expectBody string
+	}{
+		{
+			name:       "ok, exact path",
+			whenURL:    "/Users/JohnDoe",
+			expectCode: http.StatusOK,
+			expectBody: "/Users/:userID JohnDoe /Users/JohnDoe",
+		},
+		{
+			name:       "ok, case folded, param keeps case",
+			whenURL:    "/USERS/JohnDoe",
+			expectCode: http.StatusOK,
+			expectBody: "/Users/:userID JohnDoe /USERS/JohnDoe",
+		},
+		{
+			name:       "ok, duplicate slashes",
+			whenURL:    "//users//JohnDoe",
+			expectCode: http.StatusOK,
+			expectBody: "/Users/:userID JohnDoe //users//JohnDoe",
+		},
+		{
+			name:       "ok, dot segments",
+			whenURL:    "/users/./x/../JohnDoe",
+			expectCode: http.StatusOK,
+			expectBody: "/Users/:userID JohnDoe /users/./x/../JohnDoe",
+		},
+		{
+			name:       "ok, any param keeps case",
+			whenURL:    "/FILES/a//B.txt",
+			expectCode: http.StatusOK,
+			expectBody: "a/B.txt",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.URL.Path = tc.whenURL
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			assert.Equal(t, tc.expectBody, rec.Body.String())
+		})
+	}
+
+	result := e.Lookup(http.MethodGet, "/users//./JohnDoe")
+	assert.True(t, result.Found)
+	assert.Equal(t, []string{"JohnDoe"}, result.ParamValues)
+
+	assert.True(t, e.RemoveRoute(http.MethodGet, "/Users/:userID"))
+	assert.False(t, e.Lookup(http.MethodGet, "/users/1").Found)
+}
+
+func TestEchoPathNormalizationRedirect(t *testing.T) {
+	e := New()
+	e.PathNormalization = PathNormalizationConfig{
+		CaseInsensitive:  true,
+		CollapseSlashes:  true,
+		CleanDotSegments: true,
+		RedirectCode:     http.StatusMovedPermanently,
+	}
+	e.GET("/users/:id", func(c Context) error {
+		return c.String(http.StatusOK, c.Param("id"))
+	})
+
+	var testCases = []struct {
+		name           string
+		whenPath       string
+		whenQuery      string
+		expectCode     int
+		expectLocation string
+	}{
+		{
+			name:       "ok, canonical path is not redirected",
+			whenPath:   "/users/1",
+			expectCode: http.StatusOK,
+		},
+		{
+			name:       "ok, case is not redirected",
+			whenPath:   "/USERS/1",
+			expectCode: http.StatusOK,
+		},
+		{
+			name:           "ok, redirect with query",
+			whenPath:       "/users//./1",
+			whenQuery:      "a=b",
+			expectCode:     http.StatusMovedPermanently,
+			expectLocation: "/users/1?a=b",
+		},
+		{
+			name:           "ok, no protocol relative redirect",
+			whenPath:       "//evil.com/./x",
+			expectCode:     http.StatusMovedPermanently,
+			expectLocation: "/evil.com/x",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.URL.Path = tc.whenPath
+			req.URL.RawQuery = tc.whenQuery
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			assert.Equal(t, tc.expectLocation, rec.Header().Get(HeaderLocation))
+		})
+	}
+
+	e.PathNormalization.CollapseSlashes = false
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.URL.Path = "//evil.com/./x"
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "/evil.com/x", rec.Header().Get(HeaderLocation))
+}
+
+func TestEchoPathNormalizationCheckRoute(t *testing.T) {
+	e := New()
+	e.PathNormalization.CaseInsensitive = true
+	e.GET("/Users/:id", func(c Context) error { return nil })
+
+	assert.ErrorIs(t, e.Router().CheckRoute(http.MethodGet, "/users/:name"), ErrRouteConflict)
+}
+
+func TestEchoPathNormalizationCaseInsensitiveSetAfterRoutes(t *testing.T) {
+	e := New()
+	e.GET("/Users/:id", func(c Context) error {
+		return c.String(http.StatusOK, c.Path()+" "+c.Param("id"))
+	})
+	e.RouteNotFound("/Admin/*", func(c Context) error {
+		return c.String(http.StatusNotFound, "admin")
+	})
+	e.PathNormalization.CaseInsensitive = true
+	e.GET("/Files/:name", func(c Context) error {
+		return c.String(http.StatusOK, c.Path()+" "+c.Param("name"))
+	})
+
+	var testCases = []struct {
+		whenURL    string
+		expectCode int
+		expectBody string
+	}{
+		{whenURL: "/USERS/Joe", expectCode: http.StatusOK, expectBody: "/Users/:id Joe"},
+		{whenURL: "/files/A.txt", expectCode: http.StatusOK, expectBody: "/Files/:name A.txt"},
+		{whenURL: "/admin/x", expectCode: http.StatusNotFound, expectBody: "admin"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.whenURL, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.whenURL, nil))
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			assert.Equal(t, tc.expectBody, rec.Body.String())
+		})
+	}
+}
+
+func TestEchoPathNormalizationCaseInsensitiveConstraint(t *testing.T) {
+	e := New()
+	e.PathNormalization.CaseInsensitive = true
+	e.GET("/codes/:code<[A-Z]+>", func(c Context) error {
+		return c.String(http.StatusOK, c.Param("code"))
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/CODES/ABC", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ABC", rec.Body.String())
+
+	// constraint is checked against value as it is in the request path
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/codes/abc", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,431 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	"bufio"
+	"bytes"
+	stdContext "context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProxyProtocolConfig defines the config for HAProxy PROXY protocol listener.
+// See: https://www.haproxy.org/download/2.8/doc/proxy-protocol.txt
+type ProxyProtocolConfig struct {
+	// TrustedProxies is list of IP ranges of proxies that are allowed to send PROXY header. Connections from other
+	// addresses are served as is and their PROXY header (if any) is not parsed.
+	// Optional. Default value (empty) trusts all sources - use only when listener is not reachable directly.
+	TrustedProxies []*net.IPNet
+
+	// Required rejects connections from trusted proxies that do not start with PROXY header.
+	// Optional. Default value false.
+	Required bool
+
+	// ReadHeaderTimeout is maximum duration to wait for PROXY header. When header is not Required, connection that
+	// sends nothing within the timeout is not failed but served as connection without header, and header starting
+	// after the timeout is not parsed. Read deadline set by the server (i.e. `http.Server.ReadHeaderTimeout`) is
+	// respected and restored once header has been read.
+	// Optional. Default value 10 seconds.
+	ReadHeaderTimeout time.Duration
+}
+
+// ProxyProtocolHeader is the parsed PROXY protocol header of a connection.
+type ProxyProtocolHeader struct {
+	// Version is PROXY protocol version (1 or 2).
+	Version int
+	// Local is true for connections established by proxy itself (i.e. health checks). Source and destination
+	// addresses are not set for local connections.
+	Local bool
+	// SourceAddr is address of the client connected to the proxy.
+	SourceAddr net.Addr
+	// DestinationAddr is address of the proxy the client connected to.
+	DestinationAddr net.Addr
+	// TLS is true when client connected to the proxy over TLS (v2 PP2_TYPE_SSL TLV).
+	TLS bool
+	// TLVs contains raw type-length-value vectors of v2 header.
+	TLVs map[byte][]byte
+}
+
+const (
+	proxyProtocolV1MaxLength = 107
+
+	proxyProtocolV2TypeSSL      = 0x20
+	proxyProtocolV2ClientSSL    = 0x01
+	proxyProtocolV2CommandLocal = 0x0
+	proxyProtocolV2CommandProxy = 0x1
+)
+
+var proxyProtocolV2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+// Errors
+var (
+	ErrProxyProtocolHeaderMissing = errors.New("proxy protocol: header missing")
+	ErrProxyProtocolInvalidHeader = errors.New("proxy protocol: invalid header")
+)
+
+type proxyProtocolContextKey struct{}
+
+// NewProxyProtocolListener wraps listener so that accepted connections have their PROXY protocol header parsed.
+// Header is read lazily on first `Read`/`RemoteAddr`/`LocalAddr` call so slow clients do not block `Accept`.
+// `RemoteAddr` of the connection returns address of the client connected to the proxy.
+//
+// Example: `e.Listener = echo.NewProxyProtocolListener(l, echo.ProxyProtocolConfig{})`
+func NewProxyProtocolListener(l net.Listener, config ProxyProtocolConfig) net.Listener {
+	if config.ReadHeaderTimeout == 0 {
+		config.ReadHeaderTimeout = 10 * time.Second
+	}
+	return &proxyProtocolListener{Listener: l, config: config}
+}
+
+func (e *Echo) wrapProxyProtocol(l net.Listener) net.Listener {
+	if e.ProxyProtocol == nil {
+		return l
+	}
+	return NewProxyProtocolListener(l, *e.ProxyProtocol)
+}
+
+// ProxyProtocolConnContext stores PROXY protocol connection in context so that header can later be retrieved with
+// `ProxyProtocolHeaderFromContext`. Echo sets it as `http.Server.ConnContext` or calls it before `ConnContext` set by
+// the caller.
+func ProxyProtocolConnContext(ctx stdContext.Context, c net.Conn) stdContext.Context {
+	if tlsConn, ok := c.(*tls.Conn); ok {
+		c = tlsConn.NetConn()
+	}
+	if ppConn, ok := c.(*proxyProtocolConn); ok {
+		return stdContext.WithValue(ctx, proxyProtocolContextKey{}, ppConn)
+	}
+	return ctx
+}
+
+// wrapProxyProtocolConnContext returns ConnContext function that stores PROXY protocol connection in context and then
+// calls given connContext (when it is set).
+func wrapProxyProtocolConnContext(connContext func(ctx stdContext.Context, c net.Conn) stdContext.Context) func(ctx stdContext.Context, c net.Conn) stdContext.Context {
+	if connContext == nil {
+		return ProxyProtocolConnContext
+	}
+	return func(ctx stdContext.Context, c net.Conn) stdContext.Context {
+		return connContext(ProxyProtocolConnContext(ctx, c), c)
+	}
+}
+
+// ProxyProtocolHeaderFromContext returns PROXY protocol header of the connection request was received on. Returns
+// nil when connection did not have a header.
+func ProxyProtocolHeaderFromContext(ctx stdContext.Context) *ProxyProtocolHeader {
+	c, ok := ctx.Value(proxyProtocolContextKey{}).(*proxyProtocolConn)
+	if !ok {
+		return nil
+	}
+	c.readHeader()
+	return c.header
+}
+
+type proxyProtocolListener struct {
+	net.Listener
+	config ProxyProtocolConfig
+}
+
+func (l *proxyProtocolListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &proxyProtocolConn{Conn: c, config: &l.config}, nil
+}
+
+type proxyProtocolConn struct {
+	net.Conn
+	config *ProxyProtocolConfig
+
+	once   sync.Once
+	reader *bufio.Reader
+	header *ProxyProtocolHeader
+	err    error
+
+	deadlineMutex sync.Mutex
+	readDeadline  time.Time // read deadline set by the user of the connection
+}
+
+func (c *proxyProtocolConn) SetDeadline(t time.Time) error {
+	c.deadlineMutex.Lock()
+	defer c.deadlineMutex.Unlock()
+	c.readDeadline = t
+	return c.Conn.SetDeadline(t)
+}
+
+func (c *proxyProtocolConn) SetReadDeadline(t time.Time) error {
+	c.deadlineMutex.Lock()
+	defer c.deadlineMutex.Unlock()
+	c.readDeadline = t
+	return c.Conn.SetReadDeadline(t)
+}
+
+// setHeaderDeadline sets read deadline for reading the header. Deadline set by the user is kept when it is earlier.
+func (c *proxyProtocolConn) setHeaderDeadline() error {
+	c.deadlineMutex.Lock()
+	defer c.deadlineMutex.Unlock()
+	deadline := time.Now().Add(c.config.ReadHeaderTimeout)
+	if !c.readDeadline.IsZero() && c.readDeadline.Before(deadline) {
+		deadline = c.readDeadline
+	}
+	return c.Conn.SetReadDeadline(deadline)
+}
+
+// restoreDeadline restores read deadline set by the user after the header has been read.
+func (c *proxyProtocolConn) restoreDeadline() error {
+	c.deadlineMutex.Lock()
+	defer c.deadlineMutex.Unlock()
+	return c.Conn.SetReadDeadline(c.readDeadline)
+}
+
+func (c *proxyProtocolConn) Read(b []byte) (int, error) {
+	c.readHeader()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(b)
+}
+
+func (c *proxyProtocolConn) RemoteAddr() net.Addr {
+	c.readHeader()
+	if c.header != nil && c.header.SourceAddr != nil {
+		return c.header.SourceAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *proxyProtocolConn) LocalAddr() net.Addr {
+	c.readHeader()
+	if c.header != nil && c.header.DestinationAddr != nil {
+		return c.header.DestinationAddr
+	}
+	return c.Conn.LocalAddr()
+}
+
+func (c *proxyProtocolConn) readHeader() {
+	c.once.Do(func() {
+		c.reader = bufio.NewReader(c.Conn)
+		if !c.trusted() {
+			return
+		}
+		if err := c.setHeaderDeadline(); err != nil {
+			c.err = err
+			return
+		}
+		defer func() {
+			if err := c.restoreDeadline(); err != nil && c.err == nil {
+				c.err = err
+			}
+		}()
+		if !c.config.Required {
+			// connection without header is valid, so connection that is idle until the deadline is served without
+			// header instead of being failed. Header gets its own timeout once the first byte has arrived.
+			if _, err := c.reader.Peek(1); err != nil {
+				var netErr net.Error
+				if err != io.EOF && !(errors.As(err, &netErr) && netErr.Timeout()) {
+					c.err = err
+				}
+				return
+			}
+			if err := c.setHeaderDeadline(); err != nil {
+				c.err = err
+				return
+			}
+		}
+		c.header, c.err = readProxyProtocolHeader(c.reader)
+		if c.err == nil && c.header == nil && c.config.Required {
+			c.err = ErrProxyProtocolHeaderMissing
+		}
+	})
+}
+
+func (c *proxyProtocolConn) trusted() bool {
+	if len(c.config.TrustedProxies) == 0 {
+		return true
+	}
+	addr, ok := c.Conn.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, ipRange := range c.config.TrustedProxies {
+		if ipRange.Contains(addr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// readProxyProtocolHeader reads v1 or v2 header from reader. Returns nil header when stream does not start with a
+// PROXY header.
+func readProxyProtocolHeader(r *bufio.Reader) (*ProxyProtocolHeader, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+	switch first[0] {
+	case 'P':
+		if prefix, err := r.Peek(6); err != nil || string(prefix) != "PROXY " {
+			return nil, nil
+		}
+		return readProxyProtocolV1(r)
+	case proxyProtocolV2Signature[0]:
+		if prefix, err := r.Peek(len(proxyProtocolV2Signature)); err != nil || !bytes.Equal(prefix, proxyProtocolV2Signature) {
+			return nil, nil
+		}
+		return readProxyProtocolV2(r)
+	}
+	return nil, nil
+}
+
+// readProxyProtocolV1 parses human-readable header i.e. `PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n`
+func readProxyProtocolV1(r *bufio.Reader) (*ProxyProtocolHeader, error) {
+	var line []byte
+	for len(line) < proxyProtocolV1MaxLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, ErrProxyProtocolInvalidHeader
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	header := &ProxyProtocolHeader{Version: 1}
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		header.Local = true
+		return header, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, ErrProxyProtocolInvalidHeader
+	}
+	src, err := parseProxyProtocolV1Addr(fields[1], fields[2], fields[4])
+	if err != nil {
+		return nil, err
+	}
+	dst, err := parseProxyProtocolV1Addr(fields[1], fields[3], fields[5])
+	if err != nil {
+		return nil, err
+	}
+	header.SourceAddr = src
+	header.DestinationAddr = dst
+	return header, nil
+}
+
+func parseProxyProtocolV1Addr(protocol, ip, port string) (*net.TCPAddr, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil || (protocol == "TCP4") != (addr.To4() != nil) {
+		return nil, ErrProxyProtocolInvalidHeader
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, ErrProxyProtocolInvalidHeader
+	}
+	return &net.TCPAddr{IP: addr, Port: int(p)}, nil
+}
+
+// readProxyProtocolV2 parses binary header.
+func readProxyProtocolV2(r *bufio.Reader) (*ProxyProtocolHeader, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, err
+	}
+	if fixed[12]>>4 != 2 {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrProxyProtocolInvalidHeader, fixed[12]>>4)
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(fixed[14:16]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	header := &ProxyProtocolHeader{Version: 2}
+	switch fixed[12] & 0x0F {
+	case proxyProtocolV2CommandLocal:
+		header.Local = true
+		return header, nil // addresses of local connections must be ignored
+	case proxyProtocolV2CommandProxy:
+	default:
+		return nil, ErrProxyProtocolInvalidHeader
+	}
+
+	var addrLen int
+	switch family := fixed[13] >> 4; family {
+	case 0x1: // AF_INET
+		addrLen = 12
+		if len(payload) < addrLen {
+			return nil, ErrProxyProtocolInvalidHeader
+		}
+		header.SourceAddr, header.DestinationAddr = proxyProtocolV2Addrs(fixed[13]&0x0F, payload[0:4], payload[4:8], payload[8:12])
+	case 0x2: // AF_INET6
+		addrLen = 36
+		if len(payload) < addrLen {
+			return nil, ErrProxyProtocolInvalidHeader
+		}
+		header.SourceAddr, header.DestinationAddr = proxyProtocolV2Addrs(fixed[13]&0x0F, payload[0:16], payload[16:32], payload[32:36])
+	case 0x0, 0x3: // AF_UNSPEC, AF_UNIX - addresses are not usable as IP addresses
+		if family == 0x3 {
+			addrLen = 216
+		}
+		if len(payload) < addrLen {
+			return nil, ErrProxyProtocolInvalidHeader
+		}
+	default:
+		return nil, ErrProxyProtocolInvalidHeader
+	}
+
+	tlvs, err := parseProxyProtocolTLVs(payload[addrLen:])
+	if err != nil {
+		return nil, err
+	}
+	header.TLVs = tlvs
+	if ssl, ok := tlvs[proxyProtocolV2TypeSSL]; ok && len(ssl) > 0 {
+		header.TLS = ssl[0]&proxyProtocolV2ClientSSL != 0
+	}
+	return header, nil
+}
+
+func proxyProtocolV2Addrs(transport byte, src, dst, ports []byte) (net.Addr, net.Addr) {
+	srcPort := int(binary.BigEndian.Uint16(ports[0:2]))
+	dstPort := int(binary.BigEndian.Uint16(ports[2:4]))
+	srcIP := append(net.IP(nil), src...)
+	dstIP := append(net.IP(nil), dst...)
+	if transport == 0x2 { // DGRAM
+		return &net.UDPAddr{IP: srcIP, Port: srcPort}, &net.UDPAddr{IP: dstIP, Port: dstPort}
+	}
+	return &net.TCPAddr{IP: srcIP, Port: srcPort}, &net.TCPAddr{IP: dstIP, Port: dstPort}
+}
+
+func parseProxyProtocolTLVs(b []byte) (map[byte][]byte, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	tlvs := make(map[byte][]byte)
+	for len(b) > 0 {
+		if len(b) < 3 {
+			return nil, ErrProxyProtocolInvalidHeader
+		}
+		length := int(binary.BigEndian.Uint16(b[1:3]))
+		if len(b) < 3+length {
+			return nil, ErrProxyProtocolInvalidHeader
+		}
+		tlvs[b[0]] = b[3 : 3+length]
+		b = b[3+length:]
+	}
+	return tlvs, nil
+}
@@ -0,0 +1,306 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	"bufio"
+	"bytes"
+	stdContext "context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func proxyProtocolV2Header(command byte, family byte, addresses []byte, tlvs []byte) []byte {
+	b := append([]byte(nil), proxyProtocolV2Signature...)
+	b = append(b, 0x20|command, family)
+	length := make([]byte, 2)
+	binary.BigEndian.PutUint16(length, uint16(len(addresses)+len(tlvs)))
+	b = append(b, length...)
+	b = append(b, addresses...)
+	return append(b, tlvs...)
+}
+
+func TestReadProxyProtocolHeader(t *testing.T) {
+	ipv4Addresses := []byte{192, 0, 2, 1, 198, 51, 100, 1, 0xDC, 0x04, 0x01, 0xBB} // ports 56324 and 443
+
+	var testCases = []struct {
+		name         string
+		givenData    []byte
+		expectHeader *ProxyProtocolHeader
+		expectRest   string
+		expectError  string
+	}{
+		{
+			name:         "no header",
+			givenData:    []byte("GET / HTTP/1.1\r\n"),
+			expectHeader: nil,
+			expectRest:   "GET / HTTP/1.1\r\n",
+		},
+		{
+			name:      "v1 tcp4",
+			givenData: []byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\nGET /"),
+			expectHeader: &ProxyProtocolHeader{
+				Version:         1,
+				SourceAddr:      &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 56324},
+				DestinationAddr: &net.TCPAddr{IP: net.ParseIP("198.51.100.1"), Port: 443},
+			},
+			expectRest: "GET /",
+		},
+		{
+			name:      "v1 tcp6",
+			givenData: []byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n"),
+			expectHeader: &ProxyProtocolHeader{
+				Version:         1,
+				SourceAddr:      &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 56324},
+				DestinationAddr: &net.TCPAddr{IP: net.ParseIP("2001:db8::2"), Port: 443},
+			},
+		},
+		{
+			name:         "v1 unknown",
+			givenData:    []byte("PROXY UNKNOWN\r\n"),
+			expectHeader: &ProxyProtocolHeader{Version: 1, Local: true},
+		},
+		{
+			name:        "nok, v1 address family mismatch",
+			givenData:   []byte("PROXY TCP4 2001:db8::1 198.51.100.1 56324 443\r\n"),
+			expectError: "proxy protocol: invalid header",
+		},
+		{
+			name:        "nok, v1 without CRLF",
+			givenData:   []byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\n"),
+			expectError: "proxy protocol: invalid header",
+		},
+		{
+			name:      "v2 tcp4 with ssl tlv",
+			givenData: append(proxyProtocolV2Header(0x1, 0x11, ipv4Addresses, []byte{0x20, 0x00, 0x01, 0x01}), "GET /"...),
+			expectHeader: &ProxyProtocolHeader{
+				Version:         2,
+				SourceAddr:      &net.TCPAddr{IP: net.IP{192, 0, 2, 1}, Port: 56324},
+				DestinationAddr: &net.TCPAddr{IP: net.IP{198, 51, 100, 1}, Port: 443},
+				TLS:             true,
+				TLVs:            map[byte][]byte{0x20: {0x01}},
+			},
+			expectRest: "GET /",
+		},
+		{
+			name:         "v2 local command",
+			givenData:    proxyProtocolV2Header(0x0, 0x00, nil, nil),
+			expectHeader: &ProxyProtocolHeader{Version: 2, Local: true},
+		},
+		{
+			name:        "nok, v2 truncated addresses",
+			givenData:   proxyProtocolV2Header(0x1, 0x21, ipv4Addresses, nil),
+			expectError: "proxy protocol: invalid header",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := bufio.NewReader(bytes.NewReader(tc.givenData))
+			header, err := readProxyProtocolHeader(r)
+			if tc.expectError != "" {
+				assert.EqualError(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectHeader, header)
+
+			rest, _ := io.ReadAll(r)
+			assert.Equal(t, tc.expectRest, string(rest))
+		})
+	}
+}
+
+func TestProxyProtocolConn(t *testing.T) {
+	_, trusted, _ := net.ParseCIDR("10.0.0.0/8")
+
+	var testCases = []struct {
+		name             string
+		givenConfig      ProxyProtocolConfig
+		givenData        string
+		expectRemoteAddr string
+		expectRead       string
+		expectError      string
+	}{
+		{
+			name:             "ok, header is parsed",
+			givenData:        "PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\nhello",
+			expectRemoteAddr: "192.0.2.1:56324",
+			expectRead:       "hello",
+		},
+		{
+			name:             "ok, untrusted source is served as is",
+			givenConfig:      ProxyProtocolConfig{TrustedProxies: []*net.IPNet{trusted}},
+			givenData:        "PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n",
+			expectRemoteAddr: "127.0.0.1:1234",
+			expectRead:       "PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n",
+		},
+		{
+			name:             "nok, required header is missing",
+			givenConfig:      ProxyProtocolConfig{Required: true},
+			givenData:        "hello",
+			expectRemoteAddr: "127.0.0.1:1234",
+			expectError:      "proxy protocol: header missing",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, client := net.Pipe()
+			go func() {
+				_, _ = client.Write([]byte(tc.givenData))
+				_ = client.Close()
+			}()
+
+			config := tc.givenConfig
+			config.ReadHeaderTimeout = time.Second
+			c := &proxyProtocolConn{
+				Conn:   &testRemoteAddrConn{Conn: server, remoteAddr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234}},
+				config: &config,
+			}
+			defer c.Close()
+
+			assert.Equal(t, tc.expectRemoteAddr, c.RemoteAddr().String())
+			data, err := io.ReadAll(c)
+			if tc.expectError != "" {
+				assert.EqualError(t, err, tc.expectError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectRead, string(data))
+			}
+		})
+	}
+}
+
+func TestProxyProtocolConnOptionalHeaderAfterTimeout(t *testing.T) {
+	server, client := net.Pipe()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = client.Write([]byte("hello"))
+		_ = client.Close()
+	}()
+
+	c := &proxyProtocolConn{Conn: server, config: &ProxyProtocolConfig{ReadHeaderTimeout: 20 * time.Millisecond}}
+	defer c.Close()
+
+	// waiting for optional header is bounded but idle connection is not failed by ReadHeaderTimeout
+	start := time.Now()
+	assert.Equal(t, "pipe", c.RemoteAddr().String())
+	assert.Less(t, time.Since(start), 80*time.Millisecond)
+	data, err := io.ReadAll(c)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestProxyProtocolConnRestoresReadDeadline(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	go func() {
+		_, _ = client.Write([]byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n"))
+	}()
+
+	c := &proxyProtocolConn{Conn: server, config: &ProxyProtocolConfig{ReadHeaderTimeout: time.Second}}
+	defer c.Close()
+
+	// deadline set by server (i.e. http.Server.ReadHeaderTimeout) is still in effect after header has been read
+	assert.NoError(t, c.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+	start := time.Now()
+	_, err := c.Read(make([]byte, 1))
+	var netErr net.Error
+	assert.True(t, errors.As(err, &netErr) && netErr.Timeout())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, "192.0.2.1:56324", c.RemoteAddr().String())
+}
+
+type testRemoteAddrConn struct {
+	net.Conn
+	remoteAddr net.Addr
+}
+
+func (c *testRemoteAddrConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+func TestEchoStartProxyProtocol(t *testing.T) {
+	e := New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.ProxyProtocol = &ProxyProtocolConfig{}
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, c.Request().RemoteAddr+" "+c.Scheme())
+	})
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- e.Start("127.0.0.1:0")
+	}()
+	assert.NoError(t, waitForServerStart(e, errCh, false))
+	defer e.Close()
+
+	conn, err := net.Dial("tcp", e.ListenerAddr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	header := proxyProtocolV2Header(0x1, 0x11,
+		[]byte{192, 0, 2, 1, 127, 0, 0, 1, 0xDC, 0x04, 0x01, 0xBB},
+		[]byte{0x20, 0x00, 0x01, 0x01},
+	)
+	_, err = conn.Write(append(header, "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"...))
+	assert.NoError(t, err)
+
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(res.Body)
+	assert.Equal(t, "192.0.2.1:56324 https", string(body))
+}
+
+func TestEchoStartProxyProtocolKeepsConnContext(t *testing.T) {
+	type connContextKey struct{}
+
+	e := New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.ProxyProtocol = &ProxyProtocolConfig{}
+	e.Server.ConnContext = func(ctx stdContext.Context, c net.Conn) stdContext.Context {
+		return stdContext.WithValue(ctx, connContextKey{}, "custom")
+	}
+	e.GET("/", func(c Context) error {
+		ctx := c.Request().Context()
+		header := ProxyProtocolHeaderFromContext(ctx)
+		if header == nil {
+			return c.String(http.StatusOK, "no header")
+		}
+		return c.String(http.StatusOK, header.SourceAddr.String()+" "+ctx.Value(connContextKey{}).(string))
+	})
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- e.Start("127.0.0.1:0")
+	}()
+	assert.NoError(t, waitForServerStart(e, errCh, false))
+	defer e.Close()
+
+	conn, err := net.Dial("tcp", e.ListenerAddr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("PROXY TCP4 192.0.2.1 127.0.0.1 56324 80\r\nGET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"))
+	assert.NoError(t, err)
+
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(res.Body)
+	assert.Equal(t, "192.0.2.1:56324 custom", string(body))
+}
+
+func TestProxyProtocolHeaderFromContext(t *testing.T) {
+	assert.Nil(t, ProxyProtocolHeaderFromContext(stdContext.Background()))
+}
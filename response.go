@@ -96,7 +96,11 @@ func (r *Response) Flush() {
 // take over the connection.
 // See [http.Hijacker](https://golang.org/pkg/net/http/#Hijacker)
 func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return responseControllerHijack(r.Writer)
+	conn, rw, err := responseControllerHijack(r.Writer)
+	if err != nil || r.echo == nil {
+		return conn, rw, err
+	}
+	return r.echo.connections.track(conn), rw, nil
 }
 
 // Unwrap returns the original http.ResponseWriter.
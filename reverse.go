@@ -0,0 +1,316 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrReverseRouteNotFound is returned by `Router#ReverseURL()` when there is no route with given name.
+	ErrReverseRouteNotFound = errors.New("route for reverse not found")
+	// ErrReverseParams is returned by `Router#ReverseURL()` when params are missing, extra or invalid.
+	ErrReverseParams = errors.New("invalid reverse route params")
+)
+
+// ReverseURL generates URL for the named route from named path params and query params. Path param values are
+// URL-escaped (for wildcard `*` param each path segment is escaped) and checked against param constraints.
+//
+// Params can be `map[string]string`, `map[string]interface{}` or struct (or pointer to struct) with `param` tags
+// (same tags as used for binding path params). Wildcard param has name `*`. When multiple routes have the same
+// name, route with param names matching the given params is used.
+//
+// Returns error wrapping `ErrReverseRouteNotFound` when route does not exist and `ErrReverseParams` when params are
+// missing, extra or do not match param constraint.
+//
+// Example:
+//
+//	e.GET("/users/:id<int>/files/*", getFile).Name = "user-file"
+//	url, err := e.ReverseURL("user-file", map[string]interface{}{"id": 1, "*": "a b/c.txt"}, url.Values{"v": {"2"}})
+//	// url == "/users/1/files/a%20b/c.txt?v=2"
+func (r *Router) ReverseURL(name string, params interface{}, query url.Values) (string, error) {
+	values, err := reverseParams(params)
+	if err != nil {
+		return "", err
+	}
+
+	candidates := make([]*Route, 0, 1)
+	for _, route := range r.routes {
+		if route.Name == name {
+			candidates = append(candidates, route)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("%w: %q", ErrReverseRouteNotFound, name)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Path == candidates[j].Path {
+			return candidates[i].Method < candidates[j].Method
+		}
+		return candidates[i].Path < candidates[j].Path
+	})
+
+	route := candidates[0]
+	for _, c := range candidates {
+		if sameParamNames(routeParamNames(c.Path), values) {
+			route = c
+			break
+		}
+	}
+
+	path, err := reversePath(route.Path, values)
+	if err != nil {
+		return "", err
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path, nil
+}
+
+// ReverseURL generates URL for the named route of the default router. See `Router#ReverseURL()`.
+// In case when Echo serves multiple hosts/domains use `e.Routers()["domain2.site"].ReverseURL()` for specific host.
+func (e *Echo) ReverseURL(name string, params interface{}, query url.Values) (string, error) {
+	return e.currentRouting().router.ReverseURL(name, params, query)
+}
+
+// TemplateFuncs returns functions for generating route URLs in templates. Functions can be added to
+// `html/template` and `text/template` templates with `Template.Funcs()`.
+//
+//   - `reverse <name> [<key> <value>]...` generates URL for route in the default router
+//   - `reverseHost <host> <name> [<key> <value>]...` generates URL for route in the host router
+//
+// Keys are path param names. Keys starting with `?` are added as query params. Instead of key value pairs single
+// map or struct argument can be given.
+//
+// Example: `<a href="{{ reverse "user" "id" .ID "?tab" "files" }}">`
+func (e *Echo) TemplateFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		"reverse": func(name string, args ...interface{}) (string, error) {
+			return templateReverse(e.currentRouting().router, name, args)
+		},
+		"reverseHost": func(host, name string, args ...interface{}) (string, error) {
+			router, ok := e.Routers()[host]
+			if !ok {
+				return "", fmt.Errorf("%w: %q for host %q", ErrReverseRouteNotFound, name, host)
+			}
+			return templateReverse(router, name, args)
+		},
+	}
+}
+
+func templateReverse(router *Router, name string, args []interface{}) (string, error) {
+	if len(args) == 1 {
+		return router.ReverseURL(name, args[0], nil)
+	}
+	if len(args)%2 != 0 {
+		return "", fmt.Errorf("%w: odd number of key value arguments", ErrReverseParams)
+	}
+	params := make(map[string]string, len(args)/2)
+	var query url.Values
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			return "", fmt.Errorf("%w: key %v is not a string", ErrReverseParams, args[i])
+		}
+		value := fmt.Sprint(args[i+1])
+		if strings.HasPrefix(key, "?") {
+			if query == nil {
+				query = url.Values{}
+			}
+			query.Add(key[1:], value)
+			continue
+		}
+		params[key] = value
+	}
+	return router.ReverseURL(name, params, query)
+}
+
+// reverseParams converts params given to `ReverseURL` to map of param values.
+func reverseParams(params interface{}) (map[string]string, error) {
+	values := map[string]string{}
+	switch p := params.(type) {
+	case nil:
+		return values, nil
+	case map[string]string:
+		for k, v := range p {
+			values[k] = v
+		}
+		return values, nil
+	case map[string]interface{}:
+		for k, v := range p {
+			values[k] = fmt.Sprint(v)
+		}
+		return values, nil
+	case Map:
+		for k, v := range p {
+			values[k] = fmt.Sprint(v)
+		}
+		return values, nil
+	}
+
+	v := reflect.ValueOf(params)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return values, nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("%w: map key must be string, got %T", ErrReverseParams, params)
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			values[iter.Key().String()] = fmt.Sprint(iter.Value().Interface())
+		}
+	case reflect.Struct:
+		structReverseParams(v, values)
+	default:
+		return nil, fmt.Errorf("%w: unsupported params type %T", ErrReverseParams, params)
+	}
+	return values, nil
+}
+
+func structReverseParams(v reflect.Value, values map[string]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+		tag := strings.SplitN(field.Tag.Get("param"), ",", 2)[0]
+		if field.Anonymous && tag == "" {
+			for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				structReverseParams(fv, values)
+			}
+			continue
+		}
+		if tag == "" || tag == "-" || field.PkgPath != "" {
+			continue
+		}
+		for fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Ptr {
+			continue // nil pointer is treated as missing param
+		}
+		values[tag] = fmt.Sprint(fv.Interface())
+	}
+}
+
+// routeParamNames returns param names in route path. Wildcard param has name `*`.
+func routeParamNames(path string) []string {
+	names := make([]string, 0)
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == ':':
+			i++
+		case path[i] == ':':
+			j := i + 1
+			for j < len(path) && path[j] != '/' && path[j] != '<' {
+				j++
+			}
+			names = append(names, path[i+1:j])
+			if j < len(path) && path[j] == '<' {
+				j += paramConstraintLen(path[j:], path)
+			}
+			i = j - 1
+		case path[i] == '*':
+			names = append(names, "*")
+		}
+	}
+	return names
+}
+
+func sameParamNames(names []string, values map[string]string) bool {
+	if len(names) != len(values) {
+		return false
+	}
+	for _, n := range names {
+		if _, ok := values[n]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// reversePath replaces params in route path with escaped values.
+func reversePath(path string, values map[string]string) (string, error) {
+	var b strings.Builder
+	used := 0
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == ':':
+			b.WriteByte(':')
+			i++
+		case path[i] == ':':
+			j := i + 1
+			for j < len(path) && path[j] != '/' && path[j] != '<' {
+				j++
+			}
+			name := path[i+1 : j]
+			var constraint *paramConstraint
+			if j < len(path) && path[j] == '<' {
+				l := paramConstraintLen(path[j:], path)
+				constraint = newParamConstraint(path[j : j+l])
+				j += l
+			}
+			value, ok := values[name]
+			if !ok {
+				return "", fmt.Errorf("%w: missing param %q for route %s", ErrReverseParams, name, path)
+			}
+			if constraint != nil && !constraint.match(value) {
+				return "", fmt.Errorf("%w: param %q value %q does not match constraint of route %s", ErrReverseParams, name, value, path)
+			}
+			b.WriteString(url.PathEscape(value))
+			used++
+			i = j - 1
+		case path[i] == '*':
+			value, ok := values["*"]
+			if !ok {
+				return "", fmt.Errorf("%w: missing param \"*\" for route %s", ErrReverseParams, path)
+			}
+			segments := strings.Split(value, "/")
+			for k, s := range segments {
+				segments[k] = url.PathEscape(s)
+			}
+			b.WriteString(strings.Join(segments, "/"))
+			used++
+		default:
+			b.WriteByte(path[i])
+		}
+	}
+	if used != len(values) {
+		extra := make([]string, 0)
+		names := routeParamNames(path)
+		for k := range values {
+			if !containsParamName(names, k) {
+				extra = append(extra, k)
+			}
+		}
+		sort.Strings(extra)
+		return "", fmt.Errorf("%w: extra params %q for route %s", ErrReverseParams, extra, path)
+	}
+	return b.String(), nil
+}
+
+func containsParamName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type reverseUserParams struct {
+	ID     int    `param:"id"`
+	Name   string `param:"name"`
+	Ignore string
+}
+
+type reverseFileParams struct {
+	reverseUserParams
+	Path *string `param:"*"`
+}
+
+func TestEchoReverseURL(t *testing.T) {
+	e := New()
+	h := func(c Context) error { return nil }
+	e.GET("/users/:id<int>", h).Name = "user"
+	e.GET("/users/:id<int>/:name", h).Name = "user"
+	e.GET("/users/:id/files/*", h).Name = "file"
+	e.GET("/static", h).Name = "static"
+	e.GET(`/time/\:now`, h).Name = "now"
+
+	filePath := "docs/a b.txt"
+	var testCases = []struct {
+		name        string
+		whenName    string
+		whenParams  interface{}
+		whenQuery   url.Values
+		expect      string
+		expectErr   error
+		expectError string
+	}{
+		{
+			name:       "ok, map params",
+			whenName:   "user",
+			whenParams: map[string]interface{}{"id": 10},
+			expect:     "/users/10",
+		},
+		{
+			name:       "ok, route with same name chosen by params",
+			whenName:   "user",
+			whenParams: map[string]string{"id": "10", "name": "John Doe/Jr"},
+			expect:     "/users/10/John%20Doe%2FJr",
+		},
+		{
+			name:       "ok, struct params",
+			whenName:   "user",
+			whenParams: &reverseUserParams{ID: 5, Name: "jane", Ignore: "x"},
+			expect:     "/users/5/jane",
+		},
+		{
+			name:        "nok, extra param from embedded struct",
+			whenName:    "file",
+			whenParams:  reverseFileParams{reverseUserParams: reverseUserParams{ID: 1}, Path: &filePath},
+			expectErr:   ErrReverseParams,
+			expectError: `invalid reverse route params: extra params ["name"] for route /users/:id/files/*`,
+		},
+		{
+			name:       "ok, wildcard escaped per segment with query",
+			whenName:   "file",
+			whenParams: Map{"id": 1, "*": filePath},
+			whenQuery:  url.Values{"download": {"1"}, "q": {"a&b"}},
+			expect:     "/users/1/files/docs/a%20b.txt?download=1&q=a%26b",
+		},
+		{
+			name:     "ok, no params",
+			whenName: "static",
+			expect:   "/static",
+		},
+		{
+			name:     "ok, escaped colon",
+			whenName: "now",
+			expect:   "/time/:now",
+		},
+		{
+			name:        "nok, unknown route",
+			whenName:    "nope",
+			expectErr:   ErrReverseRouteNotFound,
+			expectError: `route for reverse not found: "nope"`,
+		},
+		{
+			name:        "nok, missing param",
+			whenName:    "file",
+			whenParams:  map[string]string{"id": "1"},
+			expectErr:   ErrReverseParams,
+			expectError: `invalid reverse route params: missing param "*" for route /users/:id/files/*`,
+		},
+		{
+			name:        "nok, extra param",
+			whenName:    "static",
+			whenParams:  map[string]string{"id": "1"},
+			expectErr:   ErrReverseParams,
+			expectError: `invalid reverse route params: extra params ["id"] for route /static`,
+		},
+		{
+			name:        "nok, constraint not matched",
+			whenName:    "user",
+			whenParams:  map[string]string{"id": "abc"},
+			expectErr:   ErrReverseParams,
+			expectError: `invalid reverse route params: param "id" value "abc" does not match constraint of route /users/:id<int>`,
+		},
+		{
+			name:        "nok, unsupported params",
+			whenName:    "user",
+			whenParams:  []string{"1"},
+			expectErr:   ErrReverseParams,
+			expectError: `invalid reverse route params: unsupported params type []string`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := e.ReverseURL(tc.whenName, tc.whenParams, tc.whenQuery)
+			if tc.expectError != "" {
+				assert.EqualError(t, err, tc.expectError)
+				assert.True(t, errors.Is(err, tc.expectErr))
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expect, result)
+		})
+	}
+}
+
+func TestEchoReverseURLHost(t *testing.T) {
+	e := New()
+	h := func(c Context) error { return nil }
+	e.Host("api.example.com").GET("/items/:id", h).Name = "item"
+
+	_, err := e.ReverseURL("item", Map{"id": 1}, nil)
+	assert.True(t, errors.Is(err, ErrReverseRouteNotFound))
+
+	result, err := e.Routers()["api.example.com"].ReverseURL("item", Map{"id": 1}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "/items/1", result)
+}
+
+func TestEchoTemplateFuncs(t *testing.T) {
+	e := New()
+	h := func(c Context) error { return nil }
+	e.GET("/users/:id", h).Name = "user"
+	e.Host("api.example.com").GET("/items/:id", h).Name = "item"
+
+	tmpl := template.Must(template.New("").Funcs(e.TemplateFuncs()).Parse(
+		`{{define "links"}}<a href="{{ reverse "user" "id" .id "?tab" "files" }}">` +
+			`<a href="{{ reverse "user" . }}">` +
+			`<a href="{{ reverseHost "api.example.com" "item" "id" 2 }}">{{end}}` +
+			`{{define "broken"}}{{ reverse "user" "id" }}{{end}}`,
+	))
+	e.Renderer = &TemplateRenderer{Template: tmpl}
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	err := c.Render(http.StatusOK, "links", map[string]interface{}{"id": 7})
+	assert.NoError(t, err)
+	assert.Equal(t, `<a href="/users/7?tab=files"><a href="/users/7"><a href="/items/2">`, rec.Body.String())
+
+	err = e.Renderer.Render(new(bytes.Buffer), "broken", nil, c)
+	assert.Error(t, err)
+}
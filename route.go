@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import "time"
+
+// RouteMeta is metadata attached to a route at registration. Metadata is kept by Echo instance the route was
+// registered with and is available during request with `RouteMetaOf()` and with `Echo#Meta()` for routes from
+// `Echo.Routes()` for tooling (documentation, permission listings etc.). Middlewares like BodyLimit, Timeout,
+// ContextTimeout and Authorization use it to override their configuration per route.
+//
+// Example:
+//
+//	e.Meta(e.POST("/files", upload)).
+//		Describe("Uploads a file").
+//		Tag("files").
+//		RequireScopes("files:write").
+//		WithBodyLimit(10 << 20).
+//		WithTimeout(30 * time.Second)
+//
+// Metadata must be set before the route starts serving requests.
+type RouteMeta struct {
+	// Description is human readable description of the route.
+	Description string `json:"description,omitempty"`
+	// Tags are used to group routes (i.e. in API documentation).
+	Tags []string `json:"tags,omitempty"`
+	// Deprecated marks route as deprecated.
+	Deprecated bool `json:"deprecated,omitempty"`
+	// Scopes are permissions required to access the route. Used by Authorization middleware.
+	Scopes []string `json:"scopes,omitempty"`
+	// Timeout overrides timeout of Timeout and ContextTimeout middlewares for the route.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// BodyLimit overrides request body limit (in bytes) of BodyLimit middleware for the route.
+	BodyLimit int64 `json:"body_limit,omitempty"`
+	// Values holds custom metadata.
+	Values map[string]interface{} `json:"values,omitempty"`
+}
+
+// RouteContext is implemented by Context that knows the route matched by the router. Context created by Echo
+// implements it. Custom Context implementations wrapping Echo context should delegate this method to the wrapped
+// context.
+type RouteContext interface {
+	// Route returns route matched by the router or nil when no route matched (404, 405 cases).
+	Route() *Route
+}
+
+// Meta returns metadata of the route. Empty metadata is attached to the route when it does not have any yet.
+// Routes of mounted Echo instances listed by `Echo#Routes()` return metadata kept by the mounted instance.
+func (e *Echo) Meta(route *Route) *RouteMeta {
+	if meta, ok := e.routeMeta.Load(route); ok {
+		return meta.(*RouteMeta)
+	}
+	if meta := e.mountedMeta(route); meta != nil {
+		return meta
+	}
+	meta, _ := e.routeMeta.LoadOrStore(route, &RouteMeta{})
+	return meta.(*RouteMeta)
+}
+
+// Meta implements `Echo#Meta()` for routes registered within the Group.
+func (g *Group) Meta(route *Route) *RouteMeta {
+	return g.echo.Meta(route)
+}
+
+// Describe sets route description.
+func (m *RouteMeta) Describe(description string) *RouteMeta {
+	m.Description = description
+	return m
+}
+
+// Tag adds tags to route.
+func (m *RouteMeta) Tag(tags ...string) *RouteMeta {
+	m.Tags = append(m.Tags, tags...)
+	return m
+}
+
+// Deprecate marks route as deprecated.
+func (m *RouteMeta) Deprecate() *RouteMeta {
+	m.Deprecated = true
+	return m
+}
+
+// RequireScopes adds scopes (permissions) required to access the route.
+func (m *RouteMeta) RequireScopes(scopes ...string) *RouteMeta {
+	m.Scopes = append(m.Scopes, scopes...)
+	return m
+}
+
+// WithTimeout sets request timeout for the route.
+func (m *RouteMeta) WithTimeout(timeout time.Duration) *RouteMeta {
+	m.Timeout = timeout
+	return m
+}
+
+// WithBodyLimit sets request body limit (in bytes) for the route.
+func (m *RouteMeta) WithBodyLimit(limit int64) *RouteMeta {
+	m.BodyLimit = limit
+	return m
+}
+
+// Set sets custom metadata value for key.
+func (m *RouteMeta) Set(key string, value interface{}) *RouteMeta {
+	if m.Values == nil {
+		m.Values = make(map[string]interface{})
+	}
+	m.Values[key] = value
+	return m
+}
+
+// Get returns custom metadata value for key or nil when value is not set.
+func (m *RouteMeta) Get(key string) interface{} {
+	if m == nil {
+		return nil
+	}
+	return m.Values[key]
+}
+
+// RouteMetaOf returns metadata of the route matched for the request. Returns nil when route has no metadata, no
+// route was matched or context does not implement `RouteContext`.
+func RouteMetaOf(c Context) *RouteMeta {
+	rc, ok := c.(RouteContext)
+	if !ok || c.Echo() == nil {
+		return nil
+	}
+	route := rc.Route()
+	if route == nil {
+		return nil
+	}
+	if meta, ok := c.Echo().routeMeta.Load(route); ok {
+		return meta.(*RouteMeta)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteMeta(t *testing.T) {
+	e := New()
+	var meta *RouteMeta
+	var route *Route
+	h := func(c Context) error {
+		meta = RouteMetaOf(c)
+		route = c.(RouteContext).Route()
+		return c.NoContent(http.StatusOK)
+	}
+
+	r := e.POST("/files/:id", h)
+	m := e.Meta(r).
+		Describe("Uploads a file").
+		Tag("files", "upload").
+		Deprecate().
+		RequireScopes("files:write").
+		WithTimeout(5*time.Second).
+		WithBodyLimit(1024).
+		Set("owner", "storage-team")
+	e.GET("/plain", h)
+
+	expect := &RouteMeta{
+		Description: "Uploads a file",
+		Tags:        []string{"files", "upload"},
+		Deprecated:  true,
+		Scopes:      []string{"files:write"},
+		Timeout:     5 * time.Second,
+		BodyLimit:   1024,
+		Values:      map[string]interface{}{"owner": "storage-team"},
+	}
+	assert.Equal(t, expect, m)
+	assert.Same(t, m, e.Meta(r))
+	assert.Equal(t, "storage-team", m.Get("owner"))
+	assert.Nil(t, m.Get("missing"))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/files/1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, expect, meta)
+	assert.Equal(t, r, route)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/plain", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, meta)
+	if assert.NotNil(t, route) {
+		assert.Equal(t, "/plain", route.Path)
+	}
+
+	for _, rr := range e.Routes() {
+		if rr.Path == "/files/:id" {
+			assert.Equal(t, expect, e.Meta(rr))
+		}
+	}
+}
+
+func TestRouteMetaJSON(t *testing.T) {
+	e := New()
+	r := e.GET("/users", func(c Context) error { return nil })
+	r.Name = "users"
+	e.Meta(r).Describe("Lists users").Tag("users").WithTimeout(time.Second)
+
+	b, err := json.Marshal(r)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"method":"GET","path":"/users","name":"users"}`, string(b))
+
+	b, err = json.Marshal(e.Meta(r))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"description":"Lists users","tags":["users"],"timeout":1000000000}`, string(b))
+}
+
+func TestRouteMetaGroup(t *testing.T) {
+	e := New()
+	g := e.Group("/api")
+	r := g.GET("/users", func(c Context) error { return nil })
+	g.Meta(r).RequireScopes("users:read")
+
+	assert.Equal(t, []string{"users:read"}, e.Meta(r).Scopes)
+}
+
+func TestRouteMetaRemovedWithRoute(t *testing.T) {
+	e := New()
+	r := e.GET("/users", func(c Context) error { return nil })
+	e.Meta(r).Describe("Lists users")
+
+	assert.True(t, e.RemoveRoute(http.MethodGet, "/users"))
+	_, ok := e.routeMeta.Load(r)
+	assert.False(t, ok)
+}
+
+func TestRouteMetaNotMatched(t *testing.T) {
+	e := New()
+	var route *Route
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			route = c.(RouteContext).Route()
+			return next(c)
+		}
+	})
+	e.GET("/a", func(c Context) error { return nil })
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/b", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Nil(t, route)
+	assert.Nil(t, RouteMetaOf(e.NewContext(nil, nil)))
+}
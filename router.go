@@ -7,6 +7,9 @@ import (
 	"bytes"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
 // Router is the registry of all registered routes for an `Echo` instance for
@@ -15,13 +18,19 @@ type Router struct {
 	tree   *node
 	routes map[string]*Route
 	echo   *Echo
+	// folded is true when static parts of paths in tree are lower cased (see `foldPath`)
+	folded bool
 }
 
 type node struct {
-	methods    *routeMethods
-	parent     *node
-	paramChild *node
-	anyChild   *node
+	methods *routeMethods
+	parent  *node
+	// paramChildren are param nodes of this node. Params with constraint (i.e. `:id<int>`) are before param without
+	// constraint so they are checked first.
+	paramChildren children
+	anyChild      *node
+	// constraint is value constraint of param node (i.e. `<int>` in `:id<int>`). nil for unconstrained params.
+	constraint *paramConstraint
 	// notFoundHandler is handler registered with RouteNotFound method and is executed for 404 cases
 	notFoundHandler *routeMethod
 	prefix          string
@@ -43,6 +52,8 @@ type routeMethod struct {
 	handler HandlerFunc
 	ppath   string
 	pnames  []string
+	// route is the route registered with this handler. It is nil for handlers added with `Router.Add`.
+	route *Route
 }
 
 type routeMethods struct {
@@ -71,7 +82,18 @@ const (
 )
 
 func (m *routeMethods) isHandler() bool {
-	return m.connect != nil || m.delete != nil
+	return m.connect != nil ||
+		m.delete != nil ||
+		m.get != nil ||
+		m.head != nil ||
+		m.options != nil ||
+		m.patch != nil ||
+		m.post != nil ||
+		m.propfind != nil ||
+		m.put != nil ||
+		m.trace != nil ||
+		m.report != nil ||
+		len(m.anyOther) != 0
 	// RouteNotFound/404 is not considered as a handler
 }
 
@@ -159,7 +181,6 @@ func (r *Router) Reverse(name string, params ...interface{}) string { //NOSONAR
 				if n < ln && (route.Path[i] == '*' || (!hasBackslash && route.Path[i] == ':')) {
 					// in case of `*` wildcard or `:` (unescaped colon) param we replace everything till next slash or end of path
 					for ; i < l && route.Path[i] != '/'; i++ {
-						fmt.Print("123")
 					}
 					uri.WriteString(fmt.Sprintf("%v", params[n]))
 					n++
@@ -174,6 +195,152 @@ func (r *Router) Reverse(name string, params ...interface{}) string { //NOSONAR
 	return uri.String()
 }
 
+// Remove removes route registered for method and path. Path must be the same as used when route was added
+// (i.e. `/users/:id`). Returns false when there is no such route.
+//
+// Router is not safe for concurrent use, to remove routes while server is running use `Echo#RemoveRoute()`.
+func (r *Router) Remove(method, path string) bool {
+	_, removed := r.remove(method, path)
+	return removed
+}
+
+func (r *Router) remove(method, path string) (*Route, bool) {
+	r.syncCaseFolding()
+	path = normalizePathSlash(path)
+	route := r.routes[method+path]
+	delete(r.routes, method+path)
+
+	n := r.findNode(r.foldPath(treePath(path)))
+	if n == nil {
+		return route, false
+	}
+	if method == RouteNotFound {
+		removed := n.notFoundHandler != nil
+		n.notFoundHandler = nil
+		return route, removed
+	}
+	if n.findMethod(method) == nil {
+		return route, false
+	}
+	n.removeMethod(method)
+	return route, true
+}
+
+// findNode returns node that exactly matches path as it is stored in tree (see `treePath`).
+func (r *Router) findNode(path string) *node {
+	n := r.tree
+	search := path
+	for n != nil {
+		if !strings.HasPrefix(search, n.prefix) {
+			return nil
+		}
+		search = search[len(n.prefix):]
+		if search == "" {
+			return n
+		}
+		n = n.findChild(search)
+	}
+	return nil
+}
+
+// treePath converts route path to form it is stored in tree - param names are removed (constraints are kept) and
+// escaped colons are unescaped. i.e. `/users/:id<int>/files/\:name` becomes `/users/:<int>/files/:name`
+func treePath(path string) string {
+	buf := make([]byte, 0, len(path))
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == ':':
+			buf = append(buf, ':')
+			i++
+		case path[i] == ':':
+			buf = append(buf, ':')
+			for i+1 < len(path) && path[i+1] != '/' && path[i+1] != '<' {
+				i++
+			}
+			if i+1 < len(path) && path[i+1] == '<' {
+				l := paramConstraintLen(path[i+1:], path)
+				buf = append(buf, path[i+1:i+1+l]...)
+				i += l
+			}
+		default:
+			buf = append(buf, path[i])
+		}
+	}
+	return string(buf)
+}
+
+// foldPath lower cases static parts of the route path when router matches paths case-insensitively (see
+// `PathNormalizationConfig.CaseInsensitive`). Param names and constraints are kept as they are.
+func (r *Router) foldPath(path string) string {
+	if !r.folded {
+		return path
+	}
+	buf := []byte(path)
+	for i := 0; i < len(buf); i++ {
+		switch {
+		case buf[i] == '\\' && i+1 < len(buf) && buf[i+1] == ':':
+			i++
+		case buf[i] == ':':
+			for i+1 < len(buf) && buf[i+1] != '/' && buf[i+1] != '<' {
+				i++
+			}
+			if i+1 < len(buf) && buf[i+1] == '<' {
+				i += paramConstraintLen(path[i+1:], path)
+			}
+		case 'A' <= buf[i] && buf[i] <= 'Z':
+			buf[i] += 'a' - 'A'
+		}
+	}
+	return string(buf)
+}
+
+// clone returns deep copy of the router. Handlers are shared.
+func (r *Router) clone() *Router {
+	routes := make(map[string]*Route, len(r.routes))
+	for k, v := range r.routes {
+		routes[k] = v
+	}
+	return &Router{
+		tree:   r.tree.clone(nil),
+		routes: routes,
+		echo:   r.echo,
+		folded: r.folded,
+	}
+}
+
+// syncCaseFolding re-indexes the tree when `PathNormalizationConfig.CaseInsensitive` has changed since routes were
+// added, so routes added before the change are matched the same way as routes added after it.
+func (r *Router) syncCaseFolding() {
+	caseInsensitive := r.echo != nil && r.echo.PathNormalization.CaseInsensitive
+	if caseInsensitive == r.folded {
+		return
+	}
+	r.folded = caseInsensitive
+	old := r.tree
+	r.tree = &node{methods: new(routeMethods)}
+	old.reinsert(r)
+}
+
+// reinsert inserts handlers of this node and its children to the router.
+func (n *node) reinsert(r *Router) {
+	for _, method := range n.methodNames() {
+		rm := n.findMethod(method)
+		r.insert(method, rm.ppath, rm.handler, rm.route)
+	}
+	if rm := n.notFoundHandler; rm != nil {
+		r.insert(RouteNotFound, rm.ppath, rm.handler, rm.route)
+	}
+	for _, c := range n.staticChildren {
+		c.reinsert(r)
+	}
+	for _, c := range n.paramChildren {
+		c.reinsert(r)
+	}
+	if n.anyChild != nil {
+		n.anyChild.reinsert(r)
+	}
+}
+
 func normalizePathSlash(path string) string {
 	if path == "" {
 		path = "/"
@@ -185,26 +352,28 @@ func normalizePathSlash(path string) string {
 
 func (r *Router) add(method, path, name string, h HandlerFunc) *Route {
 	path = normalizePathSlash(path)
-	r.insert(method, path, h)
-
 	route := &Route{
 		Method: method,
 		Path:   path,
 		Name:   name,
 	}
+	r.insert(method, path, h, route)
+
 	r.routes[method+path] = route
 	return route
 }
 
 // Add registers a new route for method and path with matching handler.
 func (r *Router) Add(method, path string, h HandlerFunc) {
-	r.insert(method, normalizePathSlash(path), h)
+	r.insert(method, normalizePathSlash(path), h, nil)
 }
 
-func (r *Router) insert(method, path string, h HandlerFunc) { //NOSONAR
+func (r *Router) insert(method, path string, h HandlerFunc, route *Route) { //NOSONAR
 	path = normalizePathSlash(path)
 	pnames := []string{} // Param names
 	ppath := path        // Pristine path
+	r.syncCaseFolding()
+	path = r.foldPath(path)
 
 	if h == nil && r.echo.Logger != nil {
 		// : in future we should return error
@@ -222,28 +391,32 @@ func (r *Router) insert(method, path string, h HandlerFunc) { //NOSONAR
 			j := i + 1
 
 			r.insertNode(method, path[:i], staticKind, routeMethod{})
-			for ; i < lcpIndex && path[i] != '/'; i++ {
-				fmt.Print("123")
+			for ; i < lcpIndex && path[i] != '/' && path[i] != '<'; i++ {
 			}
-
 			pnames = append(pnames, path[j:i])
+
+			// constraint (i.e. `<int>` in `:id<int>`) is kept in path as it is part of the param node prefix
+			constraintLen := 0
+			if i < lcpIndex && path[i] == '<' {
+				constraintLen = paramConstraintLen(path[i:], ppath)
+			}
 			path = path[:j] + path[i:]
-			i, lcpIndex = j, len(path)
+			i, lcpIndex = j+constraintLen, len(path)
 
 			if i == lcpIndex {
 				// path node is last fragment of route path. ie. `/users/:id`
-				r.insertNode(method, path[:i], paramKind, routeMethod{ppath: ppath, pnames: pnames, handler: h})
+				r.insertNode(method, path[:i], paramKind, routeMethod{ppath: ppath, pnames: pnames, handler: h, route: route})
 			} else {
 				r.insertNode(method, path[:i], paramKind, routeMethod{})
 			}
 		} else if path[i] == '*' {
 			r.insertNode(method, path[:i], staticKind, routeMethod{})
 			pnames = append(pnames, "*")
-			r.insertNode(method, path[:i+1], anyKind, routeMethod{ppath: ppath, pnames: pnames, handler: h})
+			r.insertNode(method, path[:i+1], anyKind, routeMethod{ppath: ppath, pnames: pnames, handler: h, route: route})
 		}
 	}
 
-	r.insertNode(method, path, staticKind, routeMethod{ppath: ppath, pnames: pnames, handler: h})
+	r.insertNode(method, path, staticKind, routeMethod{ppath: ppath, pnames: pnames, handler: h, route: route})
 }
 
 func (r *Router) insertNode(method, path string, t kind, rm routeMethod) { //NOSONAR
@@ -270,7 +443,6 @@ func (r *Router) insertNode(method, path string, t kind, rm routeMethod) { //NOS
 			max = searchLen
 		}
 		for ; lcpLen < max && search[lcpLen] == currentNode.prefix[lcpLen]; lcpLen++ {
-			fmt.Print("123")
 		}
 
 		if lcpLen == 0 {
@@ -283,7 +455,7 @@ func (r *Router) insertNode(method, path string, t kind, rm routeMethod) { //NOS
 				currentNode.paramsCount = len(rm.pnames)
 				currentNode.originalPath = rm.ppath
 			}
-			currentNode.isLeaf = currentNode.staticChildren == nil && currentNode.paramChild == nil && currentNode.anyChild == nil
+			currentNode.isLeaf = currentNode.staticChildren == nil && currentNode.paramChildren == nil && currentNode.anyChild == nil
 		} else if lcpLen < prefixLen {
 			// Split node into two before we insert new node.
 			// This happens when we are inserting path that is submatch of any existing inserted paths.
@@ -299,13 +471,16 @@ func (r *Router) insertNode(method, path string, t kind, rm routeMethod) { //NOS
 				currentNode.originalPath,
 				currentNode.methods,
 				currentNode.paramsCount,
+				currentNode.paramChildren,
+				currentNode.anyChild,
+				currentNode.notFoundHandler,
 			)
 			// Update parent path for all children to new node
 			for _, child := range currentNode.staticChildren {
 				child.parent = n
 			}
-			if currentNode.paramChild != nil {
-				currentNode.paramChild.parent = n
+			for _, child := range currentNode.paramChildren {
+				child.parent = n
 			}
 			if currentNode.anyChild != nil {
 				currentNode.anyChild.parent = n
@@ -319,7 +494,7 @@ func (r *Router) insertNode(method, path string, t kind, rm routeMethod) { //NOS
 			currentNode.originalPath = ""
 			currentNode.methods = new(routeMethods)
 			currentNode.paramsCount = 0
-			currentNode.paramChild = nil
+			currentNode.paramChildren = nil
 			currentNode.anyChild = nil
 			currentNode.isLeaf = false
 			currentNode.isHandler = false
@@ -338,7 +513,7 @@ func (r *Router) insertNode(method, path string, t kind, rm routeMethod) { //NOS
 				}
 			} else {
 				// Create child node
-				n = newNode(t, search[lcpLen:], currentNode, nil, "", new(routeMethods), 0)
+				n = newNode(t, search[lcpLen:], currentNode, nil, "", new(routeMethods), 0, nil, nil, nil)
 				if rm.handler != nil {
 					n.addMethod(method, &rm)
 					n.paramsCount = len(rm.pnames)
@@ -347,17 +522,17 @@ func (r *Router) insertNode(method, path string, t kind, rm routeMethod) { //NOS
 				// Only Static children could reach here
 				currentNode.addStaticChild(n)
 			}
-			currentNode.isLeaf = currentNode.staticChildren == nil && currentNode.paramChild == nil && currentNode.anyChild == nil
+			currentNode.isLeaf = currentNode.staticChildren == nil && currentNode.paramChildren == nil && currentNode.anyChild == nil
 		} else if lcpLen < searchLen {
 			search = search[lcpLen:]
-			c := currentNode.findChildWithLabel(search[0])
+			c := currentNode.findChild(search)
 			if c != nil {
 				// Go deeper
 				currentNode = c
 				continue
 			}
 			// Create child node
-			n := newNode(t, search, currentNode, nil, rm.ppath, new(routeMethods), 0)
+			n := newNode(t, search, currentNode, nil, rm.ppath, new(routeMethods), 0, nil, nil, nil)
 			if rm.handler != nil {
 				n.addMethod(method, &rm)
 				n.paramsCount = len(rm.pnames)
@@ -367,11 +542,12 @@ func (r *Router) insertNode(method, path string, t kind, rm routeMethod) { //NOS
 			case staticKind:
 				currentNode.addStaticChild(n)
 			case paramKind:
-				currentNode.paramChild = n
+				n.constraint = newParamConstraint(search[1:])
+				currentNode.addParamChild(n)
 			case anyKind:
 				currentNode.anyChild = n
 			}
-			currentNode.isLeaf = currentNode.staticChildren == nil && currentNode.paramChild == nil && currentNode.anyChild == nil
+			currentNode.isLeaf = currentNode.staticChildren == nil && currentNode.paramChildren == nil && currentNode.anyChild == nil
 		} else {
 			// Node already exists
 			if rm.handler != nil {
@@ -392,18 +568,24 @@ func newNode(
 	originalPath string,
 	methods *routeMethods,
 	paramsCount int,
+	paramChildren children,
+	anyChildren *node,
+	notFoundHandler *routeMethod,
 ) *node {
 	return &node{
-		kind:           t,
-		label:          pre[0],
-		prefix:         pre,
-		parent:         p,
-		staticChildren: sc,
-		originalPath:   originalPath,
-		methods:        methods,
-		paramsCount:    paramsCount,
-		isLeaf:         sc == nil,
-		isHandler:      methods.isHandler(),
+		kind:            t,
+		label:           pre[0],
+		prefix:          pre,
+		parent:          p,
+		staticChildren:  sc,
+		originalPath:    originalPath,
+		methods:         methods,
+		paramsCount:     paramsCount,
+		paramChildren:   paramChildren,
+		anyChild:        anyChildren,
+		isLeaf:          sc == nil && paramChildren == nil && anyChildren == nil,
+		isHandler:       methods.isHandler(),
+		notFoundHandler: notFoundHandler,
 	}
 }
 
@@ -420,14 +602,57 @@ func (n *node) findStaticChild(l byte) *node {
 	return nil
 }
 
-func (n *node) findChildWithLabel(l byte) *node {
-	if c := n.findStaticChild(l); c != nil {
+func (n *node) addParamChild(c *node) {
+	if c.constraint != nil && len(n.paramChildren) > 0 && n.paramChildren[len(n.paramChildren)-1].constraint == nil {
+		// keep param without constraint last, so it is checked after more specific params
+		last := len(n.paramChildren) - 1
+		n.paramChildren = append(n.paramChildren[:last], c, n.paramChildren[last])
+		return
+	}
+	n.paramChildren = append(n.paramChildren, c)
+}
+
+// findParamChild returns param child with given prefix. Prefix of param node is `:` followed by optional
+// constraint i.e. `:<int>`.
+func (n *node) findParamChild(prefix string) *node {
+	for _, c := range n.paramChildren {
+		if c.prefix == prefix {
+			return c
+		}
+	}
+	return nil
+}
+
+// matchParamChild returns first param child, starting from index `from`, which constraint accepts the param value
+// at the start of search. Constraints are matched against values, the not case-folded counterpart of search.
+// Returns index of the child and length of the param value.
+func (n *node) matchParamChild(search, values string, from int) (index int, valueLen int) {
+	for index = from; index < len(n.paramChildren); index++ {
+		child := n.paramChildren[index]
+		valueLen = len(search)
+		if !child.isLeaf {
+			if i := strings.IndexByte(search, '/'); i != -1 {
+				valueLen = i
+			}
+		}
+		if child.constraint == nil || child.constraint.match(values[:valueLen]) {
+			return index, valueLen
+		}
+	}
+	return -1, 0
+}
+
+func (n *node) findChild(search string) *node {
+	if c := n.findStaticChild(search[0]); c != nil {
 		return c
 	}
-	if l == paramLabel {
-		return n.paramChild
+	if search[0] == paramLabel {
+		if i := strings.IndexByte(search, '/'); i != -1 {
+			search = search[:i]
+		}
+		return n.findParamChild(search)
 	}
-	if l == anyLabel {
+	if search[0] == anyLabel {
 		return n.anyChild
 	}
 	return nil
@@ -475,6 +700,67 @@ func (n *node) addMethod(method string, h *routeMethod) {
 	n.isHandler = true
 }
 
+func (n *node) removeMethod(method string) {
+	switch method {
+	case http.MethodConnect:
+		n.methods.connect = nil
+	case http.MethodDelete:
+		n.methods.delete = nil
+	case http.MethodGet:
+		n.methods.get = nil
+	case http.MethodHead:
+		n.methods.head = nil
+	case http.MethodOptions:
+		n.methods.options = nil
+	case http.MethodPatch:
+		n.methods.patch = nil
+	case http.MethodPost:
+		n.methods.post = nil
+	case PROPFIND:
+		n.methods.propfind = nil
+	case http.MethodPut:
+		n.methods.put = nil
+	case http.MethodTrace:
+		n.methods.trace = nil
+	case REPORT:
+		n.methods.report = nil
+	default:
+		delete(n.methods.anyOther, method)
+	}
+	n.methods.updateAllowHeader()
+	n.isHandler = n.methods.isHandler()
+}
+
+// clone returns deep copy of the node and its children. Route methods (handlers) are shared.
+func (n *node) clone(parent *node) *node {
+	c := *n
+	c.parent = parent
+	methods := *n.methods
+	if n.methods.anyOther != nil {
+		methods.anyOther = make(map[string]*routeMethod, len(n.methods.anyOther))
+		for k, v := range n.methods.anyOther {
+			methods.anyOther[k] = v
+		}
+	}
+	c.methods = &methods
+	if n.staticChildren != nil {
+		c.staticChildren = make(children, len(n.staticChildren))
+		for i, child := range n.staticChildren {
+			c.staticChildren[i] = child.clone(&c)
+		}
+	}
+	if n.paramChildren != nil {
+		c.paramChildren = make(children, len(n.paramChildren))
+		for i, child := range n.paramChildren {
+			c.paramChildren[i] = child.clone(&c)
+		}
+	}
+	if n.anyChild != nil {
+		c.anyChild = n.anyChild.clone(&c)
+	}
+	return &c
+}
+
 func (n *node) findMethod(method string) *routeMethod {
 	switch method {
 	case http.MethodConnect:
@@ -504,6 +790,82 @@ func (n *node) findMethod(method string) *routeMethod {
 	}
 }
 
+// findHandler returns handler for method. When automatic HEAD is enabled GET handler is returned for HEAD method if
+// node does not have HEAD handler.
+func (n *node) findHandler(method string, autoHead bool) *routeMethod {
+	h := n.findMethod(method)
+	if h == nil && autoHead {
+		return n.methods.get
+	}
+	return h
+}
+
+// allowHeader returns value for `Allow` header adjusted to automatic HEAD and OPTIONS settings.
+func (r *Router) allowHeader(m *routeMethods) string {
+	allow := m.allowHeader
+	if r.echo.DisableAutoOptions && m.options == nil {
+		allow = strings.TrimPrefix(strings.TrimPrefix(allow, http.MethodOptions), ", ")
+	}
+	if !r.echo.DisableAutoHead && m.get != nil && m.head == nil {
+		allow += ", " + http.MethodHead
+	}
+	return allow
+}
+
+// headHandler wraps GET handler to serve HEAD request. Response body written by handler is discarded but headers are
+// sent and `Content-Length` is set to size of discarded body when handler did not set it.
+func headHandler(h HandlerFunc) HandlerFunc {
+	return func(c Context) error {
+		res := c.Response()
+		hw := &headResponseWriter{ResponseWriter: res.Writer}
+		res.Writer = hw
+		err := h(c)
+		res.Writer = hw.ResponseWriter
+
+		if !hw.wroteHeader && hw.size == 0 {
+			return err
+		}
+		if hw.size > 0 && hw.Header().Get(HeaderContentLength) == "" {
+			hw.Header().Set(HeaderContentLength, strconv.FormatInt(hw.size, 10))
+		}
+		status := hw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		hw.ResponseWriter.WriteHeader(status)
+		return err
+	}
+}
+
+// headResponseWriter delays writing status code until handler has finished and discards written body.
+type headResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+func (w *headResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.status = code
+	w.wroteHeader = true
+}
+
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	w.size += int64(len(b))
+	return len(b), nil
+}
+
+// Flush does nothing as body is discarded and headers are sent after handler has finished.
+func (w *headResponseWriter) Flush() {}
+
+// Unwrap returns the original http.ResponseWriter so ResponseController can hijack the connection or set deadlines.
+func (w *headResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func optionsMethodHandler(allowMethods string) func(c Context) error {
 	return func(c Context) error {
 		// Note: we are not handling most of the CORS headers here. CORS is handled by CORS middleware
@@ -522,9 +884,20 @@ func optionsMethodHandler(allowMethods string) func(c Context) error {
 // - Get context from `Echo#AcquireContext()`
 // - Reset it `Context#Reset()`
 // - Return it `Echo#ReleaseContext()`.
-func (r *Router) Find(method, path string, c Context) { //NOSONAR
-	ctx := c.(*context)
+func (r *Router) Find(method, path string, c Context) {
+	r.syncCaseFolding()
+	routePath := path
+	if r.folded {
+		routePath = toLowerASCII(path)
+	}
+	r.find(method, routePath, path, c.(*context))
+}
+
+// find matches path and takes param values from values. Values must have the same length as path and differ only
+// by case of ASCII letters (see case-insensitive path normalization).
+func (r *Router) find(method, path, values string, ctx *context) { //NOSONAR
 	currentNode := r.tree // Current node as root
+	autoHead := method == http.MethodHead && !r.echo.DisableAutoHead
 
 	var (
 		previousBestMatchNode *node
@@ -535,6 +908,9 @@ func (r *Router) Find(method, path string, c Context) { //NOSONAR
 		searchIndex = 0
 		paramIndex  int           // Param counter
 		paramValues = ctx.pvalues // Use the internal slice so the interface can keep the illusion of a dynamic slice
+		// paramChildIndex is index of the param child to check next. When backtracking from param node to its parent,
+		// next param sibling is checked before any node.
+		paramChildIndex int
 	)
 
 	// Backtracking is needed when a dead end (leaf node) is reached in the router tree.
@@ -553,6 +929,14 @@ func (r *Router) Find(method, path string, c Context) { //NOSONAR
 		} else {
 			nextNodeKind = previous.kind + 1
 		}
+		if valid && previous.kind == paramKind {
+			for i, child := range currentNode.paramChildren {
+				if child == previous && i+1 < len(currentNode.paramChildren) {
+					nextNodeKind = paramKind
+					paramChildIndex = i + 1
+				}
+			}
+		}
 
 		if fromKind == staticKind {
 			// when backtracking is done from static kind block we did not change search so nothing to restore
@@ -594,7 +978,6 @@ func (r *Router) Find(method, path string, c Context) { //NOSONAR
 				max = searchLen
 			}
 			for ; lcpLen < max && search[lcpLen] == currentNode.prefix[lcpLen]; lcpLen++ {
-				fmt.Print("123")
 			}
 		}
 
@@ -627,7 +1010,7 @@ func (r *Router) Find(method, path string, c Context) { //NOSONAR
 				if previousBestMatchNode == nil {
 					previousBestMatchNode = currentNode
 				}
-				if h := currentNode.findMethod(method); h != nil {
+				if h := currentNode.findHandler(method, autoHead); h != nil {
 					matchedRouteMethod = h
 					break
 				}
@@ -647,40 +1030,37 @@ func (r *Router) Find(method, path string, c Context) { //NOSONAR
 
 	Param:
 		// Param node
-		if child := currentNode.paramChild; search != "" && child != nil {
-			currentNode = child
-			i := 0
-			l := len(search)
-			if currentNode.isLeaf {
-				// when param node does not have any children (path param is last piece of route path) then param node should
-				// act similarly to any node - consider all remaining search as match
-				i = l
-			} else {
-				for ; i < l && search[i] != '/'; i++ {
-					fmt.Print("123")
-				}
+		if search != "" && len(currentNode.paramChildren) > 0 {
+			// when param node does not have any children (path param is last piece of route path) then param node should
+			// act similarly to any node - consider all remaining search as match.
+			// Param children which constraint does not accept the value are skipped.
+			childIndex, i := currentNode.matchParamChild(search, values[searchIndex:], paramChildIndex)
+			if childIndex != -1 {
+				paramChildIndex = 0
+				currentNode = currentNode.paramChildren[childIndex]
+
+				paramValues[paramIndex] = values[searchIndex : searchIndex+i]
+				paramIndex++
+				search = search[i:]
+				searchIndex = searchIndex + i
+				continue
 			}
-
-			paramValues[paramIndex] = search[:i]
-			paramIndex++
-			search = search[i:]
-			searchIndex = searchIndex + i
-			continue
 		}
+		paramChildIndex = 0
 
 	Any:
 		// Any node
 		if child := currentNode.anyChild; child != nil {
 			// If any node is found, use remaining path for paramValues
 			currentNode = child
-			paramValues[currentNode.paramsCount-1] = search
+			paramValues[currentNode.paramsCount-1] = values[searchIndex:]
 
 			// update indexes/search in case we need to backtrack when no handler match is found
 			paramIndex++
 			searchIndex += +len(search)
 			search = ""
 
-			if h := currentNode.findMethod(method); h != nil {
+			if h := currentNode.findHandler(method, autoHead); h != nil {
 				matchedRouteMethod = h
 				break
 			}
@@ -720,6 +1100,10 @@ func (r *Router) Find(method, path string, c Context) { //NOSONAR
 		rPath = matchedRouteMethod.ppath
 		rPNames = matchedRouteMethod.pnames
 		ctx.handler = matchedRouteMethod.handler
+		ctx.route = matchedRouteMethod.route
+		if autoHead && matchedRouteMethod == currentNode.methods.get {
+			ctx.handler = headHandler(ctx.handler)
+		}
 	} else {
 		// use previous match as basis. although we have no matching handler we have path match.
 		// so we can send http.StatusMethodNotAllowed (405) instead of http.StatusNotFound (404)
@@ -732,14 +1116,112 @@ func (r *Router) Find(method, path string, c Context) { //NOSONAR
 			rPath = currentNode.notFoundHandler.ppath
 			rPNames = currentNode.notFoundHandler.pnames
 			ctx.handler = currentNode.notFoundHandler.handler
+			ctx.route = currentNode.notFoundHandler.route
 		} else if currentNode.isHandler {
-			ctx.Set(ContextKeyHeaderAllow, currentNode.methods.allowHeader)
+			allow := r.allowHeader(currentNode.methods)
+			ctx.Set(ContextKeyHeaderAllow, allow)
 			ctx.handler = MethodNotAllowedHandler
-			if method == http.MethodOptions {
-				ctx.handler = optionsMethodHandler(currentNode.methods.allowHeader)
+			if h := r.echo.HTTPMethodNotAllowedHandler; h != nil {
+				ctx.handler = func(c Context) error {
+					c.Response().Header().Set(HeaderAllow, allow)
+					return h(c, strings.Split(allow, ", "))
+				}
+			}
+			if method == http.MethodOptions && !r.echo.DisableAutoOptions {
+				ctx.handler = optionsMethodHandler(allow)
 			}
 		}
 	}
 	ctx.path = rPath
 	ctx.pnames = rPNames
 }
+
+// paramConstraint restricts values accepted by path param. Constraint is defined after param name in angle brackets
+// and is either name of the built-in constraint or regular expression that must match the whole value:
+//
+//	`:id<int>`, `:id<uint>`, `:name<alpha>`, `:code<alnum>`, `:uuid<uuid>`, `:slug<[a-z0-9-]+>`
+//
+// Constraint can not contain `/`.
+type paramConstraint struct {
+	match func(value string) bool
+}
+
+var paramConstraintUUID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+var builtinParamConstraints = map[string]func(value string) bool{
+	"int": func(value string) bool {
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	},
+	"uint": func(value string) bool {
+		_, err := strconv.ParseUint(value, 10, 64)
+		return err == nil
+	},
+	"alpha": func(value string) bool {
+		return value != "" && strings.IndexFunc(value, func(r rune) bool {
+			return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z')
+		}) == -1
+	},
+	"alnum": func(value string) bool {
+		return value != "" && strings.IndexFunc(value, func(r rune) bool {
+			return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+		}) == -1
+	},
+	"uuid": paramConstraintUUID.MatchString,
+}
+
+// newParamConstraint creates constraint from its definition i.e. `<int>`. Returns nil for empty definition.
+func newParamConstraint(definition string) *paramConstraint {
+	if definition == "" {
+		return nil
+	}
+	expr := definition[1 : len(definition)-1]
+	if match, ok := builtinParamConstraints[expr]; ok {
+		return &paramConstraint{match: match}
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("echo: invalid route param constraint %q: %v", definition, err))
+	}
+	return &paramConstraint{match: re.MatchString}
+}
+
+// paramConstraintLen returns length of the constraint definition at the start of path i.e. `<int>/users`. Angle
+// brackets may be nested (i.e. regexp named groups). Brackets inside character classes (`[<>]`) and escaped brackets
+// (`\<`) are not counted. Panics when definition is not terminated before `/` or end of the path.
+func paramConstraintLen(path string, routePath string) int {
+	depth := 0
+	classStart := -1 // index of `[` opening current character class, -1 when outside of class
+	for i := 0; i < len(path) && path[i] != '/'; i++ {
+		c := path[i]
+		if c == '\\' {
+			if i+1 < len(path) && path[i+1] != '/' {
+				i++ // escaped character is never a bracket
+			}
+			continue
+		}
+		if classStart != -1 {
+			// `]` right after `[` or `[^` is literal
+			if c == ']' && i > classStart+1 && !(i == classStart+2 && path[classStart+1] == '^') {
+				classStart = -1
+			}
+			continue
+		}
+		switch c {
+		case '[':
+			classStart = i
+			continue
+		case '<':
+			depth++
+		case '>':
+			depth--
+		}
+		if depth == 0 {
+			if i+1 < len(path) && path[i+1] != '/' {
+				break
+			}
+			return i + 1
+		}
+	}
+	panic(fmt.Sprintf("echo: invalid route param constraint in path %q", routePath))
+}
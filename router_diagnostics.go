@@ -0,0 +1,361 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrRouteConflict is returned by `Router#CheckRoute()` when route with the same method and path (param names
+	// are ignored) is already registered.
+	ErrRouteConflict = errors.New("route conflict")
+	// ErrInvalidRoute is returned by `Router#CheckRoute()` when route path is ambiguous.
+	ErrInvalidRoute = errors.New("invalid route")
+)
+
+// RouterNode describes node of the router tree. See `Router#Tree()`.
+type RouterNode struct {
+	// Kind is kind of the node: `static`, `param` or `any`.
+	Kind string `json:"kind"`
+	// Prefix is part of the path the node matches.
+	Prefix string `json:"prefix"`
+	// Methods are handlers registered to the node.
+	Methods []RouterNodeMethod `json:"methods,omitempty"`
+	// Children are child nodes in order they are checked when matching (static, param and any).
+	Children []*RouterNode `json:"children,omitempty"`
+}
+
+// RouterNodeMethod describes handler registered to the router node.
+type RouterNodeMethod struct {
+	// Method is HTTP method of the route or `RouteNotFound`.
+	Method string `json:"method"`
+	// Path is original path route was registered with.
+	Path string `json:"path"`
+	// Name is route name. Empty for handlers added with `Router#Add()`.
+	Name string `json:"name,omitempty"`
+}
+
+// RouteLookup is result of the dry-run route lookup. See `Echo#Lookup()`.
+type RouteLookup struct {
+	// Found is true when handler is registered for the method and path.
+	Found bool `json:"found"`
+	// Route is matched route. It is route registered with `RouteNotFound` when 404 handler matched. Nil when no
+	// route matched or handler was added with `Router#Add()`.
+	Route *Route `json:"route,omitempty"`
+	// Path is matched route path, same as `Context#Path()` would return.
+	Path string `json:"path"`
+	// ParamNames are path param names of the matched route.
+	ParamNames []string `json:"param_names,omitempty"`
+	// ParamValues are path param values extracted from the path.
+	ParamValues []string `json:"param_values,omitempty"`
+	// Allow is value of `Allow` header when path matched but method did not (405 Method Not Allowed).
+	Allow string `json:"allow,omitempty"`
+}
+
+func (k kind) String() string {
+	switch k {
+	case paramKind:
+		return "param"
+	case anyKind:
+		return "any"
+	default:
+		return "static"
+	}
+}
+
+// CheckRoute checks if route for method and path can be added without conflicts. Returns error wrapping
+// `ErrRouteConflict` when route with the same method and path is already registered (routes with paths that
+// differ only by param names are the same route, i.e. `/users/:id` and `/users/:name`) or when route overlaps with
+// already registered route of the same method:
+//   - param without constraint and param with constraint at the same position match the same values, i.e.
+//     `/users/:name` and `/users/:id<int>`,
+//   - param without constraint at the end of the path shadows wildcard at the same position, i.e. `/files/:name`
+//     and `/files/*`.
+//
+// Returns error wrapping `ErrInvalidRoute` when path has duplicate param names or wildcard `*` that is not at the
+// end of the path.
+func (r *Router) CheckRoute(method, path string) error {
+	path = normalizePathSlash(path)
+	routeNames := routeParamNames(path)
+	if containsParamName(routeNames, "*") && path[len(path)-1] != '*' {
+		return fmt.Errorf("%w: %s %s has wildcard that is not at the end of the path", ErrInvalidRoute, method, path)
+	}
+	names := map[string]bool{}
+	for _, name := range routeNames {
+		if names[name] {
+			return fmt.Errorf("%w: %s %s has duplicate param name %q", ErrInvalidRoute, method, path, name)
+		}
+		names[name] = true
+	}
+
+	r.syncCaseFolding()
+	if n := r.findNode(r.foldPath(treePath(path))); n != nil {
+		existing := n.notFoundHandler
+		if method != RouteNotFound {
+			existing = n.findMethod(method)
+		}
+		if existing != nil && existing.handler != nil {
+			if existing.ppath == path {
+				return fmt.Errorf("%w: %s %s is already registered", ErrRouteConflict, method, path)
+			}
+			return fmt.Errorf("%w: %s %s conflicts with already registered %s %s", ErrRouteConflict, method, path, method, existing.ppath)
+		}
+	}
+	if method == RouteNotFound {
+		return nil
+	}
+
+	segments := routeSegments(path)
+	for _, existing := range r.tree.routePaths(method, nil) {
+		if overlap := routeOverlap(segments, routeSegments(existing)); overlap != "" {
+			return fmt.Errorf("%w: %s %s %s already registered %s %s", ErrRouteConflict, method, path, overlap, method, existing)
+		}
+	}
+	return nil
+}
+
+// isGroupNotFoundRoute checks if RouteNotFound route registered for path in router was registered by `Group#Use()`.
+func (e *Echo) isGroupNotFoundRoute(router *Router, path string) bool {
+	n := router.findNode(router.foldPath(treePath(normalizePathSlash(path))))
+	if n == nil || n.notFoundHandler == nil || n.notFoundHandler.route == nil {
+		return false
+	}
+	_, ok := e.groupNotFoundRoutes.Load(n.notFoundHandler.route)
+	return ok
+}
+
+// routeSegment is part of the route path: static text, param (value is param constraint) or wildcard.
+type routeSegment struct {
+	kind  kind
+	value string
+}
+
+// routeSegments splits route path into static, param and wildcard segments. Param names are dropped.
+func routeSegments(path string) []routeSegment {
+	segments := make([]routeSegment, 0, 4)
+	static := func(s string) {
+		if l := len(segments); l > 0 && segments[l-1].kind == staticKind {
+			segments[l-1].value += s
+			return
+		}
+		segments = append(segments, routeSegment{kind: staticKind, value: s})
+	}
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == ':':
+			static(":")
+			i++
+		case path[i] == ':':
+			for i+1 < len(path) && path[i+1] != '/' && path[i+1] != '<' {
+				i++
+			}
+			constraint := ""
+			if i+1 < len(path) && path[i+1] == '<' {
+				l := paramConstraintLen(path[i+1:], path)
+				constraint = path[i+1 : i+1+l]
+				i += l
+			}
+			segments = append(segments, routeSegment{kind: paramKind, value: constraint})
+		case path[i] == '*':
+			segments = append(segments, routeSegment{kind: anyKind})
+		default:
+			static(path[i : i+1])
+		}
+	}
+	return segments
+}
+
+// routeOverlap describes how route with segments overlaps with existing route. Returns empty string when routes
+// do not overlap.
+func routeOverlap(segments, existing []routeSegment) string {
+	i := 0
+	for i < len(segments) && i < len(existing) && segments[i] == existing[i] {
+		i++
+	}
+	if i == len(segments) || i == len(existing) {
+		return ""
+	}
+	a, b := segments[i], existing[i]
+	switch {
+	case a.kind == paramKind && b.kind == paramKind && (a.value == "") != (b.value == "") &&
+		equalRouteSegments(segments[i+1:], existing[i+1:]):
+		return "is ambiguous with"
+	case a.kind == paramKind && a.value == "" && i == len(segments)-1 && b.kind == anyKind:
+		return "shadows"
+	case b.kind == paramKind && b.value == "" && i == len(existing)-1 && a.kind == anyKind:
+		return "is shadowed by"
+	}
+	return ""
+}
+
+func equalRouteSegments(a, b []routeSegment) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// routePaths appends original paths of routes registered for the method in this node and its children.
+func (n *node) routePaths(method string, paths []string) []string {
+	if rm := n.findMethod(method); rm != nil && rm.handler != nil {
+		paths = append(paths, rm.ppath)
+	}
+	for _, c := range n.staticChildren {
+		paths = c.routePaths(method, paths)
+	}
+	for _, c := range n.paramChildren {
+		paths = c.routePaths(method, paths)
+	}
+	if n.anyChild != nil {
+		paths = n.anyChild.routePaths(method, paths)
+	}
+	return paths
+}
+
+// Tree returns description of the router tree. Tree can be serialized to JSON.
+func (r *Router) Tree() *RouterNode {
+	return r.tree.describe()
+}
+
+// Dump writes router tree as indented text. Each line contains node prefix, kind and registered methods with
+// their original paths.
+//
+// Example output:
+//
+//	/ (static) [GET /]
+//	  users (static) [GET /users, POST /users]
+//	    / (static)
+//	      : (param) [GET /users/:id]
+func (r *Router) Dump(w io.Writer) error {
+	var buf strings.Builder
+	r.Tree().dump(&buf, 0)
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// DumpJSON writes router tree as JSON.
+func (r *Router) DumpJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r.Tree())
+}
+
+// Lookup does dry-run of routing for method and path and reports which route would handle the request. Path is
+// normalized according to `Echo#PathNormalization` (without redirect). Handler is not executed.
+func (r *Router) Lookup(method, path string) RouteLookup {
+	if i := strings.IndexByte(path, '?'); i != -1 {
+		path = path[:i]
+	}
+	r.syncCaseFolding()
+	c := &context{pvalues: make([]string, r.echo.maxParamCount())}
+	routePath, values, _ := r.echo.PathNormalization.routePath(path)
+	r.find(method, routePath, values, c)
+
+	result := RouteLookup{
+		Route: c.route,
+		Path:  c.path,
+	}
+	if len(c.pnames) > 0 {
+		result.ParamNames = c.pnames
+		result.ParamValues = append([]string(nil), c.pvalues[:len(c.pnames)]...)
+	}
+	if allow, ok := c.store[ContextKeyHeaderAllow].(string); ok {
+		result.Allow = allow
+	}
+	result.Found = c.route != nil && c.route.Method != RouteNotFound
+	return result
+}
+
+// Lookup does dry-run of routing for method and path in the default router and reports which route would handle
+// the request. Handler and middlewares are not executed.
+// In case when Echo serves multiple hosts/domains use `e.Routers()["domain2.site"].Lookup()` for specific host.
+func (e *Echo) Lookup(method, path string) RouteLookup {
+	return e.currentRouting().router.Lookup(method, path)
+}
+
+func (n *node) describe() *RouterNode {
+	d := &RouterNode{
+		Kind:   n.kind.String(),
+		Prefix: n.prefix,
+	}
+	for _, method := range n.methodNames() {
+		if rm := n.findMethod(method); rm != nil {
+			d.Methods = append(d.Methods, rm.describe(method))
+		}
+	}
+	if n.notFoundHandler != nil {
+		d.Methods = append(d.Methods, n.notFoundHandler.describe(RouteNotFound))
+	}
+	for _, c := range n.staticChildren {
+		d.Children = append(d.Children, c.describe())
+	}
+	for _, c := range n.paramChildren {
+		d.Children = append(d.Children, c.describe())
+	}
+	if n.anyChild != nil {
+		d.Children = append(d.Children, n.anyChild.describe())
+	}
+	return d
+}
+
+func (rm *routeMethod) describe(method string) RouterNodeMethod {
+	m := RouterNodeMethod{Method: method, Path: rm.ppath}
+	if rm.route != nil {
+		m.Name = rm.route.Name
+	}
+	return m
+}
+
+// methodNames returns methods that have handler in deterministic order.
+func (n *node) methodNames() []string {
+	names := make([]string, 0)
+	for _, method := range []string{
+		http.MethodConnect, http.MethodDelete, http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPatch,
+		http.MethodPost, PROPFIND, http.MethodPut, http.MethodTrace, REPORT,
+	} {
+		if n.findMethod(method) != nil {
+			names = append(names, method)
+		}
+	}
+	other := make([]string, 0, len(n.methods.anyOther))
+	for method := range n.methods.anyOther {
+		other = append(other, method)
+	}
+	sort.Strings(other)
+	return append(names, other...)
+}
+
+func (n *RouterNode) dump(buf *strings.Builder, depth int) {
+	buf.WriteString(strings.Repeat("  ", depth))
+	buf.WriteString(n.Prefix)
+	buf.WriteString(" (")
+	buf.WriteString(n.Kind)
+	buf.WriteString(")")
+	if len(n.Methods) > 0 {
+		buf.WriteString(" [")
+		for i, m := range n.Methods {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(m.Method)
+			buf.WriteString(" ")
+			buf.WriteString(m.Path)
+		}
+		buf.WriteString("]")
+	}
+	buf.WriteString("\n")
+	for _, c := range n.Children {
+		c.dump(buf, depth+1)
+	}
+}
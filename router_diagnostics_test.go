@@ -0,0 +1,294 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterCheckRoute(t *testing.T) {
+	e := New()
+	h := func(c Context) error { return nil }
+	e.GET("/users/:id", h)
+	e.GET("/files/*", h)
+	e.GET("/docs/:name", h)
+	e.GET("/nums/:n<int>", h)
+	e.RouteNotFound("/api/*", h)
+
+	var testCases = []struct {
+		name        string
+		whenMethod  string
+		whenPath    string
+		expectErr   error
+		expectError string
+	}{
+		{
+			name:       "ok, new route",
+			whenMethod: http.MethodGet,
+			whenPath:   "/users/:id/files",
+		},
+		{
+			name:       "ok, same path different method",
+			whenMethod: http.MethodPost,
+			whenPath:   "/users/:id",
+		},
+		{
+			name:       "ok, wildcard in param constraint",
+			whenMethod: http.MethodGet,
+			whenPath:   "/tags/:tag<[a-z]*>",
+		},
+		{
+			name:       "ok, params with different constraints",
+			whenMethod: http.MethodGet,
+			whenPath:   "/nums/:id<uuid>",
+		},
+		{
+			name:       "ok, param with children does not shadow wildcard",
+			whenMethod: http.MethodGet,
+			whenPath:   "/files/:name/meta",
+		},
+		{
+			name:       "ok, overlapping route with different method",
+			whenMethod: http.MethodPost,
+			whenPath:   "/files/:name",
+		},
+		{
+			name:        "nok, constrained param is ambiguous with param",
+			whenMethod:  http.MethodGet,
+			whenPath:    "/users/:id<int>",
+			expectErr:   ErrRouteConflict,
+			expectError: "route conflict: GET /users/:id<int> is ambiguous with already registered GET /users/:id",
+		},
+		{
+			name:        "nok, param is ambiguous with constrained param",
+			whenMethod:  http.MethodGet,
+			whenPath:    "/nums/:v",
+			expectErr:   ErrRouteConflict,
+			expectError: "route conflict: GET /nums/:v is ambiguous with already registered GET /nums/:n<int>",
+		},
+		{
+			name:        "nok, trailing param shadows wildcard",
+			whenMethod:  http.MethodGet,
+			whenPath:    "/files/:name",
+			expectErr:   ErrRouteConflict,
+			expectError: "route conflict: GET /files/:name shadows already registered GET /files/*",
+		},
+		{
+			name:        "nok, wildcard is shadowed by trailing param",
+			whenMethod:  http.MethodGet,
+			whenPath:    "/docs/*",
+			expectErr:   ErrRouteConflict,
+			expectError: "route conflict: GET /docs/* is shadowed by already registered GET /docs/:name",
+		},
+		{
+			name:        "nok, duplicate route",
+			whenMethod:  http.MethodGet,
+			whenPath:    "/users/:id",
+			expectErr:   ErrRouteConflict,
+			expectError: "route conflict: GET /users/:id is already registered",
+		},
+		{
+			name:        "nok, route differs only by param name",
+			whenMethod:  http.MethodGet,
+			whenPath:    "/users/:name",
+			expectErr:   ErrRouteConflict,
+			expectError: "route conflict: GET /users/:name conflicts with already registered GET /users/:id",
+		},
+		{
+			name:        "nok, duplicate any route",
+			whenMethod:  http.MethodGet,
+			whenPath:    "files/*",
+			expectErr:   ErrRouteConflict,
+			expectError: "route conflict: GET /files/* is already registered",
+		},
+		{
+			name:        "nok, duplicate not found route",
+			whenMethod:  RouteNotFound,
+			whenPath:    "/api/*",
+			expectErr:   ErrRouteConflict,
+			expectError: "route conflict: echo_route_not_found /api/* is already registered",
+		},
+		{
+			name:        "nok, duplicate param name",
+			whenMethod:  http.MethodGet,
+			whenPath:    "/teams/:id/users/:id",
+			expectErr:   ErrInvalidRoute,
+			expectError: `invalid route: GET /teams/:id/users/:id has duplicate param name "id"`,
+		},
+		{
+			name:        "nok, wildcard in the middle",
+			whenMethod:  http.MethodGet,
+			whenPath:    "/static/*/meta",
+			expectErr:   ErrInvalidRoute,
+			expectError: "invalid route: GET /static/*/meta has wildcard that is not at the end of the path",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := e.Router().CheckRoute(tc.whenMethod, tc.whenPath)
+			if tc.expectError != "" {
+				assert.EqualError(t, err, tc.expectError)
+				assert.True(t, errors.Is(err, tc.expectErr))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEchoStrictRouting(t *testing.T) {
+	e := New()
+	e.StrictRouting = true
+	h := func(c Context) error { return nil }
+	e.GET("/users/:id", h)
+
+	assert.PanicsWithError(t, "echo: route conflict: GET /users/:name conflicts with already registered GET /users/:id", func() {
+		e.GET("/users/:name", h)
+	})
+	assert.PanicsWithError(t, `echo: invalid route: GET /a/:x/:x has duplicate param name "x"`, func() {
+		e.GET("/a/:x/:x", h)
+	})
+
+	// groups re-register their not found routes when middlewares are added
+	assert.NotPanics(t, func() {
+		g := e.Group("/admin", func(next HandlerFunc) HandlerFunc { return next })
+		g.Use(func(next HandlerFunc) HandlerFunc { return next })
+		g.GET("/stats", h)
+		// custom not found route replaces the one registered by group
+		g.RouteNotFound("/*", h)
+	})
+	assert.PanicsWithError(t, "echo: route conflict: echo_route_not_found /admin/* is already registered", func() {
+		e.RouteNotFound("/admin/*", h)
+	})
+	assert.PanicsWithError(t, "echo: route conflict: GET /users/:id<int> is ambiguous with already registered GET /users/:id", func() {
+		e.GET("/users/:id<int>", h)
+	})
+
+	// non strict mode replaces route
+	e.StrictRouting = false
+	assert.NotPanics(t, func() {
+		e.GET("/users/:name", h)
+	})
+	assert.Equal(t, "/users/:name", e.Lookup(http.MethodGet, "/users/1").Path)
+}
+
+func TestRouterDump(t *testing.T) {
+	e := New()
+	h := func(c Context) error { return nil }
+	e.GET("/", h)
+	e.GET("/users", h)
+	e.POST("/users", h)
+	e.GET("/users/:id", h)
+	e.Add("LOCK", "/users/:id", h)
+	e.GET("/static/*", h)
+	e.RouteNotFound("/users/*", h)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, e.Router().Dump(buf))
+	assert.Equal(t, `/ (static) [GET /]
+  users (static) [GET /users, POST /users]
+    / (static)
+      : (param) [GET /users/:id, LOCK /users/:id]
+      * (any) [echo_route_not_found /users/*]
+  static/ (static)
+    * (any) [GET /static/*]
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, e.Router().DumpJSON(buf))
+	var tree RouterNode
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &tree))
+	assert.Equal(t, *e.Router().Tree(), tree)
+	assert.Equal(t, "static", tree.Kind)
+	assert.Equal(t, []RouterNodeMethod{{Method: http.MethodGet, Path: "/", Name: "github.com/jialequ/agent.TestRouterDump.func1"}}, tree.Methods)
+}
+
+func TestEchoLookup(t *testing.T) {
+	e := New()
+	h := func(c Context) error {
+		t.Fatal("handler must not be executed")
+		return nil
+	}
+	e.GET("/users/:id", h)
+	e.GET("/files/*", h)
+	e.RouteNotFound("/api/*", h)
+
+	var testCases = []struct {
+		name        string
+		whenMethod  string
+		whenPath    string
+		expectFound bool
+		expectRoute string
+		expectPath  string
+		expectNames []string
+		expectVals  []string
+		expectAllow string
+	}{
+		{
+			name:        "ok, param route",
+			whenMethod:  http.MethodGet,
+			whenPath:    "/users/1?x=y",
+			expectFound: true,
+			expectRoute: "/users/:id",
+			expectPath:  "/users/:id",
+			expectNames: []string{"id"},
+			expectVals:  []string{"1"},
+		},
+		{
+			name:        "ok, any route",
+			whenMethod:  http.MethodGet,
+			whenPath:    "/files/a/b.txt",
+			expectFound: true,
+			expectRoute: "/files/*",
+			expectPath:  "/files/*",
+			expectNames: []string{"*"},
+			expectVals:  []string{"a/b.txt"},
+		},
+		{
+			name:        "nok, method not allowed",
+			whenMethod:  http.MethodPost,
+			whenPath:    "/users/1",
+			expectPath:  "/users/:id",
+			expectAllow: "OPTIONS, GET, HEAD",
+		},
+		{
+			name:        "nok, not found route",
+			whenMethod:  http.MethodGet,
+			whenPath:    "/api/x",
+			expectRoute: "/api/*",
+			expectPath:  "/api/*",
+			expectNames: []string{"*"},
+			expectVals:  []string{"x"},
+		},
+		{
+			name:       "nok, no match",
+			whenMethod: http.MethodGet,
+			whenPath:   "/nope",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := e.Lookup(tc.whenMethod, tc.whenPath)
+
+			assert.Equal(t, tc.expectFound, result.Found)
+			if tc.expectRoute != "" {
+				if assert.NotNil(t, result.Route) {
+					assert.Equal(t, tc.expectRoute, result.Route.Path)
+				}
+			} else {
+				assert.Nil(t, result.Route)
+			}
+			assert.Equal(t, tc.expectPath, result.Path)
+			assert.Equal(t, tc.expectNames, result.ParamNames)
+			assert.Equal(t, tc.expectVals, result.ParamValues)
+			assert.Equal(t, tc.expectAllow, result.Allow)
+		})
+	}
+}
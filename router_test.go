@@ -4,6 +4,8 @@
 package echo
 
 import (
+	"bufio"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -677,6 +679,18 @@ func TestRouterStatic(t *testing.T) {
 	assert.Equal(t, path, c.Get("path"))
 }
 
+func TestRouterSplitNodeKeepsHandler(t *testing.T) {
+	e := New()
+	r := e.router
+	r.Add(http.MethodGet, "/users", handlerFunc)
+	r.Add(http.MethodPost, "/u", handlerFunc)
+
+	c := e.NewContext(nil, nil).(*context)
+	r.Find(http.MethodGet, "/users", c)
+	assert.Equal(t, "/users", c.Path())
+	assert.NoError(t, c.handler(c))
+}
+
 func TestRouterNoRoutablePath(t *testing.T) {
 	e := New()
 	r := e.router
@@ -881,8 +895,8 @@ func TestRouterOptionsMethodHandler(t *testing.T) {
 	e.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusNoContent, rec.Code)
-	assert.Equal(t, "OPTIONS, GET", rec.Header().Get(HeaderAllow))
-	assert.Equal(t, "OPTIONS, GET", keyInContext)
+	assert.Equal(t, "OPTIONS, GET, HEAD", rec.Header().Get(HeaderAllow))
+	assert.Equal(t, "OPTIONS, GET, HEAD", keyInContext)
 }
 
 func TestRouterTwoParam(t *testing.T) {
@@ -2666,14 +2680,14 @@ func TestRouterHandleMethodOptions(t *testing.T) {
 			name:              "allows GET and POST handlers",
 			whenMethod:        http.MethodOptions,
 			whenURL:           literal_7186,
-			expectAllowHeader: "OPTIONS, GET, POST",
+			expectAllowHeader: "OPTIONS, GET, POST, HEAD",
 			expectStatus:      http.StatusNoContent,
 		},
 		{
 			name:              "allows GET and PUT handlers",
 			whenMethod:        http.MethodOptions,
 			whenURL:           literal_0618,
-			expectAllowHeader: "OPTIONS, GET, PUT",
+			expectAllowHeader: "OPTIONS, GET, PUT, HEAD",
 			expectStatus:      http.StatusNoContent,
 		},
 		{
@@ -2769,7 +2783,7 @@ func TestRouterAllowHeaderForAnyOtherMethodType(t *testing.T) {
 	err := c.handler(c)
 
 	assert.EqualError(t, err, "code=405, message=Method Not Allowed")
-	assert.ElementsMatch(t, []string{"COPY", "GET", "LOCK", "OPTIONS"}, strings.Split(c.Response().Header().Get(HeaderAllow), ", "))
+	assert.ElementsMatch(t, []string{"COPY", "GET", "HEAD", "LOCK", "OPTIONS"}, strings.Split(c.Response().Header().Get(HeaderAllow), ", "))
 }
 
 func benchmarkRouterRoutes(b *testing.B, routes []*Route, routesToFind []*Route) {
@@ -2833,6 +2847,321 @@ func BenchmarkRouterParamsAndAnyAPI(b *testing.B) {
 	benchmarkRouterRoutes(b, paramAndAnyAPI, paramAndAnyAPIToFind)
 }
 
+func TestRouterParamConstraint(t *testing.T) {
+	e := New()
+	r := e.router
+
+	r.Add(http.MethodGet, "/users/:id<int>", handlerFunc)
+	r.Add(http.MethodGet, "/users/:name", handlerFunc)
+	r.Add(http.MethodGet, "/users/:id<int>/posts", handlerFunc)
+	r.Add(http.MethodGet, "/tags/:slug<[a-z0-9-]+>", handlerFunc)
+	r.Add(http.MethodGet, "/orders/:uuid<uuid>/items", handlerFunc)
+	r.Add(http.MethodGet, "/orders/:id<uint>/items", handlerFunc)
+	r.Add(http.MethodGet, "/codes/:code<(?P<prefix>[A-Z]{2})[0-9]+>", handlerFunc)
+	r.Add(http.MethodGet, "/ops/:op<[<>]=?>", handlerFunc)
+	r.Add(http.MethodGet, `/arrows/:arrow<-\>|\<->`, handlerFunc)
+	r.Add(http.MethodGet, "/brackets/:b<[]<]+>", handlerFunc)
+
+	var testCases = []struct {
+		name        string
+		whenURL     string
+		expectRoute interface{}
+		expectParam map[string]string
+		expectError error
+	}{
+		{
+			name:        "int constraint matches",
+			whenURL:     "/users/42",
+			expectRoute: "/users/:id<int>",
+			expectParam: map[string]string{"id": "42"},
+		},
+		{
+			name:        "unconstrained param is used when constraint does not match",
+			whenURL:     "/users/joe",
+			expectRoute: "/users/:name",
+			expectParam: map[string]string{"name": "joe"},
+		},
+		{
+			name:        "constrained param with children",
+			whenURL:     "/users/42/posts",
+			expectRoute: "/users/:id<int>/posts",
+			expectParam: map[string]string{"id": "42"},
+		},
+		{
+			name:        "non matching constraint with children backtracks to unconstrained leaf param",
+			whenURL:     "/users/joe/posts",
+			expectRoute: "/users/:name",
+			expectParam: map[string]string{"name": "joe/posts"},
+		},
+		{
+			name:        "regexp constraint matches",
+			whenURL:     "/tags/go-lang-1",
+			expectRoute: "/tags/:slug<[a-z0-9-]+>",
+			expectParam: map[string]string{"slug": "go-lang-1"},
+		},
+		{
+			name:        "regexp constraint must match whole value",
+			whenURL:     "/tags/Go",
+			expectRoute: nil,
+			expectError: ErrNotFound,
+		},
+		{
+			name:        "uuid constraint",
+			whenURL:     "/orders/4b4bcd2f-0ff8-4d5a-9c5f-2f4c7e8f9a10/items",
+			expectRoute: "/orders/:uuid<uuid>/items",
+			expectParam: map[string]string{"uuid": "4b4bcd2f-0ff8-4d5a-9c5f-2f4c7e8f9a10"},
+		},
+		{
+			name:        "backtrack to next constrained sibling",
+			whenURL:     "/orders/123/items",
+			expectRoute: "/orders/:id<uint>/items",
+			expectParam: map[string]string{"id": "123"},
+		},
+		{
+			name:        "no constrained sibling matches",
+			whenURL:     "/orders/abc/items",
+			expectRoute: nil,
+			expectError: ErrNotFound,
+		},
+		{
+			name:        "constraint with nested angle brackets",
+			whenURL:     "/codes/AB123",
+			expectRoute: "/codes/:code<(?P<prefix>[A-Z]{2})[0-9]+>",
+			expectParam: map[string]string{"code": "AB123"},
+		},
+		{
+			name:        "constraint with angle brackets in character class",
+			whenURL:     "/ops/<=",
+			expectRoute: "/ops/:op<[<>]=?>",
+			expectParam: map[string]string{"op": "<="},
+		},
+		{
+			name:        "constraint with escaped angle brackets",
+			whenURL:     "/arrows/<-",
+			expectRoute: `/arrows/:arrow<-\>|\<->`,
+			expectParam: map[string]string{"arrow": "<-"},
+		},
+		{
+			name:        "constraint with literal closing bracket at start of character class",
+			whenURL:     "/brackets/]<]",
+			expectRoute: "/brackets/:b<[]<]+>",
+			expectParam: map[string]string{"b": "]<]"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := e.NewContext(nil, nil).(*context)
+
+			r.Find(http.MethodGet, tc.whenURL, c)
+			err := c.handler(c)
+
+			if tc.expectError != nil {
+				assert.Equal(t, tc.expectError, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectRoute, c.Get("path"))
+			for param, expectedValue := range tc.expectParam {
+				assert.Equal(t, expectedValue, c.Param(param))
+			}
+			checkUnusedParamValues(t, c, tc.expectParam)
+		})
+	}
+}
+
+func TestRouterParamConstraintMethodNotAllowed(t *testing.T) {
+	e := New()
+	e.GET("/users/:id<int>", handlerFunc)
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/users/x", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestRouterAutoHead(t *testing.T) {
+	e := New()
+	e.GET("/users", func(c Context) error {
+		c.Response().Header().Set("X-Custom", "yes")
+		return c.String(http.StatusOK, "users")
+	})
+	e.GET("/files", handlerFunc)
+	e.HEAD("/files", func(c Context) error {
+		return c.NoContent(http.StatusTeapot)
+	})
+
+	req := httptest.NewRequest(http.MethodHead, "/users", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "", rec.Body.String())
+	assert.Equal(t, "yes", rec.Header().Get("X-Custom"))
+	assert.Equal(t, "5", rec.Header().Get(HeaderContentLength))
+	assert.Equal(t, MIMETextPlainCharsetUTF8, rec.Header().Get(HeaderContentType))
+
+	req = httptest.NewRequest(http.MethodHead, "/files", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/users", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "OPTIONS, GET, HEAD", rec.Header().Get(HeaderAllow))
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (r *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.hijacked = true
+	return nil, nil, nil
+}
+
+func TestRouterAutoHeadHijack(t *testing.T) {
+	e := New()
+	e.GET("/ws", func(c Context) error {
+		_, _, err := c.Response().Hijack()
+		return err
+	})
+
+	rec := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/ws", nil))
+	assert.True(t, rec.hijacked)
+}
+
+func TestRouterAutoHeadDisabled(t *testing.T) {
+	e := New()
+	e.DisableAutoHead = true
+	e.GET("/users", handlerFunc)
+
+	req := httptest.NewRequest(http.MethodHead, "/users", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "OPTIONS, GET", rec.Header().Get(HeaderAllow))
+}
+
+func TestRouterDisableAutoOptions(t *testing.T) {
+	e := New()
+	e.DisableAutoOptions = true
+	e.GET("/users", handlerFunc)
+
+	req := httptest.NewRequest(http.MethodOptions, "/users", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD", rec.Header().Get(HeaderAllow))
+}
+
+func TestRouterHTTPMethodNotAllowedHandler(t *testing.T) {
+	e := New()
+	var allowed []string
+	e.HTTPMethodNotAllowedHandler = func(c Context, methods []string) error {
+		allowed = methods
+		return c.String(http.StatusMethodNotAllowed, "nope")
+	}
+	e.GET("/users", handlerFunc)
+	e.PUT("/users", handlerFunc)
+
+	req := httptest.NewRequest(http.MethodPost, "/users", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "nope", rec.Body.String())
+	assert.Equal(t, "OPTIONS, GET, PUT, HEAD", rec.Header().Get(HeaderAllow))
+	assert.Equal(t, []string{"OPTIONS", "GET", "PUT", "HEAD"}, allowed)
+
+	req = httptest.NewRequest(http.MethodOptions, "/users", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestRouterParamConstraintInvalid(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		whenPath    string
+		expectPanic string
+	}{
+		{
+			name:        "unterminated constraint",
+			whenPath:    "/users/:id<int/posts",
+			expectPanic: `echo: invalid route param constraint in path "/users/:id<int/posts"`,
+		},
+		{
+			name:        "characters after constraint",
+			whenPath:    "/users/:id<int>x",
+			expectPanic: `echo: invalid route param constraint in path "/users/:id<int>x"`,
+		},
+		{
+			name:        "unterminated character class",
+			whenPath:    "/users/:id<[a-z>",
+			expectPanic: `echo: invalid route param constraint in path "/users/:id<[a-z>"`,
+		},
+		{
+			name:        "invalid regexp",
+			whenPath:    "/users/:id<a{2,1}>",
+			expectPanic: "echo: invalid route param constraint \"<a{2,1}>\": error parsing regexp: invalid repeat count: `{2,1}`",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := New()
+			assert.PanicsWithValue(t, tc.expectPanic, func() {
+				e.router.Add(http.MethodGet, tc.whenPath, handlerFunc)
+			})
+		})
+	}
+}
+
+func TestRouterRemove(t *testing.T) {
+	e := New()
+	r := e.router
+
+	r.Add(http.MethodGet, "/users/:id<int>", handlerFunc)
+	r.Add(http.MethodGet, "/users/:name", handlerFunc)
+	r.Add(http.MethodGet, "/files/\\:undelete", handlerFunc)
+	r.Add(http.MethodGet, "/static/*", handlerFunc)
+	r.Add(RouteNotFound, "/static/*", handlerFunc)
+
+	assert.True(t, r.Remove(http.MethodGet, "/users/:id<int>"))
+	assert.False(t, r.Remove(http.MethodGet, "/users/:id<int>"))
+	assert.False(t, r.Remove(http.MethodPost, "/users/:name"))
+	assert.True(t, r.Remove(http.MethodGet, "/files/\\:undelete"))
+	assert.True(t, r.Remove(http.MethodGet, "/static/*"))
+
+	var testCases = []struct {
+		whenURL     string
+		expectRoute interface{}
+	}{
+		{whenURL: "/users/1", expectRoute: "/users/:name"},
+		{whenURL: "/files/:undelete", expectRoute: nil},
+		{whenURL: "/static/app.js", expectRoute: "/static/*"}, // RouteNotFound handler is still registered
+	}
+	for _, tc := range testCases {
+		t.Run(tc.whenURL, func(t *testing.T) {
+			c := e.NewContext(nil, nil).(*context)
+			r.Find(http.MethodGet, tc.whenURL, c)
+			_ = c.handler(c)
+			assert.Equal(t, tc.expectRoute, c.Get("path"))
+		})
+	}
+
+	assert.True(t, r.Remove(RouteNotFound, "/static/*"))
+	c := e.NewContext(nil, nil).(*context)
+	r.Find(http.MethodGet, "/static/app.js", c)
+	assert.Equal(t, ErrNotFound, c.handler(c))
+}
+
 const literal_4602 = "/authorizations/:id"
 
 const literal_7620 = "/notifications/threads/:id/subscription"
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import "sync/atomic"
+
+// routingTable is immutable snapshot of routers that is used to serve requests.
+type routingTable struct {
+	router       *Router
+	routers      map[string]*Router
+	hostPatterns []*hostPattern
+	maxParam     int
+}
+
+// loadRouting returns routing snapshot for serving requests. First call marks routing as live - from that moment
+// on all route changes are done with copy-on-write.
+func (e *Echo) loadRouting() *routingTable {
+	if atomic.LoadUint32(&e.routingLive) == 0 {
+		e.routesMutex.Lock()
+		if atomic.LoadUint32(&e.routingLive) == 0 {
+			// routers are re-indexed here when case sensitivity was changed after routes were added, from now on
+			// routers are shared with requests and must not change in place
+			e.router.syncCaseFolding()
+			for _, r := range e.routers {
+				r.syncCaseFolding()
+			}
+			e.publishRouting()
+			atomic.StoreUint32(&e.routingLive, 1)
+		}
+		e.routesMutex.Unlock()
+	}
+	return e.routing.Load().(*routingTable)
+}
+
+// currentRouting returns routing snapshot without marking routing as live.
+func (e *Echo) currentRouting() *routingTable {
+	if atomic.LoadUint32(&e.routingLive) == 1 {
+		return e.routing.Load().(*routingTable)
+	}
+	return &routingTable{router: e.router, routers: e.routers, hostPatterns: e.hostPatterns, maxParam: *e.maxParam}
+}
+
+func (e *Echo) maxParamCount() int {
+	if atomic.LoadUint32(&e.routingLive) == 1 {
+		return e.routing.Load().(*routingTable).maxParam
+	}
+	return *e.maxParam
+}
+
+// publishRouting stores current routers as new routing snapshot. Must be called with routesMutex locked.
+func (e *Echo) publishRouting() {
+	e.routing.Store(&routingTable{
+		router:       e.router,
+		routers:      e.routers,
+		hostPatterns: e.hostPatterns,
+		maxParam:     *e.maxParam,
+	})
+}
+
+// updateRouter applies change to router of the host. Before routing is live router is changed in place, after that
+// change is applied to copy of the router which then replaces the original in new routing snapshot.
+func (e *Echo) updateRouter(host string, change func(router *Router)) {
+	e.routesMutex.Lock()
+	defer e.routesMutex.Unlock()
+
+	router := e.findRouter(host)
+	if atomic.LoadUint32(&e.routingLive) == 0 {
+		change(router)
+		return
+	}
+
+	clone := router.clone()
+	change(clone)
+
+	if e.router == router {
+		e.router = clone
+	}
+	routers := make(map[string]*Router, len(e.routers))
+	for k, v := range e.routers {
+		if v == router {
+			v = clone
+		}
+		routers[k] = v
+	}
+	e.routers = routers
+	hostPatterns := make([]*hostPattern, len(e.hostPatterns))
+	for i, p := range e.hostPatterns {
+		if p.router == router {
+			cp := *p
+			cp.router = clone
+			p = &cp
+		}
+		hostPatterns[i] = p
+	}
+	e.hostPatterns = hostPatterns
+
+	e.publishRouting()
+}
+
+// addHostRouter adds new router for host name (or host pattern).
+func (e *Echo) addHostRouter(name string) {
+	e.routesMutex.Lock()
+	defer e.routesMutex.Unlock()
+
+	live := atomic.LoadUint32(&e.routingLive) == 1
+	if live {
+		// copy-on-write: published routing snapshot references current map and slice
+		routers := make(map[string]*Router, len(e.routers)+1)
+		for k, v := range e.routers {
+			routers[k] = v
+		}
+		e.routers = routers
+		e.hostPatterns = append([]*hostPattern(nil), e.hostPatterns...)
+	}
+	router := NewRouter(e)
+	if isHostPattern(name) {
+		e.addHostPattern(name, router)
+	}
+	e.routers[name] = router
+	if live {
+		e.publishRouting()
+	}
+}
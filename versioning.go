@@ -0,0 +1,380 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VersioningConfig defines the config for versioned routes.
+type VersioningConfig struct {
+	// VersionLookup is a string in the form of "<source>:<name>" or "<source>:<name>,<source>:<name>" that is used
+	// to extract API version from the request. Sources are tried in given order and first found version is used.
+	// Optional. Default value "header:X-API-Version".
+	// Possible values:
+	// - "header:<name>" - version from request header i.e. `X-API-Version: 2`
+	// - "query:<name>" - version from query parameter i.e. `?version=2`
+	// - "accept:<name>" - version from `Accept` header media type parameter i.e. `Accept: application/json; version=2`
+	// - "vendor:<name>" - version from `Accept` header vendor media type i.e. `Accept: application/vnd.<name>.v2+json`
+	// - "path:<name>" - version from path prefix i.e. `/v2/users`. Routes are registered with `/:<name>` prefix.
+	VersionLookup string
+
+	// Default is version used when request does not specify version. When empty, requests without version are
+	// rejected with `ErrAPIVersionRequired`.
+	// Optional.
+	Default string
+
+	// Policies defines deprecation policies for versions. Key is version.
+	// Optional.
+	Policies map[string]VersionPolicy
+}
+
+// VersionPolicy defines deprecation of API version. Versioned routes add `Deprecation`, `Sunset` and `Link` headers
+// to responses of deprecated versions.
+type VersionPolicy struct {
+	// Deprecated marks version as deprecated. Response has `Deprecation: true` header.
+	Deprecated bool
+
+	// DeprecatedAt is the time when version was deprecated. Response has `Deprecation: @<unix timestamp>` header.
+	DeprecatedAt time.Time
+
+	// Sunset is the time when version will stop being available. Response has `Sunset: <HTTP date>` header (RFC 8594).
+	Sunset time.Time
+
+	// Link is URL of documentation about deprecation or migration to newer version. Response has
+	// `Link: <url>; rel="deprecation"` header.
+	Link string
+}
+
+// Versioning registers routes that have different handlers for different API versions on the same method and path.
+// See `Echo#Versioning()`.
+type Versioning struct {
+	config     VersioningConfig
+	extractors []versionExtractor
+	pathParam  string
+	add        func(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) *Route
+
+	mu       sync.RWMutex
+	versions map[string]bool
+	routes   map[string]*versionedRoute
+}
+
+// versionedRoute is a route registered in the router that dispatches requests to handlers of the versions.
+type versionedRoute struct {
+	route    *Route
+	handlers map[string]HandlerFunc
+}
+
+// VersionGroup is a set of routes for a single API version.
+type VersionGroup struct {
+	versioning *Versioning
+	version    string
+	middleware []MiddlewareFunc
+}
+
+type versionExtractor func(c Context) string
+
+var (
+	// ErrAPIVersionRequired is returned when request does not specify API version and there is no default version.
+	ErrAPIVersionRequired = NewHTTPError(http.StatusBadRequest, "API version is required")
+	// ErrAPIVersionUnsupported is returned when request specifies API version that is not known.
+	ErrAPIVersionUnsupported = NewHTTPError(http.StatusBadRequest, "unsupported API version")
+	// ErrAPIVersionNotAvailable is returned when route exists but not for the requested API version.
+	ErrAPIVersionNotAvailable = NewHTTPError(http.StatusNotFound, "route is not available in requested API version")
+)
+
+// APIVersionContext is implemented by Context holding API version chosen for the request by versioned routes. Context
+// created by Echo implements it. Custom Context implementations wrapping Echo context should delegate these methods
+// to the wrapped context.
+type APIVersionContext interface {
+	// APIVersion returns API version chosen for the request by versioned routes (see `Echo#Versioning()`).
+	// Returns empty string for routes that are not versioned.
+	APIVersion() string
+
+	// SetAPIVersion sets API version of the request.
+	SetAPIVersion(version string)
+}
+
+// APIVersion returns API version chosen for the request by versioned routes (see `Echo#Versioning()`). Returns empty
+// string for routes that are not versioned or when context does not implement `APIVersionContext`.
+func APIVersion(c Context) string {
+	if vc, ok := c.(APIVersionContext); ok {
+		return vc.APIVersion()
+	}
+	return ""
+}
+
+// DefaultVersioningConfig is the default versioning config.
+var DefaultVersioningConfig = VersioningConfig{
+	VersionLookup: "header:X-API-Version",
+}
+
+// Versioning creates versioned routes registry with config. Handlers for each version are registered with
+// `Versioning#Version()` groups. Chosen version is available with `APIVersion()`.
+//
+// Example:
+//
+//	api := e.Versioning(echo.VersioningConfig{
+//		VersionLookup: "header:X-API-Version,vendor:company",
+//		Default:       "1",
+//		Policies:      map[string]echo.VersionPolicy{"1": {Deprecated: true, Sunset: sunset}},
+//	})
+//	api.Version("1").GET("/users", listUsersV1)
+//	api.Version("2").GET("/users", listUsersV2)
+//
+// Versions are compared without leading `v` so `v2`, `V2` and `2` are the same version.
+func (e *Echo) Versioning(config VersioningConfig) *Versioning {
+	return newVersioning(config, e.Add)
+}
+
+// Versioning implements `Echo#Versioning()` for sub-routes within the Group.
+func (g *Group) Versioning(config VersioningConfig) *Versioning {
+	return newVersioning(config, g.Add)
+}
+
+func newVersioning(config VersioningConfig, add func(string, string, HandlerFunc, ...MiddlewareFunc) *Route) *Versioning {
+	if config.VersionLookup == "" {
+		config.VersionLookup = DefaultVersioningConfig.VersionLookup
+	}
+	v := &Versioning{
+		config:   config,
+		add:      add,
+		versions: map[string]bool{},
+		routes:   map[string]*versionedRoute{},
+	}
+	for _, source := range strings.Split(config.VersionLookup, ",") {
+		parts := strings.SplitN(strings.TrimSpace(source), ":", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			panic(fmt.Sprintf("echo: invalid version lookup %q", source))
+		}
+		name := parts[1]
+		switch parts[0] {
+		case "header":
+			v.extractors = append(v.extractors, func(c Context) string {
+				c.Response().Header().Add(HeaderVary, name)
+				return c.Request().Header.Get(name)
+			})
+		case "query":
+			v.extractors = append(v.extractors, func(c Context) string {
+				return c.QueryParam(name)
+			})
+		case "accept":
+			v.extractors = append(v.extractors, func(c Context) string {
+				c.Response().Header().Add(HeaderVary, HeaderAccept)
+				return mediaTypeParamVersion(c.Request().Header.Get(HeaderAccept), name)
+			})
+		case "vendor":
+			v.extractors = append(v.extractors, func(c Context) string {
+				c.Response().Header().Add(HeaderVary, HeaderAccept)
+				return vendorMediaTypeVersion(c.Request().Header.Get(HeaderAccept), name)
+			})
+		case "path":
+			if v.pathParam != "" {
+				panic("echo: version lookup can have only one path source")
+			}
+			v.pathParam = name
+			v.extractors = append(v.extractors, func(c Context) string {
+				return c.Param(name)
+			})
+		default:
+			panic(fmt.Sprintf("echo: invalid version lookup source %q", parts[0]))
+		}
+	}
+	return v
+}
+
+// Version returns group for registering routes of the version with optional version-level middleware.
+func (v *Versioning) Version(version string, m ...MiddlewareFunc) *VersionGroup {
+	version = normalizeVersion(version)
+	if version == "" {
+		panic("echo: version can not be empty")
+	}
+	v.mu.Lock()
+	v.versions[version] = true
+	v.mu.Unlock()
+	return &VersionGroup{versioning: v, version: version, middleware: m}
+}
+
+// Versions returns all known versions.
+func (v *Versioning) Versions() []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	versions := make([]string, 0, len(v.versions))
+	for version := range v.versions {
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+func (v *Versioning) register(version, method, path string, handler HandlerFunc) *Route {
+	if v.pathParam != "" {
+		path = "/:" + v.pathParam + path
+	}
+	key := method + " " + path
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	vr, ok := v.routes[key]
+	if !ok {
+		vr = &versionedRoute{handlers: map[string]HandlerFunc{}}
+		vr.route = v.add(method, path, func(c Context) error {
+			return v.dispatch(c, key)
+		})
+		v.routes[key] = vr
+	}
+	vr.handlers[version] = handler
+	return vr.route
+}
+
+func (v *Versioning) dispatch(c Context, key string) error {
+	version := ""
+	for _, extractor := range v.extractors {
+		if version = extractor(c); version != "" {
+			break
+		}
+	}
+	if version == "" {
+		if v.config.Default == "" {
+			return ErrAPIVersionRequired
+		}
+		version = v.config.Default
+	}
+	version = normalizeVersion(version)
+
+	v.mu.RLock()
+	known := v.versions[version]
+	handler := v.routes[key].handlers[version]
+	v.mu.RUnlock()
+
+	if !known {
+		return ErrAPIVersionUnsupported.WithInternal(fmt.Errorf("unsupported API version %q", version))
+	}
+	if handler == nil {
+		return ErrAPIVersionNotAvailable.WithInternal(fmt.Errorf("route %s is not available in API version %q", key, version))
+	}
+
+	if vc, ok := c.(APIVersionContext); ok {
+		vc.SetAPIVersion(version)
+	}
+	if policy, ok := v.policy(version); ok {
+		policy.writeHeaders(c.Response().Header())
+	}
+	return handler(c)
+}
+
+func (v *Versioning) policy(version string) (VersionPolicy, bool) {
+	for k, p := range v.config.Policies {
+		if normalizeVersion(k) == version {
+			return p, true
+		}
+	}
+	return VersionPolicy{}, false
+}
+
+func (p VersionPolicy) writeHeaders(h http.Header) {
+	if !p.DeprecatedAt.IsZero() {
+		h.Set("Deprecation", "@"+strconv.FormatInt(p.DeprecatedAt.Unix(), 10))
+	} else if p.Deprecated {
+		h.Set("Deprecation", "true")
+	}
+	if !p.Sunset.IsZero() {
+		h.Set("Sunset", p.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if p.Link != "" {
+		h.Add("Link", "<"+p.Link+">; rel=\"deprecation\"")
+	}
+}
+
+// Add registers a new versioned route for an HTTP method and path with matching handler and optional route-level
+// middleware. Route with the same method and path can be registered for multiple versions. Returned route is
+// shared by all versions of the method and path.
+func (g *VersionGroup) Add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) *Route {
+	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
+	m = append(m, g.middleware...)
+	m = append(m, middleware...)
+	return g.versioning.register(g.version, method, path, applyMiddleware(handler, m...))
+}
+
+// DELETE implements `Echo#DELETE()` for routes of the version.
+func (g *VersionGroup) DELETE(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.Add(http.MethodDelete, path, h, m...)
+}
+
+// GET implements `Echo#GET()` for routes of the version.
+func (g *VersionGroup) GET(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.Add(http.MethodGet, path, h, m...)
+}
+
+// HEAD implements `Echo#HEAD()` for routes of the version.
+func (g *VersionGroup) HEAD(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.Add(http.MethodHead, path, h, m...)
+}
+
+// OPTIONS implements `Echo#OPTIONS()` for routes of the version.
+func (g *VersionGroup) OPTIONS(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.Add(http.MethodOptions, path, h, m...)
+}
+
+// PATCH implements `Echo#PATCH()` for routes of the version.
+func (g *VersionGroup) PATCH(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.Add(http.MethodPatch, path, h, m...)
+}
+
+// POST implements `Echo#POST()` for routes of the version.
+func (g *VersionGroup) POST(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.Add(http.MethodPost, path, h, m...)
+}
+
+// PUT implements `Echo#PUT()` for routes of the version.
+func (g *VersionGroup) PUT(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.Add(http.MethodPut, path, h, m...)
+}
+
+func normalizeVersion(version string) string {
+	version = strings.TrimSpace(version)
+	if len(version) > 1 && (version[0] == 'v' || version[0] == 'V') {
+		version = version[1:]
+	}
+	return version
+}
+
+// mediaTypeParamVersion returns value of media type parameter from the first media range in Accept header that
+// has the parameter i.e. `application/json; version=2`.
+func mediaTypeParamVersion(accept, param string) string {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		parts := strings.Split(mediaRange, ";")
+		for _, p := range parts[1:] {
+			kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+			if len(kv) == 2 && strings.EqualFold(strings.TrimSpace(kv[0]), param) {
+				return strings.Trim(strings.TrimSpace(kv[1]), `"`)
+			}
+		}
+	}
+	return ""
+}
+
+// vendorMediaTypeVersion returns version from the first vendor media type in Accept header
+// i.e. `application/vnd.company.v2+json`.
+func vendorMediaTypeVersion(accept, vendor string) string {
+	prefix := "application/vnd." + strings.ToLower(vendor) + ".v"
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(mediaRange, ";", 2)[0]))
+		if !strings.HasPrefix(mediaType, prefix) {
+			continue
+		}
+		version := mediaType[len(prefix):]
+		if i := strings.IndexByte(version, '+'); i != -1 {
+			version = version[:i]
+		}
+		if version != "" {
+			return version
+		}
+	}
+	return ""
+}
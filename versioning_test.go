@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: © 2015 LabStack LLC and Echo contributors
+
+package echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEchoVersioning(t *testing.T) {
+	sunset := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	e := New()
+	api := e.Versioning(VersioningConfig{
+		VersionLookup: "header:X-API-Version,query:version,vendor:company,accept:version",
+		Default:       "1",
+		Policies: map[string]VersionPolicy{
+			"v1": {Deprecated: true, Sunset: sunset, Link: "https://example.com/migrate"},
+		},
+	})
+	handler := func(c Context) error {
+		return c.String(http.StatusOK, c.Path()+" v"+APIVersion(c))
+	}
+	api.Version("1").GET("/users", handler)
+	api.Version("v2").GET("/users", handler)
+	api.Version("2").POST("/users", handler)
+	api.Version("3")
+
+	var testCases = []struct {
+		name          string
+		whenMethod    string
+		whenURL       string
+		whenHeader    map[string]string
+		expectCode    int
+		expectBody    string
+		expectHeaders map[string]string
+	}{
+		{
+			name:       "ok, default version with deprecation headers",
+			whenURL:    "/users",
+			expectCode: http.StatusOK,
+			expectBody: "/users v1",
+			expectHeaders: map[string]string{
+				"Deprecation": "true",
+				"Sunset":      "Wed, 02 Jan 2030 03:04:05 GMT",
+				"Link":        `<https://example.com/migrate>; rel="deprecation"`,
+			},
+		},
+		{
+			name:          "ok, version from header",
+			whenURL:       "/users",
+			whenHeader:    map[string]string{"X-API-Version": "2"},
+			expectCode:    http.StatusOK,
+			expectBody:    "/users v2",
+			expectHeaders: map[string]string{"Deprecation": "", "Vary": "X-API-Version"},
+		},
+		{
+			name:       "ok, version from query",
+			whenURL:    "/users?version=v2",
+			expectCode: http.StatusOK,
+			expectBody: "/users v2",
+		},
+		{
+			name:       "ok, version from vendor media type",
+			whenURL:    "/users",
+			whenHeader: map[string]string{HeaderAccept: "text/html, application/vnd.company.v2+json"},
+			expectCode: http.StatusOK,
+			expectBody: "/users v2",
+		},
+		{
+			name:       "ok, version from media type parameter",
+			whenURL:    "/users",
+			whenHeader: map[string]string{HeaderAccept: `application/json; q=0.9; version="2"`},
+			expectCode: http.StatusOK,
+			expectBody: "/users v2",
+		},
+		{
+			name:       "ok, header source has priority",
+			whenURL:    "/users?version=1",
+			whenHeader: map[string]string{"X-API-Version": "2"},
+			expectCode: http.StatusOK,
+			expectBody: "/users v2",
+		},
+		{
+			name:       "ok, route registered only for one version",
+			whenMethod: http.MethodPost,
+			whenURL:    "/users",
+			whenHeader: map[string]string{"X-API-Version": "2"},
+			expectCode: http.StatusOK,
+			expectBody: "/users v2",
+		},
+		{
+			name:       "nok, route not available in known version",
+			whenMethod: http.MethodPost,
+			whenURL:    "/users",
+			whenHeader: map[string]string{"X-API-Version": "3"},
+			expectCode: http.StatusNotFound,
+			expectBody: "{\"message\":\"route is not available in requested API version\"}\n",
+		},
+		{
+			name:       "nok, unknown version",
+			whenURL:    "/users",
+			whenHeader: map[string]string{"X-API-Version": "9"},
+			expectCode: http.StatusBadRequest,
+			expectBody: "{\"message\":\"unsupported API version\"}\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := http.MethodGet
+			if tc.whenMethod != "" {
+				method = tc.whenMethod
+			}
+			req := httptest.NewRequest(method, tc.whenURL, nil)
+			for k, v := range tc.whenHeader {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			assert.Equal(t, tc.expectBody, rec.Body.String())
+			for k, v := range tc.expectHeaders {
+				assert.Equal(t, v, rec.Header().Get(k))
+			}
+		})
+	}
+
+	versions := api.Versions()
+	sort.Strings(versions)
+	assert.Equal(t, []string{"1", "2", "3"}, versions)
+}
+
+func TestEchoVersioningPath(t *testing.T) {
+	e := New()
+	g := e.Group("/api")
+	api := g.Versioning(VersioningConfig{VersionLookup: "path:version"})
+	var mwCalled bool
+	v2 := api.Version("2", func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			mwCalled = true
+			return next(c)
+		}
+	})
+	r := v2.GET("/users/:id", func(c Context) error {
+		return c.String(http.StatusOK, c.(APIVersionContext).APIVersion()+" "+c.Param("id"))
+	})
+	assert.Equal(t, "/api/:version/users/:id", r.Path)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v2/users/7", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2 7", rec.Body.String())
+	assert.True(t, mwCalled)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users/7", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestEchoVersioningRequired(t *testing.T) {
+	e := New()
+	api := e.Versioning(VersioningConfig{})
+	api.Version("1").GET("/", func(c Context) error { return c.NoContent(http.StatusOK) })
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "{\"message\":\"API version is required\"}\n", rec.Body.String())
+}
+
+func TestEchoVersioningInvalidLookup(t *testing.T) {
+	e := New()
+	assert.PanicsWithValue(t, `echo: invalid version lookup source "cookie"`, func() {
+		e.Versioning(VersioningConfig{VersionLookup: "cookie:version"})
+	})
+	assert.PanicsWithValue(t, `echo: invalid version lookup "header"`, func() {
+		e.Versioning(VersioningConfig{VersionLookup: "header"})
+	})
+}
+
+func TestEchoVersioningDeprecatedAt(t *testing.T) {
+	h := http.Header{}
+	VersionPolicy{DeprecatedAt: time.Unix(1700000000, 0)}.writeHeaders(h)
+	assert.Equal(t, "@1700000000", h.Get("Deprecation"))
+}